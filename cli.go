@@ -4,6 +4,7 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -149,14 +150,14 @@ func (c *CLI) AskInput(question string, defaultValue string) string {
 // SelectDevice 交互式选择设备
 func (c *CLI) SelectDevice(devices []*DeviceInfo, bestMatch *DeviceInfo) (*DeviceInfo, error) {
 	if len(devices) == 0 {
-		return nil, fmt.Errorf("没有可选择的设备")
+		return nil, errors.New(T("cli.device.no_choices"))
 	}
 
 	// 只有一个设备的情况
 	if len(devices) == 1 {
 		dev := devices[0]
 		fmt.Println()
-		fmt.Println(c.colorize(ColorBold, "检测到 1 个设备:"))
+		fmt.Println(c.colorize(ColorBold, T("cli.device.detected_1")))
 		fmt.Println()
 
 		deviceName := dev.FriendlyName
@@ -176,20 +177,20 @@ func (c *CLI) SelectDevice(devices []*DeviceInfo, bestMatch *DeviceInfo) (*Devic
 
 		fmt.Printf("  %s %s\n", c.colorize(statusColor, statusIcon), deviceName)
 		if dev.Status != "" {
-			fmt.Printf("  状态: %s\n", c.colorize(statusColor, dev.Status))
+			fmt.Println("  " + T("cli.device.status", c.colorize(statusColor, dev.Status)))
 		}
-		fmt.Printf("  设备ID: %s\n", dev.InstanceID)
+		fmt.Println("  " + T("cli.device.id", dev.InstanceID))
 		fmt.Println()
 
 		// 单设备也需要确认
-		if c.AskYesNo("是否使用此设备", true) {
+		if c.AskYesNo(T("cli.device.use_this"), true) {
 			return dev, nil
 		}
-		return nil, fmt.Errorf("用户取消选择")
+		return nil, errors.New(T("cli.device.cancelled"))
 	}
 
 	fmt.Println()
-	fmt.Println(c.colorize(ColorBold, "检测到以下设备:"))
+	fmt.Println(c.colorize(ColorBold, T("cli.device.detected_n")))
 	fmt.Println()
 
 	for i, dev := range devices {
@@ -209,7 +210,7 @@ func (c *CLI) SelectDevice(devices []*DeviceInfo, bestMatch *DeviceInfo) (*Devic
 		// 标记推荐设备
 		recommended := ""
 		if bestMatch != nil && dev.InstanceID == bestMatch.InstanceID {
-			recommended = c.colorize(ColorGreen+ColorBold, " [推荐]")
+			recommended = c.colorize(ColorGreen+ColorBold, T("cli.device.recommended"))
 		}
 
 		// 设备名称
@@ -226,13 +227,13 @@ func (c *CLI) SelectDevice(devices []*DeviceInfo, bestMatch *DeviceInfo) (*Devic
 
 		// 显示状态
 		if dev.Status != "" {
-			fmt.Printf("      状态: %s\n", c.colorize(statusColor, dev.Status))
+			fmt.Println("      " + T("cli.device.status", c.colorize(statusColor, dev.Status)))
 		}
 
 		// 显示匹配度
 		score := dev.Score()
 		if score > 0 {
-			fmt.Printf("      匹配度: %d 分\n", score)
+			fmt.Println("      " + T("cli.device.score", score))
 		}
 
 		// 显示设备 ID（截断显示）
@@ -259,12 +260,12 @@ func (c *CLI) SelectDevice(devices []*DeviceInfo, bestMatch *DeviceInfo) (*Devic
 	// 询问用户选择
 	for {
 		input := c.AskInput(
-			fmt.Sprintf("请选择设备 (1-%d)", len(devices)),
+			fmt.Sprintf(T("cli.device.choose"), len(devices)),
 			strconv.Itoa(defaultChoice))
 
 		choice, err := strconv.Atoi(input)
 		if err != nil || choice < 1 || choice > len(devices) {
-			c.PrintError("无效的选择，请输入 1-%d", len(devices))
+			c.PrintError(T("cli.device.bad_choice"), len(devices))
 			continue
 		}
 
@@ -275,13 +276,13 @@ func (c *CLI) SelectDevice(devices []*DeviceInfo, bestMatch *DeviceInfo) (*Devic
 // PrintDeviceList 打印设备列表
 func (c *CLI) PrintDeviceList(devices []*DeviceInfo) {
 	if len(devices) == 0 {
-		c.PrintWarning("未找到任何设备")
+		c.PrintWarning("%s", T("cli.device.none"))
 		return
 	}
 
 	for i, dev := range devices {
 		fmt.Println()
-		fmt.Printf("设备 #%d\n", i+1)
+		fmt.Println(T("cli.device.header", i+1))
 		fmt.Println(strings.Repeat("-", 50))
 
 		statusIcon := "○"
@@ -294,20 +295,20 @@ func (c *CLI) PrintDeviceList(devices []*DeviceInfo) {
 			statusColor = ColorGreen
 		}
 
-		fmt.Printf("名称: %s\n", dev.FriendlyName)
-		fmt.Printf("状态: %s %s\n", c.colorize(statusColor, statusIcon), dev.Status)
-		fmt.Printf("实例ID: %s\n", dev.InstanceID)
+		fmt.Println(T("cli.device.name", dev.FriendlyName))
+		fmt.Println(T("cli.device.status", c.colorize(statusColor, statusIcon)+" "+dev.Status))
+		fmt.Println(T("cli.device.instance", dev.InstanceID))
 
 		if dev.Class != "" {
-			fmt.Printf("类别: %s\n", dev.Class)
+			fmt.Println(T("cli.device.class", dev.Class))
 		}
 		if dev.Manufacturer != "" {
-			fmt.Printf("制造商: %s\n", dev.Manufacturer)
+			fmt.Println(T("cli.device.vendor", dev.Manufacturer))
 		}
 
 		score := dev.Score()
 		if score > 0 {
-			fmt.Printf("匹配度: %d 分\n", score)
+			fmt.Println(T("cli.device.score", score))
 		}
 	}
 	fmt.Println()
@@ -358,9 +359,9 @@ func IsAdmin() bool {
 // CheckAdminAndWarn 检查管理员权限并给出警告
 func (c *CLI) CheckAdminAndWarn() bool {
 	if !IsAdmin() {
-		c.PrintWarning("未以管理员身份运行！")
-		c.PrintInfo("设备操作需要管理员权限。")
-		c.PrintInfo("请右键程序 → '以管理员身份运行'")
+		c.PrintWarning("%s", T("cli.not_admin"))
+		c.PrintInfo("%s", T("cli.device_needs_admin"))
+		c.PrintInfo("%s", T("cli.run_as_admin_hint"))
 		fmt.Println()
 		return false
 	}
@@ -409,21 +410,21 @@ func EnsureAdmin(cli *CLI) bool {
 		return false // 已是管理员，不需要退出
 	}
 
-	cli.PrintWarning("需要管理员权限才能执行此操作")
+	cli.PrintWarning("%s", T("cli.need_admin_prompt"))
 	fmt.Println()
 
-	if cli.AskYesNo("是否自动以管理员身份重新运行", true) {
-		cli.PrintInfo("正在请求管理员权限...")
+	if cli.AskYesNo(T("cli.ask_elevate"), true) {
+		cli.PrintInfo("%s", T("cli.elevating"))
 		if RunElevated() {
-			cli.PrintSuccess("已在新窗口中以管理员身份启动程序")
-			cli.PrintInfo("请在新窗口中继续操作")
+			cli.PrintSuccess("%s", T("cli.elevated"))
+			cli.PrintInfo("%s", T("cli.continue_in_new"))
 			return true // 请求提升成功，当前进程应退出
 		}
-		cli.PrintError("提升权限失败，可能是用户取消了 UAC 提示")
-		cli.PrintInfo("请右键程序 → '以管理员身份运行'")
+		cli.PrintError("%s", T("cli.elevate_failed"))
+		cli.PrintInfo("%s", T("cli.run_as_admin_hint"))
 		return false
 	}
 
-	cli.PrintInfo("请右键程序 → '以管理员身份运行'")
+	cli.PrintInfo("%s", T("cli.run_as_admin_hint"))
 	return false
 }
@@ -0,0 +1,23 @@
+// Package main provides the clock abstraction used to make time-dependent logic testable.
+package main
+
+import "time"
+
+// Clock 时间来源；服务中使用系统时钟，测试中注入可控的假时钟
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock 系统时钟
+type systemClock struct{}
+
+// Now 当前时间
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Sleep 等待 d
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// After 等待 d 后从返回的通道收到当前时间
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
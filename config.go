@@ -2,37 +2,78 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // Config 配置结构
 type Config struct {
-	DeviceInstanceID string   `json:"device_instance_id"`
-	DeviceName       string   `json:"device_name,omitempty"` // 设备友好名称
-	WaitSeconds      int      `json:"wait_seconds"`
-	BackupDevices    []string `json:"backup_devices,omitempty"` // 备选设备列表
-	AutoDetect       bool     `json:"auto_detect,omitempty"`    // 是否自动检测
-	LogLevel         string   `json:"log_level,omitempty"`      // 日志级别
-	LogDir           string   `json:"log_dir,omitempty"`        // 日志目录
+	DeviceInstanceID string            `json:"device_instance_id"`
+	DeviceName       string            `json:"device_name,omitempty"` // 设备友好名称
+	WaitSeconds      int               `json:"wait_seconds"`
+	BackupDevices    []string          `json:"backup_devices,omitempty"`  // 备选设备列表
+	AutoDetect       bool              `json:"auto_detect,omitempty"`     // 是否自动检测
+	LogLevel         string            `json:"log_level,omitempty"`       // 日志级别
+	LogTagLevels     map[string]string `json:"log_tag_levels,omitempty"`  // 按标签覆盖日志级别，如 {"CHECK": "DEBUG"}
+	LogDir           string            `json:"log_dir,omitempty"`         // 日志目录
+	LogFormat        string            `json:"log_format,omitempty"`      // 日志文件格式（text/json）
+	Syslog           *SyslogConfig     `json:"syslog,omitempty"`          // 同时发送日志到 syslog 采集端（可选）
+	WakeTraceFile    string            `json:"wake_trace_file,omitempty"` // 记录电源事件轨迹（JSONL，相对路径位于日志目录）
 
 	// 智能检测配置
 	CheckBeforeReset   bool `json:"check_before_reset,omitempty"`   // 修复前先检查状态
 	ResumeDelaySeconds int  `json:"resume_delay_seconds,omitempty"` // 唤醒后等待秒数
 	LogAllEvents       bool `json:"log_all_events,omitempty"`       // 记录所有事件（包括跳过的）
 
+	// 盖子、电源和会话事件触发条件
+	WakeTriggers *WakeTriggerConfig `json:"wake_triggers,omitempty"`
+
+	// 修复前后运行的钩子命令
+	Hooks *HooksConfig `json:"hooks,omitempty"`
+
 	// 通知配置
-	EnableNotification bool `json:"enable_notification,omitempty"` // 启用 Windows 通知
+	EnableNotification bool                      `json:"enable_notification,omitempty"` // 启用通知
+	Notifications      []NotificationSinkConfig  `json:"notifications,omitempty"`       // 通知渠道（未配置时只发送 Windows Toast）
+	NotificationPolicy *NotificationPolicyConfig `json:"notification_policy,omitempty"` // 限流、去重、静默时段和每日汇总
+
+	// 界面语言和通知文本
+	Language              string                                `json:"language,omitempty"`               // 界面语言（zh-CN/en-US/auto，默认跟随系统）
+	NotificationTemplates map[string]NotificationTemplateConfig `json:"notification_templates,omitempty"` // 按事件自定义通知标题和正文
 
 	// 日志管理
-	MaxLogDays int `json:"max_log_days,omitempty"` // 日志保留天数
+	MaxLogDays    int  `json:"max_log_days,omitempty"`     // 日志保留天数
+	MaxLogSizeMB  int  `json:"max_log_size_mb,omitempty"`  // 单个日志文件大小上限（MB），超过后轮转
+	MaxLogFiles   int  `json:"max_log_files,omitempty"`    // 最多保留的日志文件数（0=不限制）
+	MaxLogTotalMB int  `json:"max_log_total_mb,omitempty"` // 日志总大小上限（MB，0=不限制）
+	CompressLogs  bool `json:"compress_logs,omitempty"`    // 轮转后的日志是否 gzip 压缩
 
 	// 修复重试配置
 	MaxRetryCount     int `json:"max_retry_count,omitempty"`     // 连续失败最大重试次数（0=无限制）
 	RetryIntervalSecs int `json:"retry_interval_secs,omitempty"` // 基础重试间隔（秒）
 	MaxRetryInterval  int `json:"max_retry_interval,omitempty"`  // 最大重试间隔（秒，用于退避）
+
+	// 设备状态频繁变化（抖动）时暂停自动修复，稳定后恢复（为空时使用默认阈值）
+	FlapDetection *FlapConfig `json:"flap_detection,omitempty"`
+
+	// 重试间隔策略和自动修复次数上限（为空时按基础间隔指数退避、不限制次数）
+	RetryPolicy *RetryPolicyConfig `json:"retry_policy,omitempty"`
+
+	// 设备状态轮询（Modern Standby）
+	PollInterval    int                 `json:"poll_interval,omitempty"`    // 轮询间隔（秒，默认 10）
+	AdaptivePolling *AdaptivePollConfig `json:"adaptive_polling,omitempty"` // 自适应轮询：唤醒或失败后加快，稳定后放慢（可选）
+	IdleDozeSecs    int                 `json:"idle_doze_secs,omitempty"`   // 空闲超过该秒数视为用户离开，发现异常时等用户回来再修复（默认 300）
+	IdleActiveSecs  int                 `json:"idle_active_secs,omitempty"` // 空闲不超过该秒数视为用户活跃（默认 60）
+
+	// 服务重启后恢复待唤醒修复标记和重试状态，超过有效期的丢弃
+	PollerStateMaxAgeMinutes int `json:"poller_state_max_age_minutes,omitempty"` // 有效期（分钟，默认 720）
+
+	// 达到最大重试次数后停止自动修复，经过冷却时间、下次唤醒或 -rearm 后恢复
+	GiveUpCooldownMinutes int `json:"give_up_cooldown_minutes,omitempty"` // 冷却时间（分钟，默认 120）
 }
 
 // DefaultConfig 返回默认配置
@@ -45,17 +86,76 @@ func DefaultConfig() *Config {
 		AutoDetect:         true,
 		LogLevel:           "INFO",
 		LogDir:             "",
+		LogFormat:          string(LogFormatText),
 		CheckBeforeReset:   true, // 默认先检查再修复
 		ResumeDelaySeconds: 3,    // 默认等待3秒
 		LogAllEvents:       true, // 默认记录所有事件
 		EnableNotification: true, // 默认启用通知
 		MaxLogDays:         30,   // 默认保留30天
+		MaxLogSizeMB:       10,   // 默认单个文件10MB
+		MaxLogTotalMB:      100,  // 默认总计100MB
 		MaxRetryCount:      10,   // 默认最多连续重试10次
 		RetryIntervalSecs:  60,   // 默认60秒基础重试间隔
 		MaxRetryInterval:   600,  // 默认最大10分钟重试间隔
 	}
 }
 
+// DefaultGiveUpCooldown 停止自动修复后的默认冷却时间
+const DefaultGiveUpCooldown = 2 * time.Hour
+
+// GiveUpCooldown 返回停止自动修复后的冷却时间
+func (c *Config) GiveUpCooldown() time.Duration {
+	if c.GiveUpCooldownMinutes > 0 {
+		return time.Duration(c.GiveUpCooldownMinutes) * time.Minute
+	}
+	return DefaultGiveUpCooldown
+}
+
+// RetryIntervals 返回基础重试间隔和最大重试间隔（未配置时为 60 秒和 10 分钟）
+func (c *Config) RetryIntervals() (base, maxInterval time.Duration) {
+	base, maxInterval = time.Minute, 10*time.Minute
+	if c.RetryIntervalSecs > 0 {
+		base = time.Duration(c.RetryIntervalSecs) * time.Second
+	}
+	if c.MaxRetryInterval > 0 {
+		maxInterval = time.Duration(c.MaxRetryInterval) * time.Second
+	}
+	return base, maxInterval
+}
+
+// PollSchedule 返回设备状态轮询间隔设置
+func (c *Config) PollSchedule() PollSchedule {
+	return NewPollSchedule(time.Duration(c.PollInterval)*time.Second, c.AdaptivePolling)
+}
+
+// IdleThresholds 返回空闲/活跃判定阈值（未配置的项使用默认值）
+func (c *Config) IdleThresholds() IdleThresholds {
+	t := DefaultIdleThresholds
+	if c.IdleDozeSecs > 0 {
+		t.Doze = time.Duration(c.IdleDozeSecs) * time.Second
+	}
+	if c.IdleActiveSecs > 0 {
+		t.Active = time.Duration(c.IdleActiveSecs) * time.Second
+	}
+	return t
+}
+
+// PollerStateMaxAge 返回轮询器状态的有效期
+func (c *Config) PollerStateMaxAge() time.Duration {
+	if c.PollerStateMaxAgeMinutes > 0 {
+		return time.Duration(c.PollerStateMaxAgeMinutes) * time.Minute
+	}
+	return DefaultPollerStateMaxAge
+}
+
+// WakeTracePath 返回唤醒事件轨迹文件路径（未配置时为空）
+func (c *Config) WakeTracePath(logDir string) string {
+	if c.WakeTraceFile == "" || filepath.IsAbs(c.WakeTraceFile) {
+		return c.WakeTraceFile
+	}
+	return filepath.Join(logDir, c.WakeTraceFile)
+}
+
 // LoadConfig 从文件加载配置
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -100,9 +200,105 @@ func (c *Config) Validate() error {
 	if c.WaitSeconds < 0 {
 		return fmt.Errorf("wait_seconds 必须为非负数")
 	}
+	if _, err := ParseLogLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("log_level 无效: %w", err)
+	}
+	if _, err := ParseTagLevels(c.LogTagLevels); err != nil {
+		return fmt.Errorf("log_tag_levels 无效: %w", err)
+	}
+	if _, err := ParseLogFormat(c.LogFormat); err != nil {
+		return fmt.Errorf("log_format 无效: %w", err)
+	}
+	if c.GiveUpCooldownMinutes < 0 {
+		return fmt.Errorf("give_up_cooldown_minutes 必须为非负数")
+	}
+	if c.PollInterval < 0 {
+		return fmt.Errorf("poll_interval 必须为非负数")
+	}
+	if c.IdleDozeSecs < 0 || c.IdleActiveSecs < 0 {
+		return fmt.Errorf("idle_doze_secs、idle_active_secs 必须为非负数")
+	}
+	if err := c.IdleThresholds().Validate(); err != nil {
+		return fmt.Errorf("idle_doze_secs/idle_active_secs 无效: %w", err)
+	}
+	if c.PollerStateMaxAgeMinutes < 0 {
+		return fmt.Errorf("poller_state_max_age_minutes 必须为非负数")
+	}
+	if c.FlapDetection != nil {
+		if err := c.FlapDetection.Validate(); err != nil {
+			return fmt.Errorf("flap_detection 配置无效: %w", err)
+		}
+	}
+	if c.RetryPolicy != nil {
+		if err := c.RetryPolicy.Validate(); err != nil {
+			return fmt.Errorf("retry_policy 配置无效: %w", err)
+		}
+	}
+	if c.AdaptivePolling != nil {
+		if err := c.AdaptivePolling.Validate(); err != nil {
+			return fmt.Errorf("adaptive_polling 配置无效: %w", err)
+		}
+	}
+	if c.MaxLogSizeMB < 0 || c.MaxLogFiles < 0 || c.MaxLogTotalMB < 0 {
+		return fmt.Errorf("max_log_size_mb、max_log_files、max_log_total_mb 必须为非负数")
+	}
+	if c.Language != "" && !strings.EqualFold(c.Language, "auto") {
+		if _, err := ParseLocale(c.Language); err != nil {
+			return fmt.Errorf("language 无效: %w", err)
+		}
+	}
+	if _, err := parseNotificationTemplates(c.NotificationTemplates); err != nil {
+		return fmt.Errorf("notification_templates 无效: %w", err)
+	}
+	if c.Hooks != nil {
+		if err := c.Hooks.Validate(); err != nil {
+			return fmt.Errorf("hooks 配置无效: %w", err)
+		}
+	}
+	if c.Syslog != nil {
+		if err := c.Syslog.Validate(); err != nil {
+			return fmt.Errorf("syslog 配置无效: %w", err)
+		}
+	}
 	return nil
 }
 
+// LogRotation 返回日志轮转与保留策略
+func (c *Config) LogRotation() LogRotation {
+	const mb = 1024 * 1024
+	return LogRotation{
+		MaxSizeBytes:  int64(c.MaxLogSizeMB) * mb,
+		Compress:      c.CompressLogs,
+		MaxDays:       c.MaxLogDays,
+		MaxFiles:      c.MaxLogFiles,
+		MaxTotalBytes: int64(c.MaxLogTotalMB) * mb,
+	}
+}
+
+// DeviceDisplayName 返回用于日志和通知的设备名称（未配置友好名称时使用实例 ID）
+func (c *Config) DeviceDisplayName() string {
+	if c.DeviceName != "" {
+		return c.DeviceName
+	}
+	return c.DeviceInstanceID
+}
+
+// RepairOptions 返回修复流程参数
+func (c *Config) RepairOptions() RepairOptions {
+	delay := time.Duration(c.ResumeDelaySeconds) * time.Second
+	if delay <= 0 {
+		delay = 3 * time.Second
+	}
+	return RepairOptions{
+		DeviceName:       c.DeviceDisplayName(),
+		DeviceID:         c.DeviceInstanceID,
+		ResumeDelay:      delay,
+		ResetWait:        time.Duration(c.WaitSeconds) * time.Second,
+		CheckBeforeReset: c.CheckBeforeReset,
+		NotifySkip:       c.LogAllEvents,
+	}
+}
+
 // ValidateDevice 验证设备是否仍然存在
 func (c *Config) ValidateDevice() error {
 	if c.DeviceInstanceID == "" {
@@ -110,7 +306,7 @@ func (c *Config) ValidateDevice() error {
 	}
 
 	dm := NewDeviceManager(c.DeviceInstanceID)
-	_, err := dm.GetStatus()
+	_, err := dm.GetStatus(context.Background())
 	return err
 }
 
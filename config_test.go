@@ -57,6 +57,127 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "JSON 日志格式",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				LogFormat:        "json",
+			},
+			wantError: false,
+		},
+		{
+			name: "无效日志格式",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				LogFormat:        "xml",
+			},
+			wantError: true,
+		},
+		{
+			name: "负冷却时间",
+			config: Config{
+				DeviceInstanceID:      "ACPI\\VEN_INT&DEV_0B45",
+				GiveUpCooldownMinutes: -1,
+			},
+			wantError: true,
+		},
+		{
+			name: "英文界面",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				Language:         "en-US",
+			},
+			wantError: false,
+		},
+		{
+			name: "不支持的语言",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				Language:         "fr-FR",
+			},
+			wantError: true,
+		},
+		{
+			name: "通知模板语法错误",
+			config: Config{
+				DeviceInstanceID:      "ACPI\\VEN_INT&DEV_0B45",
+				NotificationTemplates: map[string]NotificationTemplateConfig{"failure": {Title: "{{.Device"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "通知模板事件无效",
+			config: Config{
+				DeviceInstanceID:      "ACPI\\VEN_INT&DEV_0B45",
+				NotificationTemplates: map[string]NotificationTemplateConfig{"boom": {Title: "x"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "轮询间隔为负数",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				PollInterval:     -1,
+			},
+			wantError: true,
+		},
+		{
+			name: "自适应轮询间隔为负数",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				AdaptivePolling:  &AdaptivePollConfig{FastIntervalSecs: -2},
+			},
+			wantError: true,
+		},
+		{
+			name: "活跃阈值不小于空闲阈值",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				IdleDozeSecs:     120,
+				IdleActiveSecs:   120,
+			},
+			wantError: true,
+		},
+		{
+			name: "固定间隔重试策略",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				RetryPolicy:      &RetryPolicyConfig{Type: "fixed", Schedule: []string{"10s", "1m", "10m"}, MaxPerDay: 20},
+			},
+			wantError: false,
+		},
+		{
+			name: "重试策略间隔表无效",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				RetryPolicy:      &RetryPolicyConfig{Type: "fixed", Schedule: []string{"soon"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "每次唤醒修复上限为负数",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				RetryPolicy:      &RetryPolicyConfig{MaxPerWake: -1},
+			},
+			wantError: true,
+		},
+		{
+			name: "抖动检测次数过小",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				FlapDetection:    &FlapConfig{Transitions: 1},
+			},
+			wantError: true,
+		},
+		{
+			name: "只放宽空闲阈值",
+			config: Config{
+				DeviceInstanceID: "ACPI\\VEN_INT&DEV_0B45",
+				IdleDozeSecs:     1800,
+			},
+			wantError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -3,9 +3,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 )
 
@@ -80,11 +80,18 @@ func (d *DeviceInfo) Score() int {
 }
 
 // Detector 设备检测器
-type Detector struct{}
+type Detector struct {
+	logger TagLogger
+}
 
-// NewDetector 创建设备检测器
+// NewDetector 创建设备检测器（默认输出到控制台）
 func NewDetector() *Detector {
-	return &Detector{}
+	return &Detector{logger: ConsoleLogger{}}
+}
+
+// SetLogger 设置日志输出
+func (dt *Detector) SetLogger(logger TagLogger) {
+	dt.logger = logger
 }
 
 // ScanAllDevices 扫描所有 PnP 设备
@@ -127,7 +134,7 @@ Get-PnpDevice | Where-Object {
 
 		var device DeviceInfo
 		if err := json.Unmarshal([]byte(line), &device); err != nil {
-			log.Printf("警告: 解析设备信息失败: %v, 行: %s", err, line)
+			dt.logger.LogTag(context.Background(), WARNING, TagCheck, "解析设备信息失败: %v, 行: %s", err, line)
 			continue
 		}
 		devices = append(devices, &device)
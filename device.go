@@ -4,26 +4,32 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"os/exec"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // DeviceManager 管理设备操作
 type DeviceManager struct {
 	instanceID string
+	logger     TagLogger
 }
 
-// NewDeviceManager 创建设备管理器
+// NewDeviceManager 创建设备管理器（默认输出到控制台，服务中通过 SetLogger 写入日志文件）
 func NewDeviceManager(instanceID string) *DeviceManager {
-	return &DeviceManager{instanceID: instanceID}
+	return &DeviceManager{instanceID: instanceID, logger: ConsoleLogger{}}
+}
+
+// SetLogger 设置日志输出
+func (dm *DeviceManager) SetLogger(logger TagLogger) {
+	dm.logger = logger
 }
 
 // GetStatus 获取设备当前状态
-func (dm *DeviceManager) GetStatus() (string, error) {
+func (dm *DeviceManager) GetStatus(ctx context.Context) (string, error) {
 	script := fmt.Sprintf("(Get-PnpDevice -InstanceId '%s').Status", escapeSingleQuotes(dm.instanceID))
-	output, err := runPowerShell(script)
+	output, err := runPowerShellContext(ctx, script)
 	if err != nil {
 		return "", fmt.Errorf("获取设备状态失败: %w", err)
 	}
@@ -31,10 +37,10 @@ func (dm *DeviceManager) GetStatus() (string, error) {
 }
 
 // Disable 禁用设备
-func (dm *DeviceManager) Disable() error {
-	log.Printf("正在禁用设备: %s", dm.instanceID)
+func (dm *DeviceManager) Disable(ctx context.Context) error {
+	dm.logger.LogTag(ctx, INFO, TagReset, "正在禁用设备: %s", dm.instanceID)
 	script := fmt.Sprintf("Disable-PnpDevice -InstanceId '%s' -Confirm:$false", escapeSingleQuotes(dm.instanceID))
-	_, err := runPowerShell(script)
+	_, err := runPowerShellContext(ctx, script)
 	if err != nil {
 		// 检查是否是权限问题
 		if strings.Contains(err.Error(), "0x80041001") || strings.Contains(err.Error(), "常规故障") {
@@ -42,70 +48,87 @@ func (dm *DeviceManager) Disable() error {
 		}
 		return fmt.Errorf("禁用设备失败: %w", err)
 	}
-	log.Println("设备已禁用")
+	dm.logger.LogTag(ctx, INFO, TagReset, "设备已禁用")
 	return nil
 }
 
 // Enable 启用设备
-func (dm *DeviceManager) Enable() error {
-	log.Printf("正在启用设备: %s", dm.instanceID)
+func (dm *DeviceManager) Enable(ctx context.Context) error {
+	dm.logger.LogTag(ctx, INFO, TagReset, "正在启用设备: %s", dm.instanceID)
 	script := fmt.Sprintf("Enable-PnpDevice -InstanceId '%s' -Confirm:$false", escapeSingleQuotes(dm.instanceID))
-	_, err := runPowerShell(script)
+	_, err := runPowerShellContext(ctx, script)
 	if err != nil {
 		return fmt.Errorf("启用设备失败: %w", err)
 	}
-	log.Println("设备已启用")
+	dm.logger.LogTag(ctx, INFO, TagReset, "设备已启用")
 	return nil
 }
 
 // Reset 重置设备（禁用后再启用）
-func (dm *DeviceManager) Reset(waitDuration time.Duration) error {
-	log.Println("开始重置设备...")
+func (dm *DeviceManager) Reset(ctx context.Context, waitDuration time.Duration) error {
+	dm.logger.LogTag(ctx, INFO, TagReset, "开始重置设备...")
 
 	// 获取初始状态
-	initialStatus, err := dm.GetStatus()
+	initialStatus, err := dm.GetStatus(ctx)
 	if err != nil {
-		log.Printf("警告: 无法获取初始状态: %v", err)
+		dm.logger.LogTag(ctx, WARNING, TagCheck, "无法获取初始状态: %v", err)
 	} else {
-		log.Printf("初始状态: %s", initialStatus)
+		dm.logger.LogTag(ctx, INFO, TagCheck, "初始状态: %s", initialStatus)
 	}
 
 	// 禁用设备
-	if err := dm.Disable(); err != nil {
+	if err := dm.Disable(ctx); err != nil {
 		return err
 	}
 
-	// 等待
-	log.Printf("等待 %v...", waitDuration)
-	time.Sleep(waitDuration)
+	// 等待（context 取消时提前结束，仍然尝试重新启用设备）
+	dm.logger.LogTag(ctx, DEBUG, TagReset, "等待 %v...", waitDuration)
+	select {
+	case <-time.After(waitDuration):
+	case <-ctx.Done():
+	}
 
 	// 启用设备
-	if err := dm.Enable(); err != nil {
+	if err := dm.Enable(context.WithoutCancel(ctx)); err != nil {
 		return err
 	}
 
 	// 验证最终状态
-	finalStatus, err := dm.GetStatus()
+	finalStatus, err := dm.GetStatus(ctx)
 	if err != nil {
-		log.Printf("警告: 无法获取最终状态: %v", err)
+		dm.logger.LogTag(ctx, WARNING, TagCheck, "无法获取最终状态: %v", err)
 	} else {
-		log.Printf("最终状态: %s", finalStatus)
+		dm.logger.LogTag(ctx, INFO, TagCheck, "最终状态: %s", finalStatus)
 	}
 
-	log.Println("设备重置完成")
+	dm.logger.LogTag(ctx, INFO, TagReset, "设备重置完成")
 	return nil
 }
 
+// powerShellSpawns 本进程启动的 PowerShell 进程数（用于统计轮询开销）
+var powerShellSpawns atomic.Int64
+
+// PowerShellSpawnCount 返回本进程累计启动的 PowerShell 进程数
+func PowerShellSpawnCount() int64 {
+	return powerShellSpawns.Load()
+}
+
 // runPowerShell 执行 PowerShell 命令
 func runPowerShell(body string) (string, error) {
+	return runPowerShellContext(context.Background(), body)
+}
+
+// runPowerShellContext 执行 PowerShell 命令，parent 取消时终止进程
+func runPowerShellContext(parent context.Context, body string) (string, error) {
 	full := fmt.Sprintf("[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; $ErrorActionPreference='Stop'; %s", body)
 
 	// 防止 Disable/Enable-PnpDevice 偶发卡死导致服务长时间阻塞
 	const timeout = 90 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", full)
+	powerShellSpawns.Add(1)
 	out, err := cmd.CombinedOutput()
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("PowerShell 执行超时(%v): %s", timeout, strings.TrimSpace(string(out)))
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -48,7 +49,7 @@ func TestDeviceManager_GetStatus(t *testing.T) {
 	t.Skip("需要实际的设备才能测试")
 
 	dm := NewDeviceManager("INVALID_INSTANCE_ID")
-	_, err := dm.GetStatus()
+	_, err := dm.GetStatus(context.Background())
 	if err == nil {
 		t.Error("期望获取无效设备状态时返回错误")
 	}
@@ -0,0 +1,45 @@
+// Package main provides correlation IDs that tie together one wake-and-repair episode.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// episodeKey context 中保存事件 ID 的键
+type episodeKey struct{}
+
+// NewEpisodeID 生成唤醒修复事件 ID：触发时间 + 随机后缀，如 20251224-083015-a1b2
+// 以时间开头便于在日志和统计历史中直接看出先后顺序
+func NewEpisodeID(now time.Time) string {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+}
+
+// WithEpisode 返回携带事件 ID 的 context
+func WithEpisode(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, episodeKey{}, id)
+}
+
+// NewEpisodeContext 在触发点创建新的事件 ID，并返回携带它的 context
+func NewEpisodeContext(ctx context.Context) (context.Context, string) {
+	id := NewEpisodeID(time.Now())
+	return WithEpisode(ctx, id), id
+}
+
+// EpisodeFromContext 获取 context 中的事件 ID（没有时返回空字符串）
+func EpisodeFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(episodeKey{}).(string)
+	return id
+}
+
+// WithContext 返回附带 context 中事件 ID 的日志记录器
+func (l *Logger) WithContext(ctx context.Context) *FieldLogger {
+	return l.WithFields(LogFields{Episode: EpisodeFromContext(ctx)})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewEpisodeID(t *testing.T) {
+	now := time.Date(2025, 12, 24, 8, 30, 15, 0, time.Local)
+
+	id := NewEpisodeID(now)
+	if !regexp.MustCompile(`^20251224-083015-[0-9a-f]{4}$`).MatchString(id) {
+		t.Errorf("NewEpisodeID() = %q, unexpected format", id)
+	}
+
+	// 同一秒内的多个事件应有不同的随机后缀
+	seen := map[string]bool{id: true}
+	for i := 0; i < 10; i++ {
+		seen[NewEpisodeID(now)] = true
+	}
+	if len(seen) < 2 {
+		t.Error("NewEpisodeID() should differ for episodes in the same second")
+	}
+}
+
+func TestEpisodeContext(t *testing.T) {
+	if got := EpisodeFromContext(context.Background()); got != "" {
+		t.Errorf("EpisodeFromContext(background) = %q, want empty", got)
+	}
+
+	ctx, id := NewEpisodeContext(context.Background())
+	if id == "" || EpisodeFromContext(ctx) != id {
+		t.Errorf("EpisodeFromContext() = %q, want %q", EpisodeFromContext(ctx), id)
+	}
+
+	// 派生的 context 仍然携带事件 ID
+	child, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if EpisodeFromContext(child) != id {
+		t.Error("derived context lost the episode ID")
+	}
+}
+
+func TestEpisode_Timeline(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+	l.SetFormat(LogFormatJSON)
+
+	ctx := WithEpisode(context.Background(), "ep-1")
+	other := WithEpisode(context.Background(), "ep-2")
+
+	l.WithContext(ctx).InfoTag(TagResume, "系统从睡眠唤醒")
+	l.WithContext(other).InfoTag(TagResume, "另一次唤醒")
+	l.InfoTag(TagService, "无关日志")
+	l.WithFields(LogFields{Device: "touch", Episode: EpisodeFromContext(ctx)}).InfoTag(TagSuccess, "触屏设备修复成功")
+
+	lines, err := readLogLinesFrom(dir, 100, &LogFilter{Fields: map[string]string{"episode": "ep-1"}})
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("episode timeline = %v, want 2 lines", lines)
+	}
+	for _, line := range lines {
+		entry, _ := ParseLogLine(line)
+		if entry.Fields.Episode != "ep-1" {
+			t.Errorf("line %q has episode %q", line, entry.Fields.Episode)
+		}
+	}
+}
+
+func TestStatsManager_RecordsEpisode(t *testing.T) {
+	sm := NewStatsManager(t.TempDir())
+	ctx := WithEpisode(context.Background(), "ep-1")
+
+	sm.RecordResume(ctx)
+	sm.RecordReset(ctx, false, "失败: 超时")
+	sm.RecordSkip(context.Background())
+
+	history := sm.GetHistory()
+	if len(history) != 3 {
+		t.Fatalf("history = %d records, want 3", len(history))
+	}
+	if history[0].Episode != "ep-1" || history[1].Episode != "ep-1" || history[2].Episode != "" {
+		t.Errorf("episodes = %q, %q, %q", history[0].Episode, history[1].Episode, history[2].Episode)
+	}
+}
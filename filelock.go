@@ -0,0 +1,38 @@
+// Package main provides cross-process file locking for files shared by the service and the CLI.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// fileLock 跨进程独占文件锁
+// 服务进程和 CLI 进程会同时读写可执行文件目录下的数据文件，仅靠进程内互斥锁无法保护
+type fileLock struct {
+	file *os.File
+}
+
+// acquireFileLock 获取独占锁（阻塞直到其他进程释放）
+func acquireFileLock(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("加锁失败: %w", err)
+	}
+
+	return &fileLock{file: f}, nil
+}
+
+// Release 释放锁
+func (l *fileLock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	_ = unlockFile(l.file)
+	_ = l.file.Close()
+	l.file = nil
+}
@@ -0,0 +1,266 @@
+// Package main provides flapping detection for devices that keep switching between OK and Error, and the diagnostic snapshots taken when one is quarantined.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FlapConfig 设备状态反复变化（抖动）检测配置
+type FlapConfig struct {
+	Transitions int `json:"transitions,omitempty"` // 窗口内正常/异常切换达到该次数视为抖动（默认 6）
+	WindowSecs  int `json:"window_secs,omitempty"` // 统计窗口（秒，默认 300）
+	StableSecs  int `json:"stable_secs,omitempty"` // 隔离后状态保持不变多久解除隔离（秒，默认 900）
+}
+
+// Validate 验证抖动检测配置
+func (c *FlapConfig) Validate() error {
+	if c.Transitions < 0 || c.WindowSecs < 0 || c.StableSecs < 0 {
+		return fmt.Errorf("transitions、window_secs、stable_secs 必须为非负数")
+	}
+	return c.Thresholds().Validate()
+}
+
+// Thresholds 返回抖动检测阈值（未配置的项使用默认值）
+func (c *FlapConfig) Thresholds() FlapThresholds {
+	t := DefaultFlapThresholds
+	if c == nil {
+		return t
+	}
+	if c.Transitions > 0 {
+		t.Transitions = c.Transitions
+	}
+	if c.WindowSecs > 0 {
+		t.Window = time.Duration(c.WindowSecs) * time.Second
+	}
+	if c.StableSecs > 0 {
+		t.Stable = time.Duration(c.StableSecs) * time.Second
+	}
+	return t
+}
+
+// FlapThresholds 抖动检测阈值
+type FlapThresholds struct {
+	Transitions int           // 窗口内切换次数上限
+	Window      time.Duration // 统计窗口
+	Stable      time.Duration // 解除隔离前需要保持稳定的时长
+}
+
+// DefaultFlapThresholds 默认阈值：5 分钟内切换 6 次（约 3 次“异常 → 修复 → 异常”）进入隔离，稳定 15 分钟后解除
+var DefaultFlapThresholds = FlapThresholds{Transitions: 6, Window: 5 * time.Minute, Stable: 15 * time.Minute}
+
+// Validate 切换次数至少为 2，窗口和稳定时长必须为正数
+func (t FlapThresholds) Validate() error {
+	if t.Transitions < 2 {
+		return fmt.Errorf("切换次数至少为 2")
+	}
+	if t.Window <= 0 || t.Stable <= 0 {
+		return fmt.Errorf("统计窗口和稳定时长必须为正数")
+	}
+	return nil
+}
+
+// String 用于日志
+func (t FlapThresholds) String() string {
+	return T("flap.thresholds", t.Window, t.Transitions, t.Stable)
+}
+
+// FlapEvent 一次状态观察的结果
+type FlapEvent int
+
+const (
+	FlapNone        FlapEvent = iota // 无变化
+	FlapQuarantined                  // 切换过于频繁，进入隔离
+	FlapReleased                     // 隔离期间保持稳定，解除隔离
+)
+
+// FlapSample 一次设备状态读取
+type FlapSample struct {
+	At     time.Time `json:"at"`
+	Status string    `json:"status"`
+}
+
+// maxFlapSamples 保留的最近状态读取数（用于诊断快照）
+const maxFlapSamples = 50
+
+// FlapDetector 根据设备状态读取检测抖动，并在隔离期间等待状态稳定
+type FlapDetector struct {
+	thresholds       FlapThresholds
+	mu               sync.Mutex
+	samples          []FlapSample // 最近的状态读取
+	lastOK           *bool        // 上次读取是否正常（尚未读取时为 nil）
+	changes          []time.Time  // 窗口内的切换时间
+	lastChange       time.Time    // 最近一次切换的时间
+	quarantinedSince time.Time    // 进入隔离的时间（未隔离时为零值）
+}
+
+// NewFlapDetector 创建抖动检测器
+func NewFlapDetector(thresholds FlapThresholds) *FlapDetector {
+	return &FlapDetector{thresholds: thresholds}
+}
+
+// Thresholds 抖动检测阈值
+func (d *FlapDetector) Thresholds() FlapThresholds {
+	return d.thresholds
+}
+
+// Observe 记录一次设备状态读取，返回是否因此进入或解除隔离
+func (d *FlapDetector) Observe(status string, now time.Time) FlapEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.samples = append(d.samples, FlapSample{At: now, Status: status})
+	if len(d.samples) > maxFlapSamples {
+		d.samples = d.samples[len(d.samples)-maxFlapSamples:]
+	}
+
+	ok := isDeviceOK(status)
+	if d.lastOK != nil && *d.lastOK != ok {
+		d.lastChange = now
+		d.changes = append(d.changes, now)
+	}
+	d.lastOK = &ok
+	d.prune(now)
+
+	if !d.quarantinedSince.IsZero() {
+		if !now.Before(d.releaseAt()) {
+			d.quarantinedSince = time.Time{}
+			d.changes = nil
+			return FlapReleased
+		}
+		return FlapNone
+	}
+	if len(d.changes) >= d.thresholds.Transitions {
+		d.quarantinedSince = now
+		return FlapQuarantined
+	}
+	return FlapNone
+}
+
+// prune 丢弃统计窗口之外的切换
+func (d *FlapDetector) prune(now time.Time) {
+	i := 0
+	for i < len(d.changes) && now.Sub(d.changes[i]) > d.thresholds.Window {
+		i++
+	}
+	d.changes = d.changes[i:]
+}
+
+// releaseAt 最早解除隔离的时间：最近一次切换（或进入隔离）之后保持稳定
+func (d *FlapDetector) releaseAt() time.Time {
+	return maxTime(d.lastChange, d.quarantinedSince).Add(d.thresholds.Stable)
+}
+
+// Quarantined 是否处于隔离中
+func (d *FlapDetector) Quarantined() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.quarantinedSince.IsZero()
+}
+
+// QuarantinedSince 进入隔离的时间（未隔离时返回零值）
+func (d *FlapDetector) QuarantinedSince() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.quarantinedSince
+}
+
+// ReleaseAt 状态保持不变时预计解除隔离的时间（未隔离时返回零值）
+func (d *FlapDetector) ReleaseAt() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.quarantinedSince.IsZero() {
+		return time.Time{}
+	}
+	return d.releaseAt()
+}
+
+// Restore 恢复服务重启前的隔离状态，重新开始计算稳定时长
+func (d *FlapDetector) Restore(since, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.quarantinedSince = since
+	d.lastChange = now
+}
+
+// Transitions 统计窗口内的切换次数
+func (d *FlapDetector) Transitions(now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(now)
+	return len(d.changes)
+}
+
+// Samples 最近的状态读取（按时间顺序）
+func (d *FlapDetector) Samples() []FlapSample {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]FlapSample(nil), d.samples...)
+}
+
+// maxTime 返回较晚的时间
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// FlapSnapshot 进入隔离时的诊断快照
+type FlapSnapshot struct {
+	Time        time.Time         `json:"time"`
+	DeviceID    string            `json:"device_id"`
+	Transitions int               `json:"transitions"` // 统计窗口内的切换次数
+	WindowSecs  int               `json:"window_secs"`
+	ReleaseAt   time.Time         `json:"release_at"` // 状态保持不变时预计解除隔离的时间
+	IdleSecs    int               `json:"idle_secs"`  // 用户空闲时间（-1 表示无法获取）
+	Samples     []FlapSample      `json:"samples"`    // 最近的设备状态读取
+	Poller      PollerState       `json:"poller"`     // 轮询器状态（睡眠/唤醒状态、失败次数、重试计划）
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// Summary 快照摘要（用于日志和通知）
+func (s FlapSnapshot) Summary() string {
+	statuses := make([]string, 0, 6)
+	for _, sample := range s.Samples[max(len(s.Samples)-6, 0):] {
+		statuses = append(statuses, sample.Status)
+	}
+	return T("flap.summary", s.WindowSecs, s.Transitions, strings.Join(statuses, " → "))
+}
+
+// flapSnapshotDir 诊断快照目录名（位于统计目录）
+const flapSnapshotDir = "diagnostics"
+
+// maxFlapSnapshots 最多保留的诊断快照数
+const maxFlapSnapshots = 20
+
+// SaveFlapSnapshot 将诊断快照保存到 dir/diagnostics/flap-<时间>.json，并删除最旧的多余快照；返回文件路径
+func SaveFlapSnapshot(dir string, snap FlapSnapshot) (string, error) {
+	snapDir := filepath.Join(dir, flapSnapshotDir)
+	if err := os.MkdirAll(snapDir, 0o755); err != nil {
+		return "", fmt.Errorf("创建诊断目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(snapDir, "flap-"+snap.Time.Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("保存诊断快照失败: %w", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(snapDir, "flap-*.json"))
+	if err == nil && len(matches) > maxFlapSnapshots {
+		sort.Strings(matches)
+		for _, old := range matches[:len(matches)-maxFlapSnapshots] {
+			_ = os.Remove(old)
+		}
+	}
+	return path, nil
+}
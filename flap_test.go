@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlapDetector_Observe(t *testing.T) {
+	thresholds := FlapThresholds{Transitions: 4, Window: time.Minute, Stable: 5 * time.Minute}
+	type step struct {
+		after  time.Duration // 距上一次读取
+		status string
+		want   FlapEvent
+	}
+	tests := []struct {
+		name            string
+		steps           []step
+		wantQuarantined bool
+	}{
+		{
+			name: "偶尔异常后修复不隔离",
+			steps: []step{
+				{0, "OK", FlapNone}, {10 * time.Second, "Error", FlapNone}, {10 * time.Second, "OK", FlapNone},
+				{10 * time.Minute, "Error", FlapNone}, {10 * time.Second, "OK", FlapNone},
+			},
+		},
+		{
+			name: "一分钟内切换 4 次进入隔离",
+			steps: []step{
+				{0, "OK", FlapNone}, {5 * time.Second, "Error", FlapNone}, {5 * time.Second, "OK", FlapNone},
+				{5 * time.Second, "Error", FlapNone}, {5 * time.Second, "OK", FlapQuarantined},
+			},
+			wantQuarantined: true,
+		},
+		{
+			name: "切换分散在窗口外不隔离",
+			steps: []step{
+				{0, "OK", FlapNone}, {40 * time.Second, "Error", FlapNone}, {40 * time.Second, "OK", FlapNone},
+				{40 * time.Second, "Error", FlapNone}, {40 * time.Second, "OK", FlapNone},
+			},
+		},
+		{
+			name: "持续异常不算抖动",
+			steps: []step{
+				{0, "Error", FlapNone}, {5 * time.Second, "Error", FlapNone}, {5 * time.Second, "Unknown", FlapNone},
+				{5 * time.Second, "Error", FlapNone},
+			},
+		},
+		{
+			name: "隔离期间仍在抖动时不解除",
+			steps: []step{
+				{0, "OK", FlapNone}, {5 * time.Second, "Error", FlapNone}, {5 * time.Second, "OK", FlapNone},
+				{5 * time.Second, "Error", FlapNone}, {5 * time.Second, "OK", FlapQuarantined},
+				{4 * time.Minute, "Error", FlapNone}, {4 * time.Minute, "Error", FlapNone},
+			},
+			wantQuarantined: true,
+		},
+		{
+			name: "隔离后保持稳定解除",
+			steps: []step{
+				{0, "OK", FlapNone}, {5 * time.Second, "Error", FlapNone}, {5 * time.Second, "OK", FlapNone},
+				{5 * time.Second, "Error", FlapNone}, {5 * time.Second, "OK", FlapQuarantined},
+				{4 * time.Minute, "OK", FlapNone}, {time.Minute, "OK", FlapReleased},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewFlapDetector(thresholds)
+			now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				if got := d.Observe(s.status, now); got != s.want {
+					t.Fatalf("第 %d 次读取 (%s) = %v, want %v", i+1, s.status, got, s.want)
+				}
+			}
+			if got := d.Quarantined(); got != tt.wantQuarantined {
+				t.Errorf("Quarantined() = %v, want %v", got, tt.wantQuarantined)
+			}
+		})
+	}
+}
+
+func TestFlapDetector_ReleaseAt(t *testing.T) {
+	d := NewFlapDetector(FlapThresholds{Transitions: 2, Window: time.Minute, Stable: 10 * time.Minute})
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	if !d.ReleaseAt().IsZero() {
+		t.Fatal("未隔离时 ReleaseAt 应为零值")
+	}
+	d.Observe("OK", now)
+	d.Observe("Error", now.Add(time.Second))
+	d.Observe("OK", now.Add(2*time.Second))
+	if got, want := d.ReleaseAt(), now.Add(2*time.Second+10*time.Minute); !got.Equal(want) {
+		t.Errorf("ReleaseAt = %s, want %s", got, want)
+	}
+
+	// 服务重启后恢复隔离：从恢复时起重新计算稳定时长
+	restored := NewFlapDetector(d.Thresholds())
+	restored.Restore(now, now.Add(time.Hour))
+	if !restored.Quarantined() || !restored.ReleaseAt().Equal(now.Add(time.Hour+10*time.Minute)) {
+		t.Errorf("恢复后 Quarantined = %v, ReleaseAt = %s", restored.Quarantined(), restored.ReleaseAt())
+	}
+}
+
+func TestFlapConfig_Thresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *FlapConfig
+		want    FlapThresholds
+		wantErr bool
+	}{
+		{name: "未配置使用默认值", config: nil, want: DefaultFlapThresholds},
+		{name: "只调整次数", config: &FlapConfig{Transitions: 10}, want: FlapThresholds{Transitions: 10, Window: 5 * time.Minute, Stable: 15 * time.Minute}},
+		{name: "全部配置", config: &FlapConfig{Transitions: 4, WindowSecs: 60, StableSecs: 600}, want: FlapThresholds{Transitions: 4, Window: time.Minute, Stable: 10 * time.Minute}},
+		{name: "次数为 1", config: &FlapConfig{Transitions: 1}, wantErr: true},
+		{name: "负数", config: &FlapConfig{StableSecs: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.config != nil {
+				if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+					t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if !tt.wantErr {
+				if got := tt.config.Thresholds(); got != tt.want {
+					t.Errorf("Thresholds() = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveFlapSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	var last string
+	for i := 0; i < maxFlapSnapshots+3; i++ {
+		snap := FlapSnapshot{
+			Time:        start.Add(time.Duration(i) * time.Hour),
+			DeviceID:    "ACPI\\GXTP7386",
+			Transitions: 6,
+			WindowSecs:  300,
+			Samples:     []FlapSample{{At: start, Status: "OK"}, {At: start.Add(time.Second), Status: "Error"}},
+		}
+		path, err := SaveFlapSnapshot(dir, snap)
+		if err != nil {
+			t.Fatalf("SaveFlapSnapshot() error = %v", err)
+		}
+		last = path
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, flapSnapshotDir, "flap-*.json"))
+	if len(files) != maxFlapSnapshots {
+		t.Errorf("保留 %d 个快照, want %d", len(files), maxFlapSnapshots)
+	}
+	if _, err := os.Stat(filepath.Join(dir, flapSnapshotDir, "flap-20251224-083000.json")); !os.IsNotExist(err) {
+		t.Error("最旧的快照应被删除")
+	}
+	data, err := os.ReadFile(last)
+	if err != nil || !strings.Contains(string(data), `"status": "Error"`) || !strings.Contains(string(data), `"device_id"`) {
+		t.Errorf("快照内容 = %s, err = %v", data, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package main provides user hook commands run around device resets.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HookEvent 触发钩子的时机
+type HookEvent string
+
+const (
+	HookPreReset         HookEvent = "pre_reset"          // 重置设备前；非零退出码阻止本次重置
+	HookPostResetSuccess HookEvent = "post_reset_success" // 修复成功后
+	HookPostResetFailure HookEvent = "post_reset_failure" // 修复失败后
+	HookOnGiveUp         HookEvent = "on_give_up"         // 达到最大重试次数、停止自动修复时
+)
+
+const (
+	defaultHookTimeout = 30 * time.Second
+	hookOutputLimit    = 2000 // 日志中记录的输出长度上限（字节）
+)
+
+// HooksConfig 修复前后运行的钩子命令
+type HooksConfig struct {
+	PreReset         *HookConfig `json:"pre_reset,omitempty"`
+	PostResetSuccess *HookConfig `json:"post_reset_success,omitempty"`
+	PostResetFailure *HookConfig `json:"post_reset_failure,omitempty"`
+	OnGiveUp         *HookConfig `json:"on_give_up,omitempty"`
+}
+
+// HookConfig 单个钩子命令
+type HookConfig struct {
+	Command        string   `json:"command"`
+	Args           []string `json:"args,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // 超时后终止命令（默认 30 秒）
+}
+
+// Validate 校验钩子配置
+func (c *HooksConfig) Validate() error {
+	for event, hook := range c.hooks() {
+		if hook.Command == "" {
+			return fmt.Errorf("%s: command 不能为空", event)
+		}
+		if hook.TimeoutSeconds < 0 {
+			return fmt.Errorf("%s: timeout_seconds 必须为非负数", event)
+		}
+	}
+	return nil
+}
+
+// hooks 返回已配置的钩子
+func (c *HooksConfig) hooks() map[HookEvent]*HookConfig {
+	hooks := make(map[HookEvent]*HookConfig)
+	for event, hook := range map[HookEvent]*HookConfig{
+		HookPreReset:         c.PreReset,
+		HookPostResetSuccess: c.PostResetSuccess,
+		HookPostResetFailure: c.PostResetFailure,
+		HookOnGiveUp:         c.OnGiveUp,
+	} {
+		if hook != nil {
+			hooks[event] = hook
+		}
+	}
+	return hooks
+}
+
+// timeout 返回钩子超时时间
+func (h *HookConfig) timeout() time.Duration {
+	if h.TimeoutSeconds > 0 {
+		return time.Duration(h.TimeoutSeconds) * time.Second
+	}
+	return defaultHookTimeout
+}
+
+// HookContext 传给钩子的修复上下文（通过环境变量）
+type HookContext struct {
+	Device   string // 设备名称
+	DeviceID string // 设备实例 ID
+	Status   string // 设备状态（pre_reset 为修复前状态，其他为修复后状态）
+	Attempt  int    // 连续第几次尝试
+	Trigger  string // 触发来源（power、poll、oem、manual）
+	Error    string // 失败原因
+}
+
+// env 钩子的环境变量
+func (hc HookContext) env(ctx context.Context, event HookEvent) []string {
+	return []string{
+		"GPD_HOOK=" + string(event),
+		"GPD_DEVICE=" + hc.Device,
+		"GPD_DEVICE_ID=" + hc.DeviceID,
+		"GPD_STATUS=" + hc.Status,
+		"GPD_ATTEMPT=" + strconv.Itoa(hc.Attempt),
+		"GPD_TRIGGER=" + hc.Trigger,
+		"GPD_ERROR=" + hc.Error,
+		"GPD_EPISODE=" + EpisodeFromContext(ctx),
+	}
+}
+
+// HookRunner 运行修复前后的钩子命令，退出码和输出写入日志
+type HookRunner struct {
+	hooks  map[HookEvent]*HookConfig
+	logger TagLogger
+}
+
+// NewHookRunner 根据配置创建钩子运行器（cfg 为 nil 时不运行任何钩子）
+func NewHookRunner(cfg *HooksConfig, logger TagLogger) *HookRunner {
+	r := &HookRunner{logger: logger}
+	if cfg != nil {
+		r.hooks = cfg.hooks()
+	}
+	if r.logger == nil {
+		r.logger = ConsoleLogger{}
+	}
+	return r
+}
+
+// Has 是否配置了该钩子
+func (r *HookRunner) Has(event HookEvent) bool {
+	return r != nil && r.hooks[event] != nil
+}
+
+// Run 运行钩子并等待结束；未配置时直接返回 nil
+// 命令非零退出、超时或无法启动时返回错误（pre_reset 据此阻止重置，其他钩子的错误只记录日志）
+func (r *HookRunner) Run(ctx context.Context, event HookEvent, hc HookContext) error {
+	if !r.Has(event) {
+		return nil
+	}
+	hook := r.hooks[event]
+
+	runCtx, cancel := context.WithTimeout(ctx, hook.timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, hook.Command, hook.Args...)
+	cmd.Env = append(os.Environ(), hc.env(ctx, event)...)
+	cmd.WaitDelay = time.Second // 超时后不等待仍占用输出管道的子进程
+
+	r.logger.LogTag(ctx, DEBUG, TagHook, "运行钩子 %s: %s %s", event, hook.Command, strings.Join(hook.Args, " "))
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	out := strings.TrimSpace(string(output))
+	if len(out) > hookOutputLimit {
+		out = out[:hookOutputLimit] + "..."
+	}
+
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("钩子 %s 超时（%s）", event, hook.timeout())
+	case err != nil:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("钩子 %s 退出码 %d", event, exitErr.ExitCode())
+		} else {
+			err = fmt.Errorf("钩子 %s 无法运行: %w", event, err)
+		}
+	}
+
+	if err != nil {
+		r.logger.LogTag(ctx, WARNING, TagHook, "%v (耗时 %s)%s", err, elapsed, formatHookOutput(out))
+		return err
+	}
+	r.logger.LogTag(ctx, INFO, TagHook, "钩子 %s 完成，退出码 0 (耗时 %s)%s", event, elapsed, formatHookOutput(out))
+	return nil
+}
+
+// formatHookOutput 日志中附带的钩子输出
+func formatHookOutput(out string) string {
+	if out == "" {
+		return ""
+	}
+	return "，输出: " + out
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTagLogger 记录日志内容的 TagLogger
+type recordingTagLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recordingTagLogger) LogTag(_ context.Context, level LogLevel, tag EventTag, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf("%d [%s] ", level, tag)+fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTagLogger) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.lines, "\n")
+}
+
+// TestHookHelper 被钩子测试作为外部命令运行，按 GPD_TEST_HOOK_MODE 决定行为
+func TestHookHelper(t *testing.T) {
+	mode := os.Getenv("GPD_TEST_HOOK_MODE")
+	if mode == "" {
+		t.Skip("仅作为钩子测试的辅助进程运行")
+	}
+	switch mode {
+	case "env":
+		var lines []string
+		for _, kv := range os.Environ() {
+			if strings.HasPrefix(kv, "GPD_") && !strings.HasPrefix(kv, "GPD_TEST_") {
+				lines = append(lines, kv)
+			}
+		}
+		if err := os.WriteFile(os.Getenv("GPD_TEST_HOOK_OUT"), []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Print("校准工具已重启")
+		os.Exit(0)
+	case "veto":
+		fmt.Print("平板模式应用正在运行")
+		os.Exit(2)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+}
+
+func TestHookRunner_Run(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "env.txt")
+	t.Setenv("GPD_TEST_HOOK_OUT", out)
+
+	hook := func(timeout int) *HookConfig {
+		return &HookConfig{Command: os.Args[0], Args: []string{"-test.run=^TestHookHelper$"}, TimeoutSeconds: timeout}
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		timeout int
+		wantErr string
+		wantLog string
+	}{
+		{name: "成功并记录输出", mode: "env", wantLog: "退出码 0"},
+		{name: "非零退出码", mode: "veto", wantErr: "退出码 2", wantLog: "平板模式应用正在运行"},
+		{name: "超时", mode: "hang", timeout: 1, wantErr: "超时"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GPD_TEST_HOOK_MODE", tt.mode)
+			logger := &recordingTagLogger{}
+			r := NewHookRunner(&HooksConfig{PreReset: hook(tt.timeout)}, logger)
+
+			ctx := WithEpisode(context.Background(), "ep-1")
+			err := r.Run(ctx, HookPreReset, HookContext{Device: "触摸屏", DeviceID: "ACPI\\GXTP7386", Status: "Error", Attempt: 2, Trigger: "poll"})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantLog != "" && !strings.Contains(logger.String(), tt.wantLog) {
+				t.Errorf("日志缺少 %q:\n%s", tt.wantLog, logger)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"GPD_HOOK=pre_reset", "GPD_DEVICE=触摸屏", "GPD_DEVICE_ID=ACPI\\GXTP7386", "GPD_STATUS=Error", "GPD_ATTEMPT=2", "GPD_TRIGGER=poll", "GPD_EPISODE=ep-1"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("环境变量缺少 %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestHookRunner_NotConfigured(t *testing.T) {
+	r := NewHookRunner(&HooksConfig{PreReset: &HookConfig{Command: "does-not-matter"}}, &recordingTagLogger{})
+	if err := r.Run(context.Background(), HookPostResetSuccess, HookContext{}); err != nil {
+		t.Errorf("未配置的钩子应直接返回 nil, got %v", err)
+	}
+
+	var nilRunner *HookRunner
+	if nilRunner.Has(HookPreReset) {
+		t.Error("nil HookRunner 不应有钩子")
+	}
+}
+
+func TestHooksConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     HooksConfig
+		wantErr bool
+	}{
+		{"空配置", HooksConfig{}, false},
+		{"有效", HooksConfig{OnGiveUp: &HookConfig{Command: "notify.cmd", TimeoutSeconds: 10}}, false},
+		{"缺少命令", HooksConfig{PostResetSuccess: &HookConfig{}}, true},
+		{"负超时", HooksConfig{PreReset: &HookConfig{Command: "x", TimeoutSeconds: -1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package main provides the message catalog used for user-facing notification and CLI text.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Locale 界面语言
+type Locale string
+
+const (
+	LocaleZhCN Locale = "zh-CN"
+	LocaleEnUS Locale = "en-US"
+)
+
+// defaultLocale 无法确定语言时使用的默认语言
+const defaultLocale = LocaleZhCN
+
+// localeEnvVar 指定界面语言的环境变量，优先于配置文件和系统语言
+const localeEnvVar = "GPD_TOUCH_LANG"
+
+// currentLocale 当前界面语言
+var currentLocale atomic.Value
+
+// SetLocale 设置当前界面语言
+func SetLocale(locale Locale) {
+	currentLocale.Store(locale)
+}
+
+// CurrentLocale 返回当前界面语言
+func CurrentLocale() Locale {
+	if l, ok := currentLocale.Load().(Locale); ok {
+		return l
+	}
+	return defaultLocale
+}
+
+// ParseLocale 解析语言标识，支持 zh-CN、zh_CN.UTF-8、zh、en-US、en_GB、en 等写法
+// 其他中文变体（zh-TW 等）使用简体中文，其他英文变体使用 en-US
+func ParseLocale(s string) (Locale, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i] // 去掉编码和修饰符，如 en_US.UTF-8、de_DE@euro
+	}
+	lang := strings.ToLower(strings.SplitN(strings.ReplaceAll(s, "_", "-"), "-", 2)[0])
+
+	switch lang {
+	case "zh":
+		return LocaleZhCN, nil
+	case "en":
+		return LocaleEnUS, nil
+	default:
+		return "", fmt.Errorf("不支持的语言: %q（可选 zh-CN、en-US）", s)
+	}
+}
+
+// DetectLocale 确定界面语言：环境变量 GPD_TOUCH_LANG → 配置文件 language → 系统语言 → 简体中文
+// configured 为空或 "auto" 时跳过配置文件
+func DetectLocale(configured string) Locale {
+	if l, err := ParseLocale(os.Getenv(localeEnvVar)); err == nil {
+		return l
+	}
+	if configured != "" && !strings.EqualFold(configured, "auto") {
+		if l, err := ParseLocale(configured); err == nil {
+			return l
+		}
+	}
+	for _, name := range systemLocaleNames() {
+		if l, err := ParseLocale(name); err == nil {
+			return l
+		}
+	}
+	return defaultLocale
+}
+
+// T 按当前语言查找消息并格式化
+func T(key string, args ...any) string {
+	return Tr(CurrentLocale(), key, args...)
+}
+
+// Tr 按指定语言查找消息并格式化；缺少翻译时回退到简体中文，仍找不到时返回键名
+func Tr(locale Locale, key string, args ...any) string {
+	format, ok := messageCatalog[locale][key]
+	if !ok {
+		if format, ok = messageCatalog[defaultLocale][key]; !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
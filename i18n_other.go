@@ -0,0 +1,16 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// systemLocaleNames 按 POSIX 优先级返回语言环境变量
+func systemLocaleNames() []string {
+	var names []string
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			names = append(names, v)
+		}
+	}
+	return names
+}
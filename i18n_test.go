@@ -0,0 +1,128 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// withLocale 在测试期间切换界面语言
+func withLocale(t *testing.T, locale Locale) {
+	t.Helper()
+	prev := CurrentLocale()
+	SetLocale(locale)
+	t.Cleanup(func() { SetLocale(prev) })
+}
+
+// hasHan 文本中是否有汉字（检查英文输出没有混入中文）
+func hasHan(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0
+}
+
+func TestMessageCatalogComplete(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+	zh, en := messageCatalog[LocaleZhCN], messageCatalog[LocaleEnUS]
+	var keys []string
+	for k := range zh {
+		keys = append(keys, k)
+	}
+	for k := range en {
+		if _, ok := zh[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		zv, zok := zh[k]
+		ev, eok := en[k]
+		if !zok || !eok {
+			t.Errorf("%s: 缺少翻译 (zh-CN: %v, en-US: %v)", k, zok, eok)
+			continue
+		}
+		// 两种语言的格式化占位符必须一致，否则参数会错位
+		zs, es := verbs.FindAllString(zv, -1), verbs.FindAllString(ev, -1)
+		if len(zs) != len(es) {
+			t.Errorf("%s: 占位符不一致 %v vs %v", k, zs, es)
+			continue
+		}
+		for i := range zs {
+			if zs[i][len(zs[i])-1] != es[i][len(es[i])-1] {
+				t.Errorf("%s: 占位符不一致 %v vs %v", k, zs, es)
+				break
+			}
+		}
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Locale
+		wantErr bool
+	}{
+		{"zh-CN", LocaleZhCN, false},
+		{"zh_CN.UTF-8", LocaleZhCN, false},
+		{"zh-TW", LocaleZhCN, false},
+		{"zh", LocaleZhCN, false},
+		{"en-US", LocaleEnUS, false},
+		{"en_GB.UTF-8", LocaleEnUS, false},
+		{"EN", LocaleEnUS, false},
+		{"de_DE@euro", "", true},
+		{"C", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLocale(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseLocale(%q) = %q, %v; want %q, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        string
+		configured string
+		lang       string
+		want       Locale
+	}{
+		{"环境变量优先", "en", "zh-CN", "zh_CN.UTF-8", LocaleEnUS},
+		{"配置文件", "", "en-US", "zh_CN.UTF-8", LocaleEnUS},
+		{"auto 使用系统语言", "", "auto", "en_US.UTF-8", LocaleEnUS},
+		{"无效配置使用系统语言", "", "klingon", "en_US.UTF-8", LocaleEnUS},
+		{"都不支持时默认中文", "", "", "C", LocaleZhCN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(localeEnvVar, tt.env)
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_MESSAGES", "")
+			t.Setenv("LANG", tt.lang)
+			if got := DetectLocale(tt.configured); got != tt.want {
+				t.Errorf("DetectLocale(%q) = %q, want %q", tt.configured, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTr(t *testing.T) {
+	if got := Tr(LocaleEnUS, "notify.success.message", "触摸屏"); got != "Device 触摸屏 was repaired successfully" {
+		t.Errorf("Tr(en-US) = %q", got)
+	}
+	if got := Tr(LocaleZhCN, "notify.success.message", "触摸屏"); got != "设备 触摸屏 已成功修复" {
+		t.Errorf("Tr(zh-CN) = %q", got)
+	}
+	if got := Tr("fr-FR", "notify.success.title"); got != "触屏已修复" {
+		t.Errorf("未知语言应回退到中文, got %q", got)
+	}
+	if got := Tr(LocaleEnUS, "no.such.key"); got != "no.such.key" {
+		t.Errorf("未知键应返回键名, got %q", got)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetUserDefaultLocaleName = kernel32.NewProc("GetUserDefaultLocaleName")
+
+// systemLocaleNames 返回 Windows 用户区域设置名称（如 zh-CN、en-US）
+func systemLocaleNames() []string {
+	buf := make([]uint16, 85) // LOCALE_NAME_MAX_LENGTH
+	ret, _, _ := procGetUserDefaultLocaleName.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if ret == 0 {
+		return nil
+	}
+	return []string{syscall.UTF16ToString(buf)}
+}
@@ -0,0 +1,47 @@
+// Package main provides the user idle-time source and the thresholds used to tell idle from active.
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// IdleSource 用户空闲时间来源（距上次键盘/鼠标/触摸输入的时长）
+type IdleSource interface {
+	IdleTime() (time.Duration, error)
+}
+
+// IdleThresholds 空闲/活跃判定阈值；介于两者之间时保持原状态
+type IdleThresholds struct {
+	Doze   time.Duration // 空闲超过该时长视为用户离开（可能已合盖）
+	Active time.Duration // 空闲不超过该时长视为用户活跃
+}
+
+// DefaultIdleThresholds 默认阈值：空闲 5 分钟视为离开，1 分钟内有输入视为活跃
+var DefaultIdleThresholds = IdleThresholds{Doze: 300 * time.Second, Active: 60 * time.Second}
+
+// IsIdle 空闲时间是否达到离开阈值
+func (t IdleThresholds) IsIdle(idle time.Duration) bool {
+	return idle >= t.Doze
+}
+
+// IsActive 空闲时间是否在活跃阈值内
+func (t IdleThresholds) IsActive(idle time.Duration) bool {
+	return idle <= t.Active
+}
+
+// Validate 活跃阈值必须小于离开阈值，否则状态会来回切换
+func (t IdleThresholds) Validate() error {
+	if t.Doze <= 0 || t.Active < 0 {
+		return fmt.Errorf("空闲阈值必须为正数")
+	}
+	if t.Active >= t.Doze {
+		return fmt.Errorf("活跃阈值 (%s) 必须小于空闲阈值 (%s)", t.Active, t.Doze)
+	}
+	return nil
+}
+
+// String 用于日志
+func (t IdleThresholds) String() string {
+	return fmt.Sprintf("空闲 ≥ %s 视为离开，≤ %s 视为活跃", t.Doze, t.Active)
+}
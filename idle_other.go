@@ -0,0 +1,16 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// systemIdleSource 非 Windows 系统没有统一的空闲时间接口（轮询器视为始终活跃）
+type systemIdleSource struct{}
+
+// IdleTime 始终返回错误
+func (systemIdleSource) IdleTime() (time.Duration, error) {
+	return 0, errors.New("当前系统不支持读取空闲时间")
+}
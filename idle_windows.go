@@ -0,0 +1,15 @@
+package main
+
+import "time"
+
+// systemIdleSource 通过 GetLastInputInfo 读取系统空闲时间
+type systemIdleSource struct{}
+
+// IdleTime 距上次用户输入的时长
+func (systemIdleSource) IdleTime() (time.Duration, error) {
+	ms, err := GetIdleTime()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,423 @@
+// Package main provides structured log entries shared by the text and JSON log formats.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogFormat 日志文件格式
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text" // 默认：时间戳 [级别] [标签] 消息
+	LogFormatJSON LogFormat = "json" // 每行一个 JSON 对象
+)
+
+// logTimeLayout 文本日志的时间格式
+const logTimeLayout = "2006-01-02 15:04:05.000"
+
+// ParseLogFormat 解析日志格式（空字符串视为 text）
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch LogFormat(strings.ToLower(strings.TrimSpace(s))) {
+	case "", LogFormatText:
+		return LogFormatText, nil
+	case LogFormatJSON:
+		return LogFormatJSON, nil
+	default:
+		return "", fmt.Errorf("不支持的日志格式: %q（可选 text、json）", s)
+	}
+}
+
+// LogFields 结构化日志字段
+type LogFields struct {
+	Device   string        // 设备名称或 InstanceId
+	Status   string        // 设备状态
+	Duration time.Duration // 操作耗时
+	Attempt  int           // 第几次尝试
+	Trigger  string        // 触发来源（power、oem、poll 等）
+	Episode  string        // 关联的唤醒修复事件 ID
+}
+
+// fieldNames 支持过滤的字段名（按文本格式输出顺序）
+var fieldNames = []string{"device", "status", "duration", "attempt", "trigger", "episode"}
+
+// IsEmpty 是否没有任何字段
+func (f LogFields) IsEmpty() bool {
+	return f == LogFields{}
+}
+
+// Get 按名称获取字段值的字符串形式
+func (f LogFields) Get(name string) string {
+	switch strings.ToLower(name) {
+	case "device":
+		return f.Device
+	case "status":
+		return f.Status
+	case "duration":
+		if f.Duration == 0 {
+			return ""
+		}
+		return f.Duration.String()
+	case "attempt":
+		if f.Attempt == 0 {
+			return ""
+		}
+		return strconv.Itoa(f.Attempt)
+	case "trigger":
+		return f.Trigger
+	case "episode":
+		return f.Episode
+	default:
+		return ""
+	}
+}
+
+// set 按名称设置字段（解析文本日志时使用）
+func (f *LogFields) set(name, value string) {
+	switch name {
+	case "device":
+		f.Device = value
+	case "status":
+		f.Status = value
+	case "duration":
+		f.Duration, _ = time.ParseDuration(value)
+	case "attempt":
+		f.Attempt, _ = strconv.Atoi(value)
+	case "trigger":
+		f.Trigger = value
+	case "episode":
+		f.Episode = value
+	}
+}
+
+// LogEntry 一条日志记录
+type LogEntry struct {
+	Time    time.Time
+	Level   string   // DEBUG / INFO / WARN / ERROR
+	Tag     EventTag // 可为空
+	Message string
+	Fields  LogFields
+	Raw     string // 原始行（无法解析时 Message 为整行）
+}
+
+// jsonLogLine JSON 日志格式的一行
+type jsonLogLine struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	Tag        string `json:"tag,omitempty"`
+	Message    string `json:"message"`
+	Device     string `json:"device,omitempty"`
+	Status     string `json:"status,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Attempt    int    `json:"attempt,omitempty"`
+	Trigger    string `json:"trigger,omitempty"`
+	Episode    string `json:"episode,omitempty"`
+}
+
+// FormatText 格式化为文本日志行：时间戳 [级别] [标签] 消息 | 字段
+func (e *LogEntry) FormatText() string {
+	var sb strings.Builder
+	sb.WriteString(e.Time.Format(logTimeLayout))
+	sb.WriteString(fmt.Sprintf(" [%-5s]", e.Level))
+	if e.Tag != "" {
+		sb.WriteString(fmt.Sprintf(" [%-7s]", e.Tag))
+	}
+	sb.WriteString(" ")
+	sb.WriteString(e.Message)
+
+	if !e.Fields.IsEmpty() {
+		sb.WriteString(" |")
+		for _, name := range fieldNames {
+			value := e.Fields.Get(name)
+			if value == "" {
+				continue
+			}
+			if strings.ContainsAny(value, " \t\"=|") {
+				value = strconv.Quote(value)
+			}
+			sb.WriteString(fmt.Sprintf(" %s=%s", name, value))
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatJSON 格式化为 JSON 日志行
+func (e *LogEntry) FormatJSON() string {
+	line := jsonLogLine{
+		Time:       e.Time.Format(time.RFC3339Nano),
+		Level:      e.Level,
+		Tag:        string(e.Tag),
+		Message:    e.Message,
+		Device:     e.Fields.Device,
+		Status:     e.Fields.Status,
+		DurationMs: e.Fields.Duration.Milliseconds(),
+		Attempt:    e.Fields.Attempt,
+		Trigger:    e.Fields.Trigger,
+		Episode:    e.Fields.Episode,
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return e.FormatText()
+	}
+	return string(data)
+}
+
+// textLogPattern 文本日志行：时间戳 [级别] [标签]（可选） 消息
+var textLogPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3}) \[(\w+)\s*\](?: \[(\w+)\s*\])? (.*)$`)
+
+// ParseLogLine 解析一行日志（同时支持文本和 JSON 格式）
+// 返回 false 表示无法识别，此时 Message 为整行内容
+func ParseLogLine(line string) (LogEntry, bool) {
+	entry := LogEntry{Raw: line, Message: line}
+
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var jl jsonLogLine
+		if err := json.Unmarshal([]byte(trimmed), &jl); err != nil {
+			return entry, false
+		}
+		entry.Time, _ = time.Parse(time.RFC3339Nano, jl.Time)
+		entry.Level = jl.Level
+		entry.Tag = EventTag(jl.Tag)
+		entry.Message = jl.Message
+		entry.Fields = LogFields{
+			Device:   jl.Device,
+			Status:   jl.Status,
+			Duration: time.Duration(jl.DurationMs) * time.Millisecond,
+			Attempt:  jl.Attempt,
+			Trigger:  jl.Trigger,
+			Episode:  jl.Episode,
+		}
+		return entry, true
+	}
+
+	m := textLogPattern.FindStringSubmatch(line)
+	if m == nil {
+		return entry, false
+	}
+
+	entry.Time, _ = time.ParseInLocation(logTimeLayout, m[1], time.Local)
+	entry.Level = m[2]
+	entry.Tag = EventTag(m[3])
+	entry.Message = m[4]
+
+	// 字段位于最后一个 " | " 之后
+	if idx := strings.LastIndex(entry.Message, " | "); idx >= 0 {
+		if fields, ok := parseTextFields(entry.Message[idx+3:]); ok {
+			entry.Fields = fields
+			entry.Message = entry.Message[:idx]
+		}
+	}
+
+	return entry, true
+}
+
+// parseTextFields 解析 key=value 形式的字段列表（值可以带引号）
+func parseTextFields(s string) (LogFields, bool) {
+	var fields LogFields
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return fields, false
+	}
+
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return LogFields{}, false
+		}
+		name := rest[:eq]
+		if !isFieldName(name) {
+			return LogFields{}, false
+		}
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return LogFields{}, false
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else if sp := strings.IndexByte(rest, ' '); sp >= 0 {
+			value = rest[:sp]
+			rest = rest[sp:]
+		} else {
+			value = rest
+			rest = ""
+		}
+
+		fields.set(name, value)
+		rest = strings.TrimLeft(rest, " ")
+	}
+
+	return fields, true
+}
+
+// isFieldName 是否是已知字段名
+func isFieldName(name string) bool {
+	for _, n := range fieldNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LogFilter 日志过滤条件（各条件之间为"与"关系，零值表示不限制）
+type LogFilter struct {
+	Fields map[string]string // 字段名 -> 期望值（不区分大小写）
+	Levels map[string]bool   // 允许的级别（DEBUG / INFO / WARN / ERROR）
+	Tags   map[EventTag]bool // 允许的事件标签
+	Since  time.Time         // 只保留此时间之后的记录
+	Grep   *regexp.Regexp    // 匹配日志内容
+}
+
+// logLevelNames 日志级别名称，按严重程度从低到高
+var logLevelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// ParseLevelFilter 解析级别过滤表达式
+// "WARN+" 表示 WARN 及以上，"INFO,ERROR" 表示仅这些级别
+func ParseLevelFilter(expr string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		orAbove := strings.HasSuffix(part, "+")
+		part = strings.TrimSuffix(part, "+")
+		if part == "WARNING" {
+			part = "WARN"
+		}
+
+		idx := -1
+		for i, name := range logLevelNames {
+			if name == part {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("无效的日志级别: %q（可选 %s，后缀 + 表示该级别及以上）", part, strings.Join(logLevelNames, "、"))
+		}
+
+		result[part] = true
+		if orAbove {
+			for _, name := range logLevelNames[idx:] {
+				result[name] = true
+			}
+		}
+	}
+	return result, nil
+}
+
+// ParseTagFilter 解析标签过滤表达式，如 "RESUME,FAIL"
+func ParseTagFilter(expr string) (map[EventTag]bool, error) {
+	result := make(map[EventTag]bool)
+	for _, part := range strings.Split(expr, ",") {
+		tag := EventTag(strings.ToUpper(strings.TrimSpace(part)))
+		if tag == "" {
+			continue
+		}
+		if !isKnownTag(tag) {
+			return nil, fmt.Errorf("未知的日志标签: %q", part)
+		}
+		result[tag] = true
+	}
+	return result, nil
+}
+
+// ParseSince 解析起始时间：相对时长（如 2h、30m、1d）或绝对时间（如 2025-12-24、2025-12-24 08:00）
+func ParseSince(expr string, now time.Time) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+
+	if days, ok := strings.CutSuffix(expr, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(expr); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, expr, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, expr); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("无效的起始时间: %q（示例: 2h、1d、2025-12-24 08:00）", expr)
+}
+
+// ParseFieldFilter 解析字段过滤表达式，如 "status=Error,trigger=poll"
+func ParseFieldFilter(expr string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !isFieldName(name) {
+			return nil, fmt.Errorf("无效的字段过滤条件: %q（可用字段: %s）", part, strings.Join(fieldNames, ", "))
+		}
+		result[name] = strings.TrimSpace(value)
+	}
+	return result, nil
+}
+
+// Match 判断日志记录是否满足过滤条件
+func (f *LogFilter) Match(entry *LogEntry) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Levels) > 0 && !f.Levels[entry.Level] {
+		return false
+	}
+	if len(f.Tags) > 0 && !f.Tags[entry.Tag] {
+		return false
+	}
+	// 无法解析时间的行（如旧版日志）在指定起始时间时不显示
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if f.Grep != nil {
+		text := entry.Raw
+		if !entry.Time.IsZero() {
+			text = entry.FormatText() // JSON 行按文本形式匹配，两种格式行为一致
+		}
+		if !f.Grep.MatchString(text) {
+			return false
+		}
+	}
+	for name, want := range f.Fields {
+		if !strings.EqualFold(entry.Fields.Get(name), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterLogLines 按条件过滤日志行
+func FilterLogLines(lines []string, filter *LogFilter) []string {
+	if filter == nil {
+		return lines
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		entry, _ := ParseLogLine(line)
+		if filter.Match(&entry) {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LogFormat
+		wantErr bool
+	}{
+		{"", LogFormatText, false},
+		{"text", LogFormatText, false},
+		{"JSON", LogFormatJSON, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLogFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLogFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLogFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogEntry_RoundTrip(t *testing.T) {
+	entry := LogEntry{
+		Time:    time.Date(2025, 12, 24, 8, 30, 15, 123000000, time.Local),
+		Level:   "WARN",
+		Tag:     TagFail,
+		Message: "修复后设备仍处于异常状态: Error",
+		Fields: LogFields{
+			Device:   "I2C HID Device",
+			Status:   "Error",
+			Duration: 2500 * time.Millisecond,
+			Attempt:  3,
+			Trigger:  "poll",
+		},
+	}
+
+	for _, tt := range []struct {
+		name string
+		line string
+	}{
+		{"text", entry.FormatText()},
+		{"json", entry.FormatJSON()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseLogLine(tt.line)
+			if !ok {
+				t.Fatalf("ParseLogLine(%q) failed", tt.line)
+			}
+			if !got.Time.Equal(entry.Time) {
+				t.Errorf("Time = %v, want %v", got.Time, entry.Time)
+			}
+			if got.Level != entry.Level || got.Tag != entry.Tag || got.Message != entry.Message {
+				t.Errorf("got %q/%q/%q, want %q/%q/%q",
+					got.Level, got.Tag, got.Message, entry.Level, entry.Tag, entry.Message)
+			}
+			if got.Fields != entry.Fields {
+				t.Errorf("Fields = %+v, want %+v", got.Fields, entry.Fields)
+			}
+		})
+	}
+}
+
+func TestParseLogLine_Legacy(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		ok      bool
+		level   string
+		tag     EventTag
+		message string
+	}{
+		{
+			name:    "无标签",
+			line:    "2025-01-01 12:00:00.000 [INFO ] Normal log",
+			ok:      true,
+			level:   "INFO",
+			message: "Normal log",
+		},
+		{
+			name:    "带标签",
+			line:    "2025-01-01 12:00:00.000 [INFO ] [RESUME ] 系统从睡眠唤醒",
+			ok:      true,
+			level:   "INFO",
+			tag:     TagResume,
+			message: "系统从睡眠唤醒",
+		},
+		{
+			name:    "消息中含竖线但不是字段",
+			line:    "2025-01-01 12:00:00.000 [ERROR] [FAIL   ] a | b",
+			ok:      true,
+			level:   "ERROR",
+			tag:     TagFail,
+			message: "a | b",
+		},
+		{
+			name:    "无法识别",
+			line:    "2025/01/01 12:00:00 正在禁用设备",
+			ok:      false,
+			message: "2025/01/01 12:00:00 正在禁用设备",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseLogLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ParseLogLine() ok = %v, want %v", ok, tt.ok)
+			}
+			if got.Level != tt.level || got.Tag != tt.tag || got.Message != tt.message {
+				t.Errorf("got %q/%q/%q, want %q/%q/%q", got.Level, got.Tag, got.Message, tt.level, tt.tag, tt.message)
+			}
+		})
+	}
+}
+
+func TestFilterLogLines(t *testing.T) {
+	base := time.Date(2025, 12, 24, 8, 0, 0, 0, time.Local)
+	poll := LogEntry{Time: base, Level: "WARN", Tag: TagFail, Message: "a", Fields: LogFields{Status: "Error", Trigger: "poll"}}
+	power := LogEntry{Time: base, Level: "INFO", Tag: TagSuccess, Message: "b", Fields: LogFields{Status: "OK", Trigger: "power"}}
+
+	lines := []string{
+		poll.FormatText(),
+		power.FormatJSON(),
+		poll.FormatJSON(),
+		"2025-12-24 08:00:00.000 [INFO ] trigger=poll 仅出现在消息中",
+	}
+
+	fields, err := ParseFieldFilter("trigger=poll, status=error")
+	if err != nil {
+		t.Fatalf("ParseFieldFilter() error = %v", err)
+	}
+
+	got := FilterLogLines(lines, &LogFilter{Fields: fields})
+	if len(got) != 2 {
+		t.Fatalf("FilterLogLines() returned %d lines, want 2: %v", len(got), got)
+	}
+	if got[0] != lines[0] || got[1] != lines[2] {
+		t.Errorf("FilterLogLines() = %v", got)
+	}
+}
+
+func TestParseFieldFilter_Invalid(t *testing.T) {
+	for _, expr := range []string{"unknown=1", "status"} {
+		if _, err := ParseFieldFilter(expr); err == nil {
+			t.Errorf("ParseFieldFilter(%q) should fail", expr)
+		}
+	}
+}
+
+func TestFormatLogForDisplay_JSON(t *testing.T) {
+	entry := LogEntry{Time: time.Now(), Level: "INFO", Tag: TagSuccess, Message: "触屏设备修复成功"}
+
+	formatted := FormatLogForDisplay([]string{entry.FormatJSON()})
+
+	if !strings.Contains(formatted, "+ ") || !strings.Contains(formatted, "[SUCCESS]") {
+		t.Errorf("JSON entries should be displayed like text entries, got:\n%s", formatted)
+	}
+	if strings.Contains(formatted, "{") {
+		t.Error("JSON entries should not be displayed raw")
+	}
+}
+
+func TestParseLevelFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{"WARN+", "ERROR,WARN", false},
+		{"info", "INFO", false},
+		{"debug+", "DEBUG,ERROR,INFO,WARN", false},
+		{"INFO, warning", "INFO,WARN", false},
+		{"LOUD", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseLevelFilter(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevelFilter(%q) error = %v", tt.expr, err)
+			}
+			var names []string
+			for _, name := range logLevelNames {
+				if got[name] {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != tt.want {
+				t.Errorf("ParseLevelFilter(%q) = %v, want %s", tt.expr, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2h", now.Add(-2 * time.Hour), false},
+		{"1d", now.AddDate(0, 0, -1), false},
+		{"2025-12-20", time.Date(2025, 12, 20, 0, 0, 0, 0, time.Local), false},
+		{"2025-12-20 08:30", time.Date(2025, 12, 20, 8, 30, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseSince(tt.expr, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSince(%q) error = %v", tt.expr, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseSince(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogFilter_GrepAndEpisode(t *testing.T) {
+	base := time.Date(2025, 12, 24, 8, 0, 0, 0, time.Local)
+	a := LogEntry{Time: base, Level: "INFO", Tag: TagReset, Message: "正在禁用设备", Fields: LogFields{Episode: "ep-1"}}
+	b := LogEntry{Time: base, Level: "INFO", Tag: TagReset, Message: "正在启用设备", Fields: LogFields{Episode: "ep-2"}}
+	lines := []string{a.FormatText(), b.FormatJSON()}
+
+	got := FilterLogLines(lines, &LogFilter{Grep: regexp.MustCompile("(?i)启用")})
+	if len(got) != 1 || got[0] != lines[1] {
+		t.Errorf("grep filter = %v", got)
+	}
+
+	got = FilterLogLines(lines, &LogFilter{Fields: map[string]string{"episode": "ep-1"}})
+	if len(got) != 1 || got[0] != lines[0] {
+		t.Errorf("episode filter = %v", got)
+	}
+}
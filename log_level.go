@@ -0,0 +1,128 @@
+// Package main provides log level parsing and the runtime level override shared by the CLI and the service.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ParseLogLevel 解析日志级别字符串（不区分大小写，空字符串视为 INFO）
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "", "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARNING, nil
+	case "ERROR":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("无效的日志级别: %q（可选 DEBUG、INFO、WARN、ERROR）", s)
+	}
+}
+
+// ParseTagLevels 解析按标签覆盖的日志级别，如 {"CHECK": "DEBUG"}
+func ParseTagLevels(m map[string]string) (map[EventTag]LogLevel, error) {
+	result := make(map[EventTag]LogLevel, len(m))
+	for name, levelStr := range m {
+		tag := EventTag(strings.ToUpper(strings.TrimSpace(name)))
+		if !isKnownTag(tag) {
+			return nil, fmt.Errorf("未知的日志标签: %q", name)
+		}
+		level, err := ParseLogLevel(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("标签 %s: %w", tag, err)
+		}
+		result[tag] = level
+	}
+	return result, nil
+}
+
+// isKnownTag 是否是已知的事件标签
+func isKnownTag(tag EventTag) bool {
+	for _, t := range allEventTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// LevelOverride 运行时临时日志级别（由 CLI 写入，服务定期读取）
+type LevelOverride struct {
+	Level string    `json:"level"`
+	Until time.Time `json:"until"`
+}
+
+// GetLevelOverridePath 获取临时日志级别文件路径（与配置文件同目录）
+func GetLevelOverridePath() string {
+	return filepath.Join(filepath.Dir(GetConfigPath()), "log-level-override.json")
+}
+
+// WriteLevelOverride 写入临时日志级别文件
+func WriteLevelOverride(path string, o LevelOverride) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化临时日志级别失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("写入临时日志级别失败: %w", err)
+	}
+	return nil
+}
+
+// ReadLevelOverride 读取临时日志级别文件
+func ReadLevelOverride(path string) (*LevelOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var o LevelOverride
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("解析临时日志级别失败: %w", err)
+	}
+	return &o, nil
+}
+
+// WatchLevelOverride 定期检查临时日志级别文件，变化时应用到 logger，直到 stop 关闭
+func WatchLevelOverride(logger *Logger, path string, interval time.Duration, stop <-chan struct{}) {
+	var lastMod time.Time
+
+	check := func() {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(lastMod) {
+			return
+		}
+		lastMod = info.ModTime()
+
+		o, err := ReadLevelOverride(path)
+		if err != nil {
+			logger.WarningTag(TagConfig, "读取临时日志级别失败: %v", err)
+			return
+		}
+		level, err := ParseLogLevel(o.Level)
+		if err != nil {
+			logger.WarningTag(TagConfig, "临时日志级别无效: %v", err)
+			return
+		}
+		logger.SetTemporaryLevel(level, o.Until)
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LogLevel
+		wantErr bool
+	}{
+		{"", INFO, false},
+		{"debug", DEBUG, false},
+		{"INFO", INFO, false},
+		{"WARN", WARNING, false},
+		{"warning", WARNING, false},
+		{" ERROR ", ERROR, false},
+		{"TRACE", INFO, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLogLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLogLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLogLevel(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagLevels(t *testing.T) {
+	got, err := ParseTagLevels(map[string]string{"check": "DEBUG", "RESET": "warn"})
+	if err != nil {
+		t.Fatalf("ParseTagLevels() error = %v", err)
+	}
+	if got[TagCheck] != DEBUG || got[TagReset] != WARNING {
+		t.Errorf("ParseTagLevels() = %v", got)
+	}
+
+	for _, bad := range []map[string]string{
+		{"UNKNOWN": "DEBUG"},
+		{"CHECK": "LOUD"},
+	} {
+		if _, err := ParseTagLevels(bad); err == nil {
+			t.Errorf("ParseTagLevels(%v) should fail", bad)
+		}
+	}
+}
+
+func TestLogger_TagLevels(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+	l.SetLevel(WARNING)
+	l.SetTagLevels(map[EventTag]LogLevel{TagCheck: DEBUG, TagFail: ERROR})
+
+	l.DebugTag(TagCheck, "check-debug")   // 标签放宽，应输出
+	l.InfoTag(TagReset, "reset-info")     // 全局级别过滤
+	l.WarningTag(TagFail, "fail-warning") // 标签收紧，应过滤
+	l.ErrorTag(TagFail, "fail-error")     // 应输出
+	l.Warning("untagged-warning")         // 无标签使用全局级别
+
+	lines, err := readLogLinesFrom(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	got := strings.Join(lines, "\n")
+	for _, want := range []string{"check-debug", "fail-error", "untagged-warning"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"reset-info", "fail-warning"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestLogger_TemporaryLevelExpires(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+	l.SetLevel(INFO)
+
+	l.SetTemporaryLevel(DEBUG, now.Add(30*time.Minute))
+	l.Debug("during-override")
+
+	now = now.Add(31 * time.Minute)
+	l.Debug("after-expiry")
+
+	lines, err := readLogLinesFrom(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	got := strings.Join(lines, "\n")
+	if !strings.Contains(got, "during-override") {
+		t.Errorf("debug line during override missing:\n%s", got)
+	}
+	if strings.Contains(got, "after-expiry") {
+		t.Errorf("debug line after expiry should be filtered:\n%s", got)
+	}
+	if !strings.Contains(got, "已到期") {
+		t.Errorf("expiry should be logged:\n%s", got)
+	}
+}
+
+func TestWatchLevelOverride(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log-level-override.json")
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+	l.SetLevel(INFO)
+
+	if err := WriteLevelOverride(path, LevelOverride{Level: "DEBUG", Until: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("WriteLevelOverride() error = %v", err)
+	}
+	o, err := ReadLevelOverride(path)
+	if err != nil || o.Level != "DEBUG" {
+		t.Fatalf("ReadLevelOverride() = %+v, %v", o, err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		WatchLevelOverride(l, path, 10*time.Millisecond, stop)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		l.mu.Lock()
+		applied := !l.tempUntil.IsZero()
+		l.mu.Unlock()
+		if applied {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("override was not applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(stop)
+	<-done
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("override file should remain: %v", err)
+	}
+}
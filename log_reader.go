@@ -0,0 +1,318 @@
+// Package main provides backward log reading and follow mode for -show-log.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// reverseChunkSize 从文件末尾向前读取时每次读取的字节数
+const reverseChunkSize = 64 * 1024
+
+// scanLinesBackward 从文件末尾向前逐行回调，fn 返回 false 时停止
+// 未压缩文件按块倒序读取，不会把整个文件读入内存；.gz 文件只能整体解压
+func scanLinesBackward(path string, fn func(line string) bool) error {
+	if strings.HasSuffix(path, ".gz") {
+		lines, err := readFileLines(path)
+		if err != nil {
+			return err
+		}
+		for i := len(lines) - 1; i >= 0; i-- {
+			if !fn(lines[i]) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	pos := info.Size()
+	var rest []byte // 上一块开头不完整的行
+	buf := make([]byte, reverseChunkSize)
+
+	for pos > 0 {
+		n := int64(reverseChunkSize)
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil && err != io.EOF {
+			return err
+		}
+
+		chunk := append(buf[:n:n], rest...)
+		for {
+			idx := bytes.LastIndexByte(chunk, '\n')
+			if idx < 0 {
+				break
+			}
+			line := strings.TrimSuffix(string(chunk[idx+1:]), "\r")
+			chunk = chunk[:idx]
+			if line == "" {
+				continue
+			}
+			if !fn(line) {
+				return nil
+			}
+		}
+		rest = append([]byte(nil), chunk...)
+	}
+
+	if len(rest) > 0 {
+		fn(strings.TrimSuffix(string(rest), "\r"))
+	}
+	return nil
+}
+
+// LogFollower 持续读取服务新写入的日志行，日志轮转后自动切换到新文件
+// 每次读取后立即关闭文件，避免妨碍服务重命名正在写入的日志
+type LogFollower struct {
+	dir     string
+	path    string // 当前跟随的文件
+	offset  int64  // 已读取到的位置
+	head    string // 文件第一行，用于识别同名文件是否已被替换
+	partial string // 尚未写完的最后一行
+}
+
+// NewLogFollower 创建跟随器，从当前日志文件末尾开始
+func NewLogFollower(dir string) (*LogFollower, error) {
+	f := &LogFollower{dir: dir}
+	path, err := f.activeFile()
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		f.path = path
+		f.offset = info.Size()
+		f.head = readFirstLine(path)
+	}
+	return f, nil
+}
+
+// activeFile 返回最新的未压缩日志文件（没有日志时返回空字符串）
+func (f *LogFollower) activeFile() (string, error) {
+	files, err := listLogFiles(f.dir)
+	if err != nil {
+		return "", fmt.Errorf("查找日志文件失败: %w", err)
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if !files[i].Compressed {
+			return files[i].Path, nil
+		}
+	}
+	return "", nil
+}
+
+// Poll 返回自上次调用以来新写入的完整日志行
+func (f *LogFollower) Poll() ([]string, error) {
+	active, err := f.activeFile()
+	if err != nil {
+		return nil, err
+	}
+	if active == "" {
+		return nil, nil
+	}
+
+	var lines []string
+
+	if f.path == "" {
+		// 开始跟随时还没有日志文件
+		f.switchTo(active)
+	} else if active != f.path || f.replaced() {
+		// 已轮转：先读完旧文件剩余内容，再从头读新文件
+		lines = append(lines, f.readArchivedRemainder(active)...)
+		f.switchTo(active)
+	}
+
+	newLines, err := f.readFrom(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return lines, err
+	}
+	return append(lines, newLines...), nil
+}
+
+// replaced 当前路径的文件是否已被新文件替换（按大小轮转后同名文件重新创建）
+func (f *LogFollower) replaced() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return true
+	}
+	if info.Size() < f.offset {
+		return true
+	}
+	return f.head != "" && readFirstLine(f.path) != f.head
+}
+
+// switchTo 从头开始跟随指定文件
+func (f *LogFollower) switchTo(path string) {
+	f.path = path
+	f.offset = 0
+	f.head = ""
+	f.partial = ""
+}
+
+// readArchivedRemainder 读取被轮转走的旧文件中尚未读取的内容
+// 两次读取之间可能发生多次轮转，因此还会读取旧文件之后、当前文件之前的所有分段
+func (f *LogFollower) readArchivedRemainder(active string) []string {
+	files, err := listLogFiles(f.dir)
+	if err != nil {
+		return nil
+	}
+
+	start := f.archivedIndex(files, active)
+	if start < 0 {
+		return nil
+	}
+
+	var lines []string
+	for i := start; i < len(files) && files[i].Path != active; i++ {
+		skip := int64(0)
+		if i == start {
+			skip = f.offset
+		}
+		lines = append(lines, f.readRemainder(files[i].Path, skip)...)
+	}
+	return lines
+}
+
+// archivedIndex 查找当前跟随的文件轮转后的位置（按第一行识别，可能已被改名或压缩）
+func (f *LogFollower) archivedIndex(files []logFileInfo, active string) int {
+	if f.head != "" {
+		for i := len(files) - 1; i >= 0; i-- {
+			if files[i].Path != active && readFirstLine(files[i].Path) == f.head {
+				return i
+			}
+		}
+	}
+
+	// 还没有读到完整的第一行：跨天轮转时旧文件保留原名
+	for i := range files {
+		if files[i].Path == f.path || files[i].Path == f.path+".gz" {
+			if files[i].Path != active {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// readRemainder 读取文件 skip 字节之后的所有行（.gz 文件透明解压）
+func (f *LogFollower) readRemainder(path string, skip int64) []string {
+	r, err := openLogReader(path)
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	if _, err := io.CopyN(io.Discard, r, skip); err != nil && skip > 0 {
+		return nil
+	}
+	data, _ := io.ReadAll(r)
+	return f.splitLines(data, true)
+}
+
+// readFrom 从上次位置读取文件新增内容
+func (f *LogFollower) readFrom(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	f.offset += int64(len(data))
+	if f.head == "" && f.offset > 0 {
+		f.head = readFirstLine(path)
+	}
+
+	return f.splitLines(data, false), nil
+}
+
+// splitLines 拆分为完整行；final 为 true 时最后一行不完整也输出
+func (f *LogFollower) splitLines(data []byte, final bool) []string {
+	text := f.partial + string(data)
+	f.partial = ""
+
+	parts := strings.Split(text, "\n")
+	if !final {
+		f.partial = parts[len(parts)-1]
+	}
+	if !final || parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+
+	lines := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSuffix(p, "\r"); p != "" {
+			lines = append(lines, p)
+		}
+	}
+	return lines
+}
+
+// readFirstLine 读取文件第一行（.gz 文件透明解压）
+func readFirstLine(path string) string {
+	file, err := openLogReader(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "" // 第一行尚未写完
+	}
+	return line
+}
+
+// FollowLogs 持续输出满足过滤条件的新日志行，直到 stop 关闭
+func FollowLogs(dir string, filter *LogFilter, interval time.Duration, stop <-chan struct{}, emit func(line string)) error {
+	follower, err := NewLogFollower(dir)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			lines, err := follower.Poll()
+			for _, line := range FilterLogLines(lines, filter) {
+				emit(line)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
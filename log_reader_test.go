@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanLinesBackward(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gpd-touch_2025-12-24.log")
+
+	// 超过一个读取块，验证跨块的行拼接
+	const total = 5000
+	var sb strings.Builder
+	for i := 0; i < total; i++ {
+		fmt.Fprintf(&sb, "line %04d %s\r\n", i, strings.Repeat("x", i%50))
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	if err := scanLinesBackward(path, func(line string) bool {
+		got = append(got, line)
+		return true
+	}); err != nil {
+		t.Fatalf("scanLinesBackward() error = %v", err)
+	}
+
+	if len(got) != total {
+		t.Fatalf("got %d lines, want %d", len(got), total)
+	}
+	for i, line := range got {
+		want := fmt.Sprintf("line %04d %s", total-1-i, strings.Repeat("x", (total-1-i)%50))
+		if line != want {
+			t.Fatalf("got[%d] = %q, want %q", i, line, want)
+		}
+	}
+
+	// 提前停止
+	count := 0
+	_ = scanLinesBackward(path, func(string) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Errorf("callback called %d times after stop, want 3", count)
+	}
+}
+
+func TestReadLogLinesFrom_Filters(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+
+	l.InfoTag(TagResume, "old resume")
+	now = now.Add(3 * time.Hour)
+	l.InfoTag(TagResume, "recent resume")
+	l.WarningTag(TagFail, "recent failure")
+	l.ErrorTag(TagFail, "recent error")
+	l.InfoTag(TagCheck, "recent check")
+
+	since, err := ParseSince("1h", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels, _ := ParseLevelFilter("WARN+")
+	tags, _ := ParseTagFilter("resume,fail")
+
+	tests := []struct {
+		name   string
+		filter *LogFilter
+		want   []string
+	}{
+		{"时间", &LogFilter{Since: since}, []string{"recent resume", "recent failure", "recent error", "recent check"}},
+		{"级别", &LogFilter{Levels: levels}, []string{"recent failure", "recent error"}},
+		{"标签", &LogFilter{Tags: tags, Since: since}, []string{"recent resume", "recent failure", "recent error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := readLogLinesFrom(dir, 10, tt.filter)
+			if err != nil {
+				t.Fatalf("readLogLinesFrom() error = %v", err)
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("lines = %v, want %v", lines, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("lines[%d] = %q, want %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogFollower_AcrossRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 23, 0, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{MaxSizeBytes: 200, Compress: true})
+
+	l.Info("before follow")
+
+	f, err := NewLogFollower(dir)
+	if err != nil {
+		t.Fatalf("NewLogFollower() error = %v", err)
+	}
+
+	var got []string
+	poll := func() {
+		t.Helper()
+		lines, err := f.Poll()
+		if err != nil {
+			t.Fatalf("Poll() error = %v", err)
+		}
+		got = append(got, lines...)
+	}
+
+	// 按大小轮转（旧分段被压缩）
+	for i := 0; i < 3; i++ {
+		l.Info("size %d %s", i, strings.Repeat("x", 60))
+		poll()
+	}
+	for i := 3; i < 6; i++ {
+		l.Info("size %d %s", i, strings.Repeat("x", 60))
+	}
+	poll()
+
+	// 跨天轮转
+	l.Info("last of day")
+	now = now.Add(2 * time.Hour)
+	l.Info("next day")
+	poll()
+
+	want := []string{"size 0", "size 1", "size 2", "size 3", "size 4", "size 5", "last of day", "next day"}
+	if len(got) != len(want) {
+		t.Fatalf("followed %d lines, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i, w := range want {
+		if !strings.Contains(got[i], w) {
+			t.Errorf("got[%d] = %q, want %q", i, got[i], w)
+		}
+	}
+}
+
+func TestLogFollower_PartialLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gpd-touch_2025-12-24.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewLogFollower(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendText := func(s string) {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.WriteString(s)
+		file.Close()
+	}
+
+	appendText("half")
+	if lines, _ := f.Poll(); len(lines) != 0 {
+		t.Errorf("incomplete line should not be returned, got %v", lines)
+	}
+	appendText(" line\nnext\n")
+	lines, _ := f.Poll()
+	if strings.Join(lines, ",") != "half line,next" {
+		t.Errorf("Poll() = %v", lines)
+	}
+}
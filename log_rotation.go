@@ -0,0 +1,224 @@
+// Package main provides log file rotation, compression and retention for the long-running service.
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogRotation 日志轮转与保留策略
+type LogRotation struct {
+	MaxSizeBytes  int64 // 单个日志文件最大字节数，超过后轮转（0=仅按日期轮转）
+	Compress      bool  // 轮转后的文件是否 gzip 压缩
+	MaxDays       int   // 保留天数（0=不限制）
+	MaxFiles      int   // 最多保留的文件数，含当前文件（0=不限制）
+	MaxTotalBytes int64 // 所有日志文件总大小上限（0=不限制）
+}
+
+// logFilePattern 日志文件名：gpd-touch_日期[.序号].log[.gz]
+// 同一天按大小轮转出的旧分段带序号，当前文件不带序号
+var logFilePattern = regexp.MustCompile(`^gpd-touch_(\d{4}-\d{2}-\d{2})(?:\.(\d+))?\.log(\.gz)?$`)
+
+// logFileInfo 日志文件信息
+type logFileInfo struct {
+	Path       string
+	Date       string // 2006-01-02
+	Segment    int    // 分段序号（0 表示该日期的最新文件）
+	Compressed bool
+	Size       int64
+}
+
+// logFileName 返回指定日期的当前日志文件名
+func logFileName(t time.Time) string {
+	return fmt.Sprintf("gpd-touch_%s.log", t.Format("2006-01-02"))
+}
+
+// listLogFiles 列出目录下所有日志文件，按时间从旧到新排序
+func listLogFiles(dir string) ([]logFileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []logFileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := logFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		info := logFileInfo{
+			Path:       filepath.Join(dir, entry.Name()),
+			Date:       m[1],
+			Compressed: m[3] != "",
+		}
+		if m[2] != "" {
+			info.Segment, _ = strconv.Atoi(m[2])
+		}
+		if fi, err := entry.Info(); err == nil {
+			info.Size = fi.Size()
+		}
+		files = append(files, info)
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		// 同一天：带序号的旧分段在前，不带序号的当前文件最后
+		if (a.Segment == 0) != (b.Segment == 0) {
+			return b.Segment == 0
+		}
+		return a.Segment < b.Segment
+	})
+
+	return files, nil
+}
+
+// nextSegmentPath 返回指定日期下一个可用的分段文件路径
+func nextSegmentPath(dir, date string) string {
+	maxSegment := 0
+	if files, err := listLogFiles(dir); err == nil {
+		for _, f := range files {
+			if f.Date == date && f.Segment > maxSegment {
+				maxSegment = f.Segment
+			}
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("gpd-touch_%s.%d.log", date, maxSegment+1))
+}
+
+// gzipFile 压缩文件为 .gz 并删除原文件
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpPath := path + ".gz.tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	src.Close()
+	if err := os.Rename(tmpPath, path+".gz"); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// openLogReader 打开日志文件，.gz 文件透明解压
+func openLogReader(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipReadCloser{Reader: zr, file: f}, nil
+}
+
+// gzipReadCloser 关闭时同时关闭解压器和底层文件
+type gzipReadCloser struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close 关闭解压器和文件
+func (g *gzipReadCloser) Close() error {
+	err := g.Reader.Close()
+	if ferr := g.file.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// CleanLogs 按保留策略清理日志目录，activePath 为正在写入的文件，永不删除
+func CleanLogs(dir string, r LogRotation, activePath string, now time.Time) error {
+	files, err := listLogFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	isActive := func(f logFileInfo) bool {
+		return activePath != "" && filepath.Clean(f.Path) == filepath.Clean(activePath)
+	}
+
+	// 按日期清理（使用文件名中的日期，而不是修改时间）
+	kept := files[:0]
+	if r.MaxDays > 0 {
+		cutoff := now.AddDate(0, 0, -r.MaxDays).Format("2006-01-02")
+		for _, f := range files {
+			if f.Date < cutoff && !isActive(f) {
+				_ = os.Remove(f.Path)
+				continue
+			}
+			kept = append(kept, f)
+		}
+	} else {
+		kept = files
+	}
+
+	var total int64
+	for _, f := range kept {
+		total += f.Size
+	}
+	remaining := len(kept)
+
+	// 按数量和总大小清理，从最旧的文件开始删除
+	for _, f := range kept {
+		overCount := r.MaxFiles > 0 && remaining > r.MaxFiles
+		overSize := r.MaxTotalBytes > 0 && total > r.MaxTotalBytes
+		if !overCount && !overSize {
+			break
+		}
+		if isActive(f) {
+			continue
+		}
+		if err := os.Remove(f.Path); err == nil {
+			total -= f.Size
+			remaining--
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLogger 创建使用可控时钟的日志记录器（不影响全局 logger）
+func newTestLogger(t *testing.T, dir string, now *time.Time, r LogRotation) *Logger {
+	t.Helper()
+	l := &Logger{
+		level:    DEBUG,
+		format:   LogFormatText,
+		logDir:   dir,
+		rotation: r,
+		now:      func() time.Time { return *now },
+	}
+	if err := l.openFile(*now); err != nil {
+		t.Fatalf("openFile() error = %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func fileNames(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := listLogFiles(dir)
+	if err != nil {
+		t.Fatalf("listLogFiles() error = %v", err)
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, filepath.Base(f.Path))
+	}
+	return names
+}
+
+func TestLogger_RotateAtMidnight(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 23, 59, 59, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+
+	l.Info("before midnight")
+	now = now.Add(2 * time.Second)
+	l.Info("after midnight")
+
+	names := fileNames(t, dir)
+	want := []string{"gpd-touch_2025-12-24.log", "gpd-touch_2025-12-25.log"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+
+	lines, err := readLogLinesFrom(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	if len(lines) != 2 || !strings.Contains(lines[0], "before") || !strings.Contains(lines[1], "after") {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestLogger_RotateBySizeWithCompression(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{MaxSizeBytes: 256, Compress: true})
+
+	const total = 40
+	for i := 0; i < total; i++ {
+		l.Info("line %02d %s", i, strings.Repeat("x", 40))
+		now = now.Add(time.Second)
+	}
+
+	files, err := listLogFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 3 {
+		t.Fatalf("expected several segments, got %v", fileNames(t, dir))
+	}
+	for _, f := range files[:len(files)-1] {
+		if !f.Compressed {
+			t.Errorf("rotated segment %s should be compressed", filepath.Base(f.Path))
+		}
+	}
+	if last := files[len(files)-1]; last.Compressed || last.Segment != 0 {
+		t.Errorf("active file = %+v, want uncompressed current file", last)
+	}
+
+	// 读取时透明解压，并保持时间顺序
+	lines, err := readLogLinesFrom(dir, total, nil)
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	if len(lines) != total {
+		t.Fatalf("read %d lines, want %d", len(lines), total)
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, fmt.Sprintf("line %02d", i)) {
+			t.Fatalf("lines[%d] = %q, out of order", i, line)
+		}
+	}
+}
+
+func TestListLogFiles_Order(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"gpd-touch_2025-12-24.log",
+		"gpd-touch_2025-12-24.10.log.gz",
+		"gpd-touch_2025-12-24.2.log.gz",
+		"gpd-touch_2025-12-23.log.gz",
+		"gpd-touch_2025-12-24.1.log",
+		"other.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := strings.Join(fileNames(t, dir), ",")
+	want := strings.Join([]string{
+		"gpd-touch_2025-12-23.log.gz",
+		"gpd-touch_2025-12-24.1.log",
+		"gpd-touch_2025-12-24.2.log.gz",
+		"gpd-touch_2025-12-24.10.log.gz",
+		"gpd-touch_2025-12-24.log",
+	}, ",")
+	if got != want {
+		t.Errorf("listLogFiles() = %s, want %s", got, want)
+	}
+}
+
+func TestCleanLogs(t *testing.T) {
+	now := time.Date(2025, 12, 24, 10, 0, 0, 0, time.Local)
+
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		for i := 5; i >= 0; i-- {
+			name := logFileName(now.AddDate(0, 0, -i))
+			if err := os.WriteFile(filepath.Join(dir, name), make([]byte, 100), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	tests := []struct {
+		name     string
+		rotation LogRotation
+		active   int // 距今天数，-1 表示无活动文件
+		want     int
+	}{
+		{"按天数", LogRotation{MaxDays: 2}, 0, 3},
+		{"按数量", LogRotation{MaxFiles: 4}, 0, 4},
+		{"按总大小", LogRotation{MaxTotalBytes: 250}, 0, 2},
+		{"不删除活动文件", LogRotation{MaxDays: 1}, 5, 3},
+		{"无限制", LogRotation{}, -1, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setup(t)
+			active := ""
+			if tt.active >= 0 {
+				active = filepath.Join(dir, logFileName(now.AddDate(0, 0, -tt.active)))
+			}
+
+			if err := CleanLogs(dir, tt.rotation, active, now); err != nil {
+				t.Fatalf("CleanLogs() error = %v", err)
+			}
+
+			names := fileNames(t, dir)
+			if len(names) != tt.want {
+				t.Errorf("remaining = %v, want %d files", names, tt.want)
+			}
+			if active != "" {
+				if _, err := os.Stat(active); err != nil {
+					t.Errorf("active file was removed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileLines_Gzip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gpd-touch_2025-12-24.1.log")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(path); err != nil {
+		t.Fatalf("gzipFile() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("original file should be removed after compression")
+	}
+
+	lines, err := readFileLines(path + ".gz")
+	if err != nil {
+		t.Fatalf("readFileLines() error = %v", err)
+	}
+	if strings.Join(lines, ",") != "a,b,c" {
+		t.Errorf("lines = %v", lines)
+	}
+}
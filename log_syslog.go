@@ -0,0 +1,399 @@
+// Package main provides an RFC 5424 syslog sink for forwarding service logs to a local collector.
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogSink 日志输出目标（除日志文件和控制台之外的额外输出）
+// Send 在日志记录器的锁内调用，实现不能阻塞
+type LogSink interface {
+	Send(entry LogEntry)
+	Close() error
+}
+
+// SyslogConfig syslog 输出配置
+type SyslogConfig struct {
+	Network    string `json:"network"`               // udp、tcp、unix（流）、unixgram（数据报）
+	Address    string `json:"address"`               // host:port 或 socket 路径
+	Facility   string `json:"facility,omitempty"`    // user、daemon、local0-local7（默认 user）
+	AppName    string `json:"app_name,omitempty"`    // APP-NAME（默认 gpd-touch-fix）
+	BufferSize int    `json:"buffer_size,omitempty"` // 采集端不可达时最多缓存的消息数（默认 1000）
+}
+
+// syslogFacilities 支持的 facility 名称
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "daemon": 3, "auth": 4, "syslog": 5,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSDID 结构化数据 ID（32473 是 RFC 5612 保留给文档和示例的企业号）
+const syslogSDID = "gpd@32473"
+
+const (
+	syslogDialTimeout  = 2 * time.Second
+	syslogWriteTimeout = 2 * time.Second
+	syslogRetryMin     = time.Second
+	syslogRetryMax     = time.Minute
+)
+
+// Validate 校验 syslog 配置
+func (c *SyslogConfig) Validate() error {
+	switch c.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return fmt.Errorf("不支持的 syslog 协议: %q（可选 udp、tcp、unix、unixgram）", c.Network)
+	}
+	if c.Address == "" {
+		return fmt.Errorf("syslog 地址不能为空")
+	}
+	if _, ok := syslogFacilities[strings.ToLower(c.Facility)]; c.Facility != "" && !ok {
+		return fmt.Errorf("不支持的 syslog facility: %q", c.Facility)
+	}
+	if c.BufferSize < 0 {
+		return fmt.Errorf("syslog buffer_size 必须为非负数")
+	}
+	return nil
+}
+
+// SyslogSink 以 RFC 5424 格式发送日志到 syslog 采集端
+// 发送在后台 goroutine 中进行；采集端不可达时消息暂存在内存队列中，恢复后按顺序补发
+type SyslogSink struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	procID   string
+	maxQueue int
+	dial     func(network, address string) (net.Conn, error)
+
+	mu      sync.Mutex
+	queue   []syslogMessage // 待发送的消息
+	nextSeq uint64
+	dropped int // 队列满时丢弃的消息数（下次发送成功时报告）
+
+	flushMu    sync.Mutex // 保证同一时间只有一个发送过程
+	conn       net.Conn
+	retryDelay time.Duration
+	nextDial   time.Time
+
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	running bool // 后台发送是否已启动
+}
+
+// syslogMessage 已格式化的待发送消息
+type syslogMessage struct {
+	seq  uint64
+	text string
+}
+
+// NewSyslogSink 创建 syslog 输出并启动后台发送
+func NewSyslogSink(cfg SyslogConfig) (*SyslogSink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := newSyslogSink(cfg)
+	s.running = true
+	go s.run()
+	return s, nil
+}
+
+// newSyslogSink 创建 syslog 输出（不启动后台发送，测试中手动调用 Flush）
+func newSyslogSink(cfg SyslogConfig) *SyslogSink {
+	facility := syslogFacilities["user"]
+	if cfg.Facility != "" {
+		facility = syslogFacilities[strings.ToLower(cfg.Facility)]
+	}
+	appName := cfg.AppName
+	if appName == "" {
+		appName = "gpd-touch-fix"
+	}
+	maxQueue := cfg.BufferSize
+	if maxQueue == 0 {
+		maxQueue = 1000
+	}
+	hostname, _ := os.Hostname()
+
+	return &SyslogSink{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: facility,
+		appName:  syslogToken(appName, 48),
+		hostname: syslogToken(hostname, 255),
+		procID:   strconv.Itoa(os.Getpid()),
+		maxQueue: maxQueue,
+		dial: func(network, address string) (net.Conn, error) {
+			return net.DialTimeout(network, address, syslogDialTimeout)
+		},
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Send 格式化并加入发送队列（实现 LogSink）
+func (s *SyslogSink) Send(entry LogEntry) {
+	msg := s.Format(entry)
+
+	s.mu.Lock()
+	s.nextSeq++
+	s.queue = append(s.queue, syslogMessage{seq: s.nextSeq, text: msg})
+	if len(s.queue) > s.maxQueue {
+		drop := len(s.queue) - s.maxQueue
+		s.queue = s.queue[drop:]
+		s.dropped += drop
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending 返回尚未发送的消息数
+func (s *SyslogSink) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// run 后台发送循环
+func (s *SyslogSink) run() {
+	defer close(s.done)
+
+	timer := time.NewTimer(syslogRetryMin)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+
+		_ = s.Flush()
+
+		// 仍有积压时按退避间隔重试
+		timer.Reset(syslogRetryMax)
+		if s.Pending() > 0 {
+			s.flushMu.Lock()
+			delay := time.Until(s.nextDial)
+			s.flushMu.Unlock()
+			if delay < syslogRetryMin {
+				delay = syslogRetryMin
+			}
+			timer.Reset(delay)
+		}
+	}
+}
+
+// Flush 发送队列中的所有消息；采集端不可达时保留消息并返回错误
+func (s *SyslogSink) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		msg := s.queue[0]
+		dropped := s.dropped
+		s.mu.Unlock()
+
+		if err := s.connectLocked(); err != nil {
+			return err
+		}
+
+		// 报告缓存溢出丢弃的消息数
+		if dropped > 0 {
+			notice := s.format(time.Now(), 4, "", fmt.Sprintf("syslog 采集端不可达期间缓存已满，丢弃 %d 条日志", dropped), nil)
+			if err := s.writeLocked(notice); err != nil {
+				return err
+			}
+			s.mu.Lock()
+			s.dropped -= dropped
+			s.mu.Unlock()
+		}
+
+		if err := s.writeLocked(msg.text); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		// 发送期间队列可能因溢出而前移，只有队首仍是这条消息时才移除
+		if len(s.queue) > 0 && s.queue[0].seq == msg.seq {
+			s.queue = s.queue[1:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+// connectLocked 确保已连接，失败时按指数退避推迟下次连接（调用方需持有 flushMu）
+func (s *SyslogSink) connectLocked() error {
+	if s.conn != nil {
+		return nil
+	}
+	if time.Now().Before(s.nextDial) {
+		return fmt.Errorf("syslog 采集端不可达，%s 后重试", time.Until(s.nextDial).Round(time.Second))
+	}
+
+	conn, err := s.dial(s.network, s.address)
+	if err != nil {
+		if s.retryDelay == 0 {
+			s.retryDelay = syslogRetryMin
+		} else if s.retryDelay *= 2; s.retryDelay > syslogRetryMax {
+			s.retryDelay = syslogRetryMax
+		}
+		s.nextDial = time.Now().Add(s.retryDelay)
+		return fmt.Errorf("连接 syslog 采集端失败: %w", err)
+	}
+
+	s.conn = conn
+	s.retryDelay = 0
+	s.nextDial = time.Time{}
+	return nil
+}
+
+// writeLocked 发送一条消息，流式连接使用 RFC 6587 的长度前缀分帧（调用方需持有 flushMu）
+func (s *SyslogSink) writeLocked(msg string) error {
+	data := msg
+	if s.network == "tcp" || s.network == "unix" {
+		data = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+	if _, err := s.conn.Write([]byte(data)); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("发送 syslog 消息失败: %w", err)
+	}
+	return nil
+}
+
+// Close 停止后台发送，尽量发送剩余消息并关闭连接
+func (s *SyslogSink) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+	}
+	close(s.stop)
+	if s.running {
+		<-s.done
+	}
+
+	err := s.Flush()
+
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// syslogSeverity 日志级别对应的 syslog 严重程度
+func syslogSeverity(level string) int {
+	switch level {
+	case "DEBUG":
+		return 7 // debug
+	case "WARN":
+		return 4 // warning
+	case "ERROR":
+		return 3 // err
+	default:
+		return 6 // informational
+	}
+}
+
+// Format 格式化为 RFC 5424 消息：<PRI>1 时间 主机 应用 进程 MSGID [结构化数据] BOM消息
+func (s *SyslogSink) Format(entry LogEntry) string {
+	params := make([][2]string, 0, len(fieldNames)+1)
+	if entry.Tag != "" {
+		params = append(params, [2]string{"tag", string(entry.Tag)})
+	}
+	for _, name := range fieldNames {
+		if value := entry.Fields.Get(name); value != "" {
+			params = append(params, [2]string{name, value})
+		}
+	}
+	return s.format(entry.Time, syslogSeverity(entry.Level), entry.Tag, entry.Message, params)
+}
+
+// format 按 RFC 5424 组装消息
+func (s *SyslogSink) format(t time.Time, severity int, tag EventTag, message string, params [][2]string) string {
+	var sb strings.Builder
+
+	msgID := "-"
+	if tag != "" {
+		msgID = syslogToken(string(tag), 32)
+	}
+	fmt.Fprintf(&sb, "<%d>1 %s %s %s %s %s ",
+		s.facility*8+severity,
+		t.Format("2006-01-02T15:04:05.000000Z07:00"),
+		orNil(s.hostname), orNil(s.appName), orNil(s.procID), msgID)
+
+	if len(params) == 0 {
+		sb.WriteString("-")
+	} else {
+		sb.WriteString("[" + syslogSDID)
+		for _, p := range params {
+			fmt.Fprintf(&sb, ` %s="%s"`, p[0], escapeSDValue(p[1]))
+		}
+		sb.WriteString("]")
+	}
+
+	sb.WriteString(" \xEF\xBB\xBF")
+	sb.WriteString(message)
+	return sb.String()
+}
+
+// escapeSDValue 转义结构化数据参数值中的 "、\ 和 ]
+func escapeSDValue(v string) string {
+	var sb strings.Builder
+	for _, r := range v {
+		if r == '"' || r == '\\' || r == ']' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// syslogToken 头部字段只能包含可打印 ASCII 且不含空格，并限制长度
+func syslogToken(v string, maxLen int) string {
+	var sb strings.Builder
+	for _, r := range v {
+		if r > 32 && r < 127 {
+			sb.WriteRune(r)
+		}
+		if sb.Len() >= maxLen {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// orNil 空字段使用 NILVALUE（-）
+func orNil(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SyslogConfig
+		wantErr bool
+	}{
+		{"UDP", SyslogConfig{Network: "udp", Address: "127.0.0.1:514"}, false},
+		{"Unix 数据报", SyslogConfig{Network: "unixgram", Address: "/dev/log", Facility: "local3"}, false},
+		{"未知协议", SyslogConfig{Network: "http", Address: "x"}, true},
+		{"缺少地址", SyslogConfig{Network: "tcp"}, true},
+		{"未知 facility", SyslogConfig{Network: "udp", Address: "x", Facility: "local9"}, true},
+		{"负缓存", SyslogConfig{Network: "udp", Address: "x", BufferSize: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyslogSink_Format(t *testing.T) {
+	s := newSyslogSink(SyslogConfig{Network: "udp", Address: "127.0.0.1:514", Facility: "local0"})
+	s.hostname = "gpd-win"
+	s.procID = "1234"
+
+	entry := LogEntry{
+		Time:    time.Date(2025, 12, 24, 8, 30, 15, 123000000, time.UTC),
+		Level:   "WARN",
+		Tag:     TagFail,
+		Message: "修复后设备仍处于异常状态: Error",
+		Fields:  LogFields{Device: `I2C "HID"]`, Attempt: 2, Episode: "ep-1"},
+	}
+
+	got := s.Format(entry)
+	want := `<132>1 2025-12-24T08:30:15.123000Z gpd-win gpd-touch-fix 1234 FAIL ` +
+		`[gpd@32473 tag="FAIL" device="I2C \"HID\"\]" attempt="2" episode="ep-1"] ` +
+		"\xEF\xBB\xBF修复后设备仍处于异常状态: Error"
+	if got != want {
+		t.Errorf("Format() =\n%q\nwant\n%q", got, want)
+	}
+
+	// 无标签和字段时使用 NILVALUE
+	plain := s.Format(LogEntry{Time: entry.Time, Level: "DEBUG", Message: "x"})
+	if !strings.HasPrefix(plain, "<135>1 ") || !strings.Contains(plain, " 1234 - - \xEF\xBB\xBFx") {
+		t.Errorf("Format() = %q", plain)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for level, want := range map[string]int{"DEBUG": 7, "INFO": 6, "WARN": 4, "ERROR": 3} {
+		if got := syslogSeverity(level); got != want {
+			t.Errorf("syslogSeverity(%s) = %d, want %d", level, got, want)
+		}
+	}
+}
+
+func TestSyslogSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听 UDP: %v", err)
+	}
+	defer pc.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "udp", Address: pc.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewSyslogSink() error = %v", err)
+	}
+
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.Local)
+	l := newTestLogger(t, t.TempDir(), &now, LogRotation{})
+	l.AddSink(sink)
+	l.WarningTag(TagFail, "设备修复失败")
+
+	buf := make([]byte, 2048)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<12>1 ") || !strings.Contains(msg, `tag="FAIL"`) || !strings.HasSuffix(msg, "设备修复失败") {
+		t.Errorf("received %q", msg)
+	}
+}
+
+// readOctetFrames 读取 RFC 6587 长度前缀分帧的消息
+func readOctetFrames(t *testing.T, r *bufio.Reader, count int) []string {
+	t.Helper()
+	var msgs []string
+	for i := 0; i < count; i++ {
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("read frame length: %v", err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+		if err != nil {
+			t.Fatalf("invalid frame length %q", lenStr)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		msgs = append(msgs, string(data))
+	}
+	return msgs
+}
+
+func TestSyslogSink_TCPBuffersWhileUnreachable(t *testing.T) {
+	// 先占用再释放一个端口，得到当前无人监听的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听 TCP: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := newSyslogSink(SyslogConfig{Network: "tcp", Address: addr, BufferSize: 3})
+	defer s.Close()
+
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.Local)
+	for i := 0; i < 5; i++ {
+		s.Send(LogEntry{Time: now, Level: "INFO", Tag: TagResume, Message: "msg " + strconv.Itoa(i)})
+	}
+
+	if err := s.Flush(); err == nil {
+		t.Fatal("Flush() should fail while collector is unreachable")
+	}
+	if got := s.Pending(); got != 3 {
+		t.Fatalf("Pending() = %d, want 3 (buffer size)", got)
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("无法重新监听 %s: %v", addr, err)
+	}
+	defer ln.Close()
+
+	// 跳过退避等待，模拟重试时间已到
+	s.flushMu.Lock()
+	s.nextDial = time.Time{}
+	s.flushMu.Unlock()
+
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := s.Pending(); got != 0 {
+		t.Errorf("Pending() = %d after flush, want 0", got)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	msgs := readOctetFrames(t, bufio.NewReader(conn), 4)
+	if !strings.Contains(msgs[0], "丢弃 2 条日志") {
+		t.Errorf("first message should report dropped logs, got %q", msgs[0])
+	}
+	for i, want := range []string{"msg 2", "msg 3", "msg 4"} {
+		if !strings.HasSuffix(msgs[i+1], want) {
+			t.Errorf("msgs[%d] = %q, want suffix %q", i+1, msgs[i+1], want)
+		}
+	}
+}
+
+func TestSyslogSink_UnixDatagram(t *testing.T) {
+	dir, err := os.MkdirTemp("", "syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.sock")
+
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skipf("不支持 unixgram: %v", err)
+	}
+	defer pc.Close()
+
+	s := newSyslogSink(SyslogConfig{Network: "unixgram", Address: path})
+	defer s.Close()
+
+	s.Send(LogEntry{Time: time.Now(), Level: "ERROR", Tag: TagService, Message: "unix"})
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, "unix") {
+		t.Errorf("received %q", msg)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -36,16 +35,33 @@ const (
 	TagFail    EventTag = "FAIL"    // 修复失败
 	TagService EventTag = "SERVICE" // 服务状态
 	TagConfig  EventTag = "CONFIG"  // 配置相关
+	TagHook    EventTag = "HOOK"    // 钩子命令
+	TagState   EventTag = "STATE"   // 睡眠/唤醒状态转换
+	TagFlap    EventTag = "FLAP"    // 设备状态抖动与隔离
 )
 
+// allEventTags 所有已知的事件标签（用于校验配置）
+var allEventTags = []EventTag{
+	TagResume, TagCheck, TagReset, TagSkip, TagSuccess, TagFail, TagService, TagConfig, TagHook, TagState, TagFlap,
+}
+
 // Logger 日志记录器
 type Logger struct {
-	level      LogLevel
-	logDir     string
-	file       *os.File
-	mu         sync.Mutex
-	stdLogger  *log.Logger
-	fileLogger *log.Logger
+	level     LogLevel
+	tagLevels map[EventTag]LogLevel // 按标签覆盖的日志级别
+	tempLevel LogLevel              // 临时日志级别（如 -debug-for）
+	tempUntil time.Time             // 临时日志级别的到期时间（零值表示未设置）
+	format    LogFormat             // 文件输出格式（控制台始终为文本）
+	logDir    string
+	file      *os.File
+	fileDate  string // 当前文件对应的日期，跨天时轮转
+	fileSize  int64  // 当前文件大小，超过上限时轮转
+	rotation  LogRotation
+	nextRetry time.Time // 轮转失败（如文件被占用）后的下次重试时间
+	now       func() time.Time
+	mu        sync.Mutex
+	stdLogger *log.Logger
+	sinks     []LogSink // 额外输出（如 syslog）
 }
 
 var (
@@ -74,7 +90,9 @@ func InitLoggerWithOptions(logDir string, level LogLevel, enableConsole bool) er
 	loggerOnce.Do(func() {
 		globalLogger = &Logger{
 			level:  level,
+			format: LogFormatText,
 			logDir: logDir,
+			now:    time.Now,
 		}
 
 		// 创建日志目录
@@ -84,17 +102,9 @@ func InitLoggerWithOptions(logDir string, level LogLevel, enableConsole bool) er
 			}
 
 			// 创建日志文件
-			logFile := filepath.Join(logDir, fmt.Sprintf("gpd-touch_%s.log",
-				time.Now().Format("2006-01-02")))
-
-			globalLogger.file, err = os.OpenFile(logFile,
-				os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-			if err != nil {
+			if err = globalLogger.openFile(globalLogger.now()); err != nil {
 				return
 			}
-
-			// 设置文件日志器（不带前缀，我们自己格式化）
-			globalLogger.fileLogger = log.New(globalLogger.file, "", 0)
 		}
 
 		// 设置标准日志器（可选）
@@ -119,12 +129,26 @@ func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	for _, sink := range l.sinks {
+		_ = sink.Close()
+	}
+	l.sinks = nil
+
 	if l.file != nil {
-		return l.file.Close()
+		err := l.file.Close()
+		l.file = nil
+		return err
 	}
 	return nil
 }
 
+// AddSink 添加额外的日志输出，记录器关闭时一并关闭
+func (l *Logger) AddSink(sink LogSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
 // SetLevel 设置日志级别
 func (l *Logger) SetLevel(level LogLevel) {
 	l.mu.Lock()
@@ -132,58 +156,211 @@ func (l *Logger) SetLevel(level LogLevel) {
 	l.level = level
 }
 
-// log 内部日志方法
-func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
-	if level < l.level {
+// SetTagLevels 设置按标签覆盖的日志级别，例如 CHECK 使用 DEBUG，其余使用默认级别
+func (l *Logger) SetTagLevels(levels map[EventTag]LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tagLevels = levels
+}
+
+// SetTemporaryLevel 临时调整日志级别，到期后自动恢复
+// 临时级别只会放宽过滤（取与正常级别中较详细的一个），until 不晚于当前时间则立即清除
+func (l *Logger) SetTemporaryLevel(level LogLevel, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !until.After(now) {
+		if !l.tempUntil.IsZero() {
+			l.tempUntil = time.Time{}
+			l.emitLocked(LogEntry{Time: now, Level: l.levelString(INFO), Tag: TagConfig,
+				Message: "临时日志级别已取消"})
+		}
 		return
 	}
 
+	l.tempLevel = level
+	l.tempUntil = until
+	l.emitLocked(LogEntry{Time: now, Level: l.levelString(INFO), Tag: TagConfig,
+		Message: fmt.Sprintf("临时日志级别: %s，至 %s 自动恢复", l.levelString(level), until.Format("2006-01-02 15:04:05"))})
+}
+
+// effectiveLevelLocked 返回标签的生效级别，并清理已到期的临时级别（调用方需持有锁）
+func (l *Logger) effectiveLevelLocked(tag EventTag, now time.Time) LogLevel {
+	level := l.level
+	if tagLevel, ok := l.tagLevels[tag]; ok && tag != "" {
+		level = tagLevel
+	}
+
+	if !l.tempUntil.IsZero() {
+		if now.Before(l.tempUntil) {
+			if l.tempLevel < level {
+				level = l.tempLevel
+			}
+		} else {
+			l.tempUntil = time.Time{}
+			l.emitLocked(LogEntry{Time: now, Level: l.levelString(INFO), Tag: TagConfig,
+				Message: "临时日志级别已到期，恢复为正常级别"})
+		}
+	}
+
+	return level
+}
+
+// SetRotation 设置日志轮转与保留策略
+func (l *Logger) SetRotation(r LogRotation) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.rotation = r
+}
 
-	msg := fmt.Sprintf(format, args...)
-	levelStr := l.levelString(level)
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+// CleanLogs 按当前保留策略清理日志目录（不会删除正在写入的文件）
+func (l *Logger) CleanLogs() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cleanLocked()
+}
 
-	// 格式化输出
-	formattedMsg := fmt.Sprintf("%s [%-5s] %s", timestamp, levelStr, msg)
+// cleanLocked 执行保留策略（调用方需持有锁）
+func (l *Logger) cleanLocked() error {
+	if l.logDir == "" {
+		return nil
+	}
+	return CleanLogs(l.logDir, l.rotation, l.activePath(), l.now())
+}
 
-	// 控制台输出
-	if l.stdLogger != nil {
-		l.stdLogger.Println(formattedMsg)
+// activePath 当前正在写入的文件路径（调用方需持有锁）
+func (l *Logger) activePath() string {
+	if l.file == nil {
+		return ""
 	}
+	return l.file.Name()
+}
 
-	// 文件输出
-	if l.fileLogger != nil {
-		l.fileLogger.Println(formattedMsg)
+// openFile 打开指定日期的日志文件（调用方需持有锁或处于初始化阶段）
+func (l *Logger) openFile(now time.Time) error {
+	path := filepath.Join(l.logDir, logFileName(now))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
 	}
+
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	l.file = f
+	l.fileDate = now.Format("2006-01-02")
+	l.fileSize = size
+	return nil
 }
 
-// logWithTag 带事件标签的日志方法
-func (l *Logger) logWithTag(level LogLevel, tag EventTag, format string, args ...interface{}) {
-	if level < l.level {
+// rotateIfNeeded 跨天或文件超过大小上限时切换到新文件（调用方需持有锁）
+func (l *Logger) rotateIfNeeded(now time.Time) {
+	if l.file == nil || now.Before(l.nextRetry) {
+		return
+	}
+
+	dateChanged := now.Format("2006-01-02") != l.fileDate
+	sizeExceeded := l.rotation.MaxSizeBytes > 0 && l.fileSize >= l.rotation.MaxSizeBytes
+	if !dateChanged && !sizeExceeded {
+		return
+	}
+
+	oldPath := l.file.Name()
+	_ = l.file.Close()
+	l.file = nil
+
+	// 跨天时旧文件保留原名；同一天超过大小则改名为带序号的分段
+	archived := oldPath
+	if !dateChanged {
+		archived = nextSegmentPath(l.logDir, l.fileDate)
+		if err := os.Rename(oldPath, archived); err != nil {
+			// 文件可能正被其他进程读取，稍后再试，先继续写原文件
+			archived = ""
+			l.nextRetry = now.Add(time.Minute)
+		}
+	}
+
+	if err := l.openFile(now); err != nil {
 		return
 	}
 
+	if archived != "" && l.rotation.Compress {
+		_ = gzipFile(archived)
+	}
+	_ = l.cleanLocked()
+}
+
+// SetFormat 设置日志文件输出格式
+func (l *Logger) SetFormat(format LogFormat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+}
+
+// log 内部日志方法
+func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
+	l.write(level, "", LogFields{}, format, args...)
+}
+
+// logWithTag 带事件标签的日志方法
+func (l *Logger) logWithTag(level LogLevel, tag EventTag, format string, args ...interface{}) {
+	l.write(level, tag, LogFields{}, format, args...)
+}
+
+// write 格式化并输出一条日志
+func (l *Logger) write(level LogLevel, tag EventTag, fields LogFields, format string, args ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	msg := fmt.Sprintf(format, args...)
-	levelStr := l.levelString(level)
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	now := l.now()
+	if level < l.effectiveLevelLocked(tag, now) {
+		return
+	}
+
+	l.emitLocked(LogEntry{
+		Time:    now,
+		Level:   l.levelString(level),
+		Tag:     tag,
+		Message: fmt.Sprintf(format, args...),
+		Fields:  fields,
+	})
+}
 
-	// 格式化输出：时间戳 [级别] [标签] 消息
-	formattedMsg := fmt.Sprintf("%s [%-5s] [%-7s] %s", timestamp, levelStr, tag, msg)
+// emitLocked 输出一条日志到控制台和文件（调用方需持有锁）
+func (l *Logger) emitLocked(entry LogEntry) {
+	text := entry.FormatText()
 
 	// 控制台输出
 	if l.stdLogger != nil {
-		l.stdLogger.Println(formattedMsg)
+		l.stdLogger.Println(text)
 	}
 
 	// 文件输出
-	if l.fileLogger != nil {
-		l.fileLogger.Println(formattedMsg)
+	if l.file != nil {
+		l.rotateIfNeeded(entry.Time)
+		line := text
+		if l.format == LogFormatJSON {
+			line = entry.FormatJSON()
+		}
+		l.writeFileLocked(line)
 	}
+
+	// 额外输出
+	for _, sink := range l.sinks {
+		sink.Send(entry)
+	}
+}
+
+// writeFileLocked 写入一行到当前日志文件（调用方需持有锁）
+func (l *Logger) writeFileLocked(line string) {
+	if l.file == nil {
+		return
+	}
+	n, _ := io.WriteString(l.file, line+"\n")
+	l.fileSize += int64(n)
 }
 
 // levelString 返回日志级别字符串
@@ -224,6 +401,11 @@ func (l *Logger) Error(format string, args ...interface{}) {
 
 // 带标签的日志方法
 
+// DebugTag 带标签的调试日志
+func (l *Logger) DebugTag(tag EventTag, format string, args ...interface{}) {
+	l.logWithTag(DEBUG, tag, format, args...)
+}
+
 // InfoTag 带标签的信息日志
 func (l *Logger) InfoTag(tag EventTag, format string, args ...interface{}) {
 	l.logWithTag(INFO, tag, format, args...)
@@ -239,6 +421,37 @@ func (l *Logger) ErrorTag(tag EventTag, format string, args ...interface{}) {
 	l.logWithTag(ERROR, tag, format, args...)
 }
 
+// WithFields 返回附带结构化字段的日志记录器
+func (l *Logger) WithFields(fields LogFields) *FieldLogger {
+	return &FieldLogger{logger: l, fields: fields}
+}
+
+// FieldLogger 附带结构化字段的日志记录器
+type FieldLogger struct {
+	logger *Logger
+	fields LogFields
+}
+
+// DebugTag 带标签和字段的调试日志
+func (f *FieldLogger) DebugTag(tag EventTag, format string, args ...interface{}) {
+	f.logger.write(DEBUG, tag, f.fields, format, args...)
+}
+
+// InfoTag 带标签和字段的信息日志
+func (f *FieldLogger) InfoTag(tag EventTag, format string, args ...interface{}) {
+	f.logger.write(INFO, tag, f.fields, format, args...)
+}
+
+// WarningTag 带标签和字段的警告日志
+func (f *FieldLogger) WarningTag(tag EventTag, format string, args ...interface{}) {
+	f.logger.write(WARNING, tag, f.fields, format, args...)
+}
+
+// ErrorTag 带标签和字段的错误日志
+func (f *FieldLogger) ErrorTag(tag EventTag, format string, args ...interface{}) {
+	f.logger.write(ERROR, tag, f.fields, format, args...)
+}
+
 // LogToFile 写入特定内容到日志文件
 func (l *Logger) LogToFile(content string) error {
 	l.mu.Lock()
@@ -248,7 +461,12 @@ func (l *Logger) LogToFile(content string) error {
 		return fmt.Errorf("日志文件未初始化")
 	}
 
-	_, err := io.WriteString(l.file, content+"\n")
+	l.rotateIfNeeded(l.now())
+	if l.file == nil {
+		return fmt.Errorf("日志文件轮转失败")
+	}
+	n, err := io.WriteString(l.file, content+"\n")
+	l.fileSize += int64(n)
 	return err
 }
 
@@ -266,12 +484,20 @@ func GetCurrentLogFile() string {
 	return filepath.Join(GetLogDir(), fmt.Sprintf("gpd-touch_%s.log", time.Now().Format("2006-01-02")))
 }
 
-// ReadLogLines 读取日志文件的最后 N 行
+// ReadLogLines 读取日志文件的最后 N 行（包括已压缩的归档）
 func ReadLogLines(n int) ([]string, error) {
-	logDir := GetLogDir()
+	return readLogLinesFrom(GetLogDir(), n, nil)
+}
 
-	// 获取所有日志文件
-	files, err := filepath.Glob(filepath.Join(logDir, "gpd-touch_*.log"))
+// ReadFilteredLogLines 读取满足过滤条件的最后 N 行
+func ReadFilteredLogLines(n int, filter *LogFilter) ([]string, error) {
+	return readLogLinesFrom(GetLogDir(), n, filter)
+}
+
+// readLogLinesFrom 从指定目录读取最后 N 行，可选过滤
+// 从最新的文件末尾向前读取，凑够 N 行或早于起始时间后停止，不会读取整个日志目录
+func readLogLinesFrom(logDir string, n int, filter *LogFilter) ([]string, error) {
+	files, err := listLogFiles(logDir)
 	if err != nil {
 		return nil, fmt.Errorf("查找日志文件失败: %w", err)
 	}
@@ -280,32 +506,42 @@ func ReadLogLines(n int) ([]string, error) {
 		return nil, fmt.Errorf("未找到日志文件")
 	}
 
-	// 按文件名排序（日期格式保证了正确的顺序）
-	sort.Strings(files)
+	sinceDate := ""
+	if filter != nil && !filter.Since.IsZero() {
+		sinceDate = filter.Since.Format("2006-01-02")
+	}
 
-	// 从最新的文件开始读取
+	// 倒序收集，最后再反转
 	var lines []string
-	for i := len(files) - 1; i >= 0 && len(lines) < n; i-- {
-		fileLines, err := readFileLines(files[i])
-		if err != nil {
-			continue
+	done := false
+	for i := len(files) - 1; i >= 0 && !done && len(lines) < n; i-- {
+		if files[i].Date < sinceDate {
+			break
 		}
 
-		// 将新行插入到开头
-		lines = append(fileLines, lines...)
+		_ = scanLinesBackward(files[i].Path, func(line string) bool {
+			entry, _ := ParseLogLine(line)
+			if filter != nil && !filter.Since.IsZero() && !entry.Time.IsZero() && entry.Time.Before(filter.Since) {
+				done = true
+				return false
+			}
+			if filter.Match(&entry) {
+				lines = append(lines, line)
+			}
+			return len(lines) < n
+		})
 	}
 
-	// 只返回最后 N 行
-	if len(lines) > n {
-		lines = lines[len(lines)-n:]
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
 	}
 
 	return lines, nil
 }
 
-// readFileLines 读取文件所有行
+// readFileLines 读取文件所有行（.gz 文件透明解压）
 func readFileLines(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
+	file, err := openLogReader(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -323,62 +559,64 @@ func readFileLines(filePath string) ([]string, error) {
 // FormatLogForDisplay 格式化日志行用于显示
 func FormatLogForDisplay(lines []string) string {
 	if len(lines) == 0 {
-		return "No log records found\n"
+		return T("log.none") + "\n"
 	}
 
 	var sb strings.Builder
-	sb.WriteString("========== SERVICE LOG ==========\n\n")
+	sb.WriteString(T("log.header") + "\n\n")
 
 	for _, line := range lines {
-		// Add prefix based on log level
-		prefix := "  "
-		if strings.Contains(line, "[ERROR]") || strings.Contains(line, "[FAIL]") {
-			prefix = "! "
-		} else if strings.Contains(line, "[WARN]") {
-			prefix = "W "
-		} else if strings.Contains(line, "[SUCCESS]") {
-			prefix = "+ "
-		} else if strings.Contains(line, "[RESUME]") {
-			prefix = "R "
-		} else if strings.Contains(line, "[RESET]") {
-			prefix = "* "
-		} else if strings.Contains(line, "[SKIP]") {
-			prefix = "- "
-		} else if strings.Contains(line, "[CHECK]") {
-			prefix = "? "
+		entry, ok := ParseLogLine(line)
+		if !ok {
+			sb.WriteString(fmt.Sprintf("  %s\n", line))
+			continue
 		}
 
-		sb.WriteString(fmt.Sprintf("%s%s\n", prefix, line))
+		// JSON 行也按文本格式显示，两种格式混合时保持一致
+		sb.WriteString(fmt.Sprintf("%s%s\n", logDisplayPrefix(&entry), entry.FormatText()))
 	}
 
-	sb.WriteString("\n=================================\n")
+	sb.WriteString("\n" + T("log.footer") + "\n")
 	return sb.String()
 }
 
-// CleanOldLogs 清理过期日志文件
-func CleanOldLogs(maxDays int) error {
-	if maxDays <= 0 {
-		return nil
+// logDisplayPrefix 根据级别和标签返回显示前缀
+func logDisplayPrefix(entry *LogEntry) string {
+	switch {
+	case entry.Level == "ERROR" || entry.Tag == TagFail:
+		return "! "
+	case entry.Level == "WARN":
+		return "W "
 	}
 
-	logDir := GetLogDir()
-	files, err := filepath.Glob(filepath.Join(logDir, "gpd-touch_*.log"))
-	if err != nil {
-		return err
+	switch entry.Tag {
+	case TagSuccess:
+		return "+ "
+	case TagResume:
+		return "R "
+	case TagReset:
+		return "* "
+	case TagSkip:
+		return "- "
+	case TagCheck:
+		return "? "
+	default:
+		return "  "
 	}
+}
 
-	cutoff := time.Now().AddDate(0, 0, -maxDays)
-
-	for _, file := range files {
-		info, err := os.Stat(file)
-		if err != nil {
-			continue
-		}
+// CleanOldLogs 清理过期日志文件（不会删除全局日志记录器正在写入的文件）
+func CleanOldLogs(maxDays int) error {
+	if maxDays <= 0 {
+		return nil
+	}
 
-		if info.ModTime().Before(cutoff) {
-			_ = os.Remove(file)
-		}
+	activePath := ""
+	if globalLogger != nil {
+		globalLogger.mu.Lock()
+		activePath = globalLogger.activePath()
+		globalLogger.mu.Unlock()
 	}
 
-	return nil
+	return CleanLogs(GetLogDir(), LogRotation{MaxDays: maxDays}, activePath, time.Now())
 }
@@ -179,6 +179,7 @@ func TestFormatLogForDisplay(t *testing.T) {
 		"2025-01-01 12:00:03.000 [INFO ] [SUCCESS] Success log",
 	}
 
+	withLocale(t, LocaleEnUS)
 	formatted := FormatLogForDisplay(lines)
 
 	if !strings.Contains(formatted, "SERVICE LOG") {
@@ -195,6 +196,7 @@ func TestFormatLogForDisplay(t *testing.T) {
 }
 
 func TestFormatLogForDisplay_Empty(t *testing.T) {
+	withLocale(t, LocaleEnUS)
 	formatted := FormatLogForDisplay([]string{})
 
 	if !strings.Contains(formatted, "No log records") {
@@ -229,3 +231,46 @@ func TestGetCurrentLogFile(t *testing.T) {
 		t.Error("GetCurrentLogFile() should return path ending with '.log'")
 	}
 }
+
+func TestLogger_JSONFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// 重置全局 logger
+	resetLoggerForTesting()
+
+	err := InitLoggerWithOptions(tmpDir, INFO, false)
+	if err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer func() {
+		if globalLogger != nil {
+			globalLogger.Close()
+		}
+	}()
+
+	logger := GetLogger()
+	logger.SetFormat(LogFormatJSON)
+	logger.WithFields(LogFields{Device: "I2C HID Device", Status: "OK", Trigger: "power"}).
+		InfoTag(TagSuccess, "触屏设备修复成功")
+
+	files, _ := filepath.Glob(filepath.Join(tmpDir, "gpd-touch_*.log"))
+	if len(files) == 0 {
+		t.Fatal("Log file should exist")
+	}
+
+	lines, err := readFileLines(files[0])
+	if err != nil || len(lines) != 1 {
+		t.Fatalf("readFileLines() = %v, %v; want 1 line", lines, err)
+	}
+	if !strings.HasPrefix(lines[0], "{") {
+		t.Fatalf("JSON log line expected, got %q", lines[0])
+	}
+
+	entry, ok := ParseLogLine(lines[0])
+	if !ok {
+		t.Fatalf("ParseLogLine(%q) failed", lines[0])
+	}
+	if entry.Tag != TagSuccess || entry.Fields.Device != "I2C HID Device" || entry.Fields.Trigger != "power" {
+		t.Errorf("entry = %+v", entry)
+	}
+}
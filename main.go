@@ -3,13 +3,26 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"time"
 )
 
+// configuredLanguage 读取配置文件中的 language，文件不存在或无法解析时返回空字符串
+func configuredLanguage(path string) string {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return ""
+	}
+	return cfg.Language
+}
+
 func main() {
 	// 检查是否以服务模式运行
 	if isService() {
@@ -19,34 +32,47 @@ func main() {
 		return
 	}
 
+	// 界面语言：环境变量 → 默认配置文件 → 系统语言（参数说明也需要本地化，所以在定义参数前确定）
+	SetLocale(DetectLocale(configuredLanguage(GetConfigPath())))
+
 	// 定义命令行参数
-	instanceID := flag.String("instance", "", "I2C HID 设备的 InstanceId")
-	configPath := flag.String("config", "", "配置文件路径（默认为可执行文件同目录的 config.json）")
-	waitSeconds := flag.Int("wait", 2, "禁用后等待的秒数")
-	checkOnly := flag.Bool("check", false, "仅检查设备状态，不执行重置")
-	saveConfig := flag.Bool("save-config", false, "保存当前参数到配置文件")
+	instanceID := flag.String("instance", "", T("flag.instance"))
+	configPath := flag.String("config", "", T("flag.config"))
+	waitSeconds := flag.Int("wait", 2, T("flag.wait"))
+	checkOnly := flag.Bool("check", false, T("flag.check"))
+	saveConfig := flag.Bool("save-config", false, T("flag.save_config"))
 
 	// 新增功能
-	setup := flag.Bool("setup", false, "运行安装向导（自动检测设备并配置）")
-	scanDevices := flag.Bool("scan", false, "扫描并列出所有 I2C HID 设备")
-	version := flag.Bool("version", false, "显示版本信息")
+	setup := flag.Bool("setup", false, T("flag.setup"))
+	scanDevices := flag.Bool("scan", false, T("flag.scan"))
+	version := flag.Bool("version", false, T("flag.version"))
 
 	// 服务管理命令
-	install := flag.Bool("install", false, "安装为 Windows 服务")
-	uninstall := flag.Bool("uninstall", false, "卸载 Windows 服务")
-	start := flag.Bool("start", false, "启动 Windows 服务")
-	stop := flag.Bool("stop", false, "停止 Windows 服务")
-	_ = flag.Bool("service", false, "以服务模式运行（由 Windows 服务管理器调用，内部使用）")
+	install := flag.Bool("install", false, T("flag.install"))
+	uninstall := flag.Bool("uninstall", false, T("flag.uninstall"))
+	start := flag.Bool("start", false, T("flag.start"))
+	stop := flag.Bool("stop", false, T("flag.stop"))
+	_ = flag.Bool("service", false, T("flag.service"))
 
 	// 新增状态和日志命令
-	showStatus := flag.Bool("status", false, "显示服务状态和统计信息")
-	showLog := flag.Bool("show-log", false, "显示服务日志")
-	showStats := flag.Bool("stats", false, "显示统计信息")
-	logLines := flag.Int("lines", 20, "显示日志行数（与 -show-log 配合使用）")
+	showStatus := flag.Bool("status", false, T("flag.status"))
+	rearm := flag.Bool("rearm", false, T("flag.rearm"))
+	stateTable := flag.Bool("state-table", false, T("flag.state_table"))
+	showLog := flag.Bool("show-log", false, T("flag.show_log"))
+	showStats := flag.Bool("stats", false, T("flag.stats"))
+	logLines := flag.Int("lines", 20, T("flag.lines"))
+	debugFor := flag.String("debug-for", "", T("flag.debug_for"))
+	logField := flag.String("field", "", T("flag.field"))
+	logFollow := flag.Bool("follow", false, T("flag.follow"))
+	logLevel := flag.String("level", "", T("flag.level"))
+	logTag := flag.String("tag", "", T("flag.tag"))
+	logSince := flag.String("since", "", T("flag.since"))
+	logGrep := flag.String("grep", "", T("flag.grep"))
+	logEpisode := flag.String("episode", "", T("flag.episode"))
 
 	// 通知控制命令
-	enableNotify := flag.Bool("enable-notification", false, "启用 Windows 通知")
-	disableNotify := flag.Bool("disable-notification", false, "禁用 Windows 通知")
+	enableNotify := flag.Bool("enable-notification", false, T("flag.enable_notification"))
+	disableNotify := flag.Bool("disable-notification", false, T("flag.disable_notification"))
 
 	flag.Parse()
 
@@ -74,15 +100,50 @@ func main() {
 		return
 	}
 
+	// 恢复已停止的自动修复
+	if *rearm {
+		runRearm()
+		return
+	}
+
+	// 显示状态转换表
+	if *stateTable {
+		fmt.Print(FormatWakeStateTable())
+		return
+	}
+
 	// 显示日志
 	if *showLog {
-		runShowLog(*logLines)
+		// 按事件 ID 查看时默认显示完整时间线，而不是最后 20 行
+		if *logEpisode != "" && !isFlagSet("lines") {
+			*logLines = 1000
+		}
+		runShowLog(showLogOptions{
+			Lines:   *logLines,
+			Follow:  *logFollow,
+			Fields:  *logField,
+			Level:   *logLevel,
+			Tag:     *logTag,
+			Since:   *logSince,
+			Grep:    *logGrep,
+			Episode: *logEpisode,
+		})
 		return
 	}
 
-	// 显示统计
+	// 临时调整服务日志级别
+	if *debugFor != "" {
+		runDebugFor(*debugFor)
+		return
+	}
+
+	// 显示统计（支持 -stats export/merge 及 stats export/merge 两种写法）
 	if *showStats {
-		runShowStats()
+		runStatsCommand(flag.Args())
+		return
+	}
+	if flag.Arg(0) == "stats" {
+		runStatsCommand(flag.Args()[1:])
 		return
 	}
 
@@ -105,31 +166,31 @@ func main() {
 				return
 			}
 			// 用户取消提升，退出
-			log.Fatalf("此操作需要管理员权限")
+			log.Fatal(T("cli.need_admin"))
 		}
 	}
 
 	if *install {
 		if err := installService(); err != nil {
-			log.Fatalf("安装服务失败: %v", err)
+			log.Fatalf(T("cli.install_failed"), err)
 		}
 		return
 	}
 	if *uninstall {
 		if err := uninstallService(); err != nil {
-			log.Fatalf("卸载服务失败: %v", err)
+			log.Fatalf(T("cli.uninstall_failed"), err)
 		}
 		return
 	}
 	if *start {
 		if err := startService(); err != nil {
-			log.Fatalf("启动服务失败: %v", err)
+			log.Fatalf(T("cli.start_failed"), err)
 		}
 		return
 	}
 	if *stop {
 		if err := stopService(); err != nil {
-			log.Fatalf("停止服务失败: %v", err)
+			log.Fatalf(T("cli.stop_failed"), err)
 		}
 		return
 	}
@@ -146,10 +207,13 @@ func main() {
 		var loadErr error
 		cfg, loadErr = LoadConfig(cfgPath)
 		if loadErr != nil {
-			log.Printf("警告: 加载配置文件失败: %v", loadErr)
+			log.Printf(T("cli.config_load_failed"), loadErr)
 			cfg = DefaultConfig()
 		} else {
-			log.Printf("已从配置文件加载: %s", cfgPath)
+			if cfg.Language != "" {
+				SetLocale(DetectLocale(cfg.Language))
+			}
+			log.Printf(T("cli.config_loaded"), cfgPath)
 		}
 	} else {
 		cfg = DefaultConfig()
@@ -166,33 +230,33 @@ func main() {
 	// 保存配置模式
 	if *saveConfig {
 		if err := cfg.Validate(); err != nil {
-			log.Fatalf("配置验证失败: %v", err)
+			log.Fatalf(T("cli.config_invalid"), err)
 		}
 		if err := cfg.SaveConfig(cfgPath); err != nil {
-			log.Fatalf("保存配置失败: %v", err)
+			log.Fatalf(T("cli.config_save_failed"), err)
 		}
-		log.Printf("配置已保存到: %s", cfgPath)
+		log.Printf(T("cli.config_saved"), cfgPath)
 		return
 	}
 
 	// 验证配置 - 如果设备 ID 为空，尝试自动检测
 	if cfg.DeviceInstanceID == "" {
-		log.Println("未配置设备，尝试自动检测...")
+		log.Println(T("cli.autodetect"))
 
 		detector := NewDetector()
 		bestMatch, _, err := detector.DetectBestMatch()
 		if err != nil {
-			log.Printf("自动检测失败: %v", err)
-			log.Fatalf("请使用 -setup 运行安装向导，或使用 -instance 参数手动指定设备 InstanceId")
+			log.Printf(T("cli.autodetect_failed"), err)
+			log.Fatal(T("cli.autodetect_hint"))
 		}
 
 		cfg.DeviceInstanceID = bestMatch.InstanceID
 		cfg.DeviceName = bestMatch.FriendlyName
-		log.Printf("自动检测到设备: %s", bestMatch.FriendlyName)
-		log.Printf("设备 ID: %s", bestMatch.InstanceID)
+		log.Printf(T("cli.autodetected"), bestMatch.FriendlyName)
+		log.Printf(T("cli.device_id"), bestMatch.InstanceID)
 
 		// 提示用户保存配置
-		log.Println("提示: 运行 -save-config 可保存此配置")
+		log.Println(T("cli.save_config_hint"))
 	}
 
 	// 创建设备管理器
@@ -200,19 +264,19 @@ func main() {
 
 	// 仅检查模式
 	if *checkOnly {
-		status, err := dm.GetStatus()
+		status, err := dm.GetStatus(context.Background())
 		if err != nil {
-			log.Fatalf("检查设备状态失败: %v", err)
+			log.Fatalf(T("cli.check_failed"), err)
 		}
-		fmt.Printf("设备状态: %s\n", status)
+		fmt.Println(T("cli.device_status", status))
 		return
 	}
 
 	// 检查管理员权限，如果不是管理员则尝试自动提升
 	if !IsAdmin() {
 		cli := NewCLI()
-		cli.PrintWarning("未以管理员身份运行！")
-		cli.PrintInfo("设备操作需要管理员权限。")
+		cli.PrintWarning("%s", T("cli.not_admin"))
+		cli.PrintInfo("%s", T("cli.device_needs_admin"))
 		fmt.Println()
 		if EnsureAdmin(cli) {
 			// 已在新窗口中以管理员身份启动，当前进程退出
@@ -223,39 +287,46 @@ func main() {
 	}
 
 	// 执行设备重置
-	log.Println("GPD 触屏恢复工具")
+	log.Println(T("cli.reset_banner"))
 	log.Println("==================")
-	waitDuration := time.Duration(cfg.WaitSeconds) * time.Second
-	if err := dm.Reset(waitDuration); err != nil {
-		log.Fatalf("设备重置失败: %v", err)
+	opts := cfg.RepairOptions()
+	opts.CheckBeforeReset = false // 手动修复总是重置
+	repairer := NewRepairOrchestrator(dm, opts)
+	repairer.SetHooks(NewHookRunner(cfg.Hooks, ConsoleLogger{}))
+	outcome := repairer.Run(context.Background(), RepairTrigger{Source: TriggerManual})
+	switch outcome.Result {
+	case RepairVetoed:
+		log.Fatalf(T("cli.reset_vetoed"), outcome.Err)
+	case RepairFailed:
+		log.Fatalf(T("cli.reset_failed"), outcome.Err)
 	}
 
 	log.Println("==================")
-	log.Println("触屏设备已成功重置！")
+	log.Println(T("cli.reset_done"))
 }
 
 // runScanDevices 扫描并列出设备
 func runScanDevices() {
 	cli := NewCLI()
-	cli.PrintTitle("扫描 I2C HID 设备")
+	cli.PrintTitle(T("cli.scan.title"))
 
 	detector := NewDetector()
-	cli.PrintProgress("正在扫描设备...")
+	cli.PrintProgress("%s", T("cli.scan.progress"))
 
 	devices, err := detector.DetectI2CHIDDevices()
 	if err != nil {
-		cli.PrintError("扫描失败: %v", err)
+		cli.PrintError(T("cli.scan.failed"), err)
 		return
 	}
 
 	fmt.Println() // 换行
 
 	if len(devices) == 0 {
-		cli.PrintWarning("未找到任何 I2C HID 设备")
+		cli.PrintWarning("%s", T("cli.scan.none"))
 		return
 	}
 
-	cli.PrintInfo("找到 %d 个设备", len(devices))
+	cli.PrintInfo(T("cli.scan.found"), len(devices))
 	cli.PrintDeviceList(devices)
 }
 
@@ -263,35 +334,35 @@ func runScanDevices() {
 func runSetupWizard() {
 	cli := NewCLI()
 
-	cli.PrintTitle("GPD 触屏修复工具 - 安装向导")
-	fmt.Println("欢迎使用！本向导将帮助您：")
-	fmt.Println("  1. 自动检测触控设备")
-	fmt.Println("  2. 测试修复功能")
-	fmt.Println("  3. 配置通知选项")
-	fmt.Println("  4. 安装自动化服务")
+	cli.PrintTitle(T("cli.setup.title"))
+	fmt.Println(T("cli.setup.welcome"))
+	fmt.Println(T("cli.setup.step_detect"))
+	fmt.Println(T("cli.setup.step_test"))
+	fmt.Println(T("cli.setup.step_notify"))
+	fmt.Println(T("cli.setup.step_service"))
 	fmt.Println()
 
 	// 检查管理员权限，如果不是管理员则提示自动提升
 	if !IsAdmin() {
-		cli.PrintWarning("部分功能（测试修复、安装服务）需要管理员权限")
+		cli.PrintWarning("%s", T("cli.setup.admin_partial"))
 		fmt.Println()
 		if EnsureAdmin(cli) {
 			// 已在新窗口中以管理员身份启动，当前进程退出
 			return
 		}
 		// 用户选择不提升，继续以普通权限运行（部分功能将受限）
-		cli.PrintWarning("将以有限权限模式继续，部分功能可能无法使用")
+		cli.PrintWarning("%s", T("cli.setup.limited"))
 		fmt.Println()
 	}
 
-	if !cli.AskYesNo("是否继续", true) {
-		cli.PrintInfo("已取消")
+	if !cli.AskYesNo(T("cli.setup.continue"), true) {
+		cli.PrintInfo("%s", T("cli.setup.cancelled"))
 		return
 	}
 
 	// 步骤1: 检测设备
-	cli.PrintTitle("步骤 1/4: 检测设备")
-	cli.PrintProgress("正在扫描 I2C HID 设备...")
+	cli.PrintTitle(T("cli.setup.detect_title"))
+	cli.PrintProgress("%s", T("cli.setup.detecting"))
 
 	detector := NewDetector()
 	bestMatch, candidates, err := detector.DetectBestMatch()
@@ -299,45 +370,45 @@ func runSetupWizard() {
 	fmt.Println() // 换行
 
 	if err != nil {
-		cli.PrintError("检测失败: %v", err)
-		cli.PrintInfo("您可以稍后使用 -instance 参数手动指定设备")
+		cli.PrintError(T("cli.setup.detect_failed"), err)
+		cli.PrintInfo("%s", T("cli.setup.manual_hint"))
 		return
 	}
 
-	cli.PrintSuccess("找到 %d 个候选设备", len(candidates))
+	cli.PrintSuccess(T("cli.setup.candidates"), len(candidates))
 
 	// 让用户选择设备
 	selectedDevice, err := cli.SelectDevice(candidates, bestMatch)
 	if err != nil {
-		cli.PrintError("选择设备失败: %v", err)
+		cli.PrintError(T("cli.setup.select_failed"), err)
 		return
 	}
 
-	cli.PrintSuccess("已选择: %s", selectedDevice.FriendlyName)
+	cli.PrintSuccess(T("cli.setup.selected"), selectedDevice.FriendlyName)
 	fmt.Println()
 
 	// 步骤2: 测试修复
-	cli.PrintTitle("步骤 2/4: 测试修复")
+	cli.PrintTitle(T("cli.setup.test_title"))
 
-	if !cli.AskYesNo("是否测试修复此设备", true) {
-		cli.PrintWarning("跳过测试，直接保存配置")
+	if !cli.AskYesNo(T("cli.setup.ask_test"), true) {
+		cli.PrintWarning("%s", T("cli.setup.skip_test"))
 	} else {
-		cli.PrintInfo("正在测试设备修复...")
+		cli.PrintInfo("%s", T("cli.setup.testing"))
 
 		dm := NewDeviceManager(selectedDevice.InstanceID)
 		waitDuration := 2 * time.Second
 
-		if err := dm.Reset(waitDuration); err != nil {
-			cli.PrintError("修复失败: %v", err)
-			cli.PrintWarning("您仍然可以保存配置，但请检查设备 ID 是否正确")
+		if err := dm.Reset(context.Background(), waitDuration); err != nil {
+			cli.PrintError(T("cli.setup.test_failed"), err)
+			cli.PrintWarning("%s", T("cli.setup.test_failed_hint"))
 		} else {
-			cli.PrintSuccess("修复成功！触控设备应该已经恢复正常")
+			cli.PrintSuccess("%s", T("cli.setup.test_ok"))
 		}
 		fmt.Println()
 	}
 
 	// 保存配置
-	cli.PrintInfo("正在保存配置...")
+	cli.PrintInfo("%s", T("cli.setup.saving"))
 
 	cfg := DefaultConfig()
 	cfg.SetDevice(selectedDevice)
@@ -351,135 +422,262 @@ func runSetupWizard() {
 
 	cfgPath := GetConfigPath()
 	if err := cfg.SaveConfig(cfgPath); err != nil {
-		cli.PrintError("保存配置失败: %v", err)
+		cli.PrintError(T("cli.config_save_failed"), err)
 		return
 	}
 
-	cli.PrintSuccess("配置已保存到: %s", cfgPath)
+	cli.PrintSuccess(T("cli.config_saved"), cfgPath)
 	fmt.Println()
 
 	// 步骤3: 通知设置
-	cli.PrintTitle("步骤 3/4: 通知设置")
-	fmt.Println("启用通知后，每次睡眠唤醒时会收到修复结果的 Windows 通知。")
+	cli.PrintTitle(T("cli.setup.notify_title"))
+	fmt.Println(T("cli.setup.notify_desc"))
 	fmt.Println()
 
-	enableNotification := cli.AskYesNo("是否启用 Windows 通知", true)
+	enableNotification := cli.AskYesNo(T("cli.setup.ask_notify"), true)
 	cfg.EnableNotification = enableNotification
 
 	if enableNotification {
-		cli.PrintSuccess("已启用通知")
+		cli.PrintSuccess("%s", T("cli.setup.notify_on"))
 	} else {
-		cli.PrintInfo("已禁用通知（可通过 -enable-notification 命令重新启用）")
+		cli.PrintInfo("%s", T("cli.setup.notify_off"))
 	}
 
 	// 更新配置
 	if err := cfg.SaveConfig(cfgPath); err != nil {
-		cli.PrintError("保存配置失败: %v", err)
+		cli.PrintError(T("cli.config_save_failed"), err)
 		return
 	}
 	fmt.Println()
 
 	// 步骤4: 安装服务
-	cli.PrintTitle("步骤 4/4: 安装自动化服务")
-	fmt.Println("将程序安装为 Windows 服务后，每次睡眠唤醒都会自动修复触控。")
+	cli.PrintTitle(T("cli.setup.service_title"))
+	fmt.Println(T("cli.setup.service_desc"))
 	fmt.Println()
 
-	if !cli.AskYesNo("是否安装为 Windows 服务", true) {
-		cli.PrintInfo("已跳过服务安装")
-		cli.PrintInfo("您可以稍后运行以下命令安装服务:")
+	if !cli.AskYesNo(T("cli.setup.ask_service"), true) {
+		cli.PrintInfo("%s", T("cli.setup.service_skipped"))
+		cli.PrintInfo("%s", T("cli.setup.service_later"))
 		fmt.Printf("  %s -install\n", os.Args[0])
 		fmt.Printf("  %s -start\n", os.Args[0])
 		fmt.Println()
-		cli.PrintSuccess("配置完成！")
+		cli.PrintSuccess("%s", T("cli.setup.configured"))
 		return
 	}
 
 	// 安装服务
-	cli.PrintProgress("正在安装服务...")
+	cli.PrintProgress("%s", T("cli.setup.installing"))
 	if err := installService(); err != nil {
 		fmt.Println() // 换行
-		cli.PrintError("安装服务失败: %v", err)
-		cli.PrintWarning("请以管理员身份运行本程序")
+		cli.PrintError(T("cli.install_failed"), err)
+		cli.PrintWarning("%s", T("cli.setup.run_as_admin"))
 		return
 	}
 	fmt.Println() // 换行
-	cli.PrintSuccess("服务安装成功")
+	cli.PrintSuccess("%s", T("cli.setup.installed"))
 
 	// 启动服务
-	if cli.AskYesNo("是否立即启动服务", true) {
-		cli.PrintProgress("正在启动服务...")
+	if cli.AskYesNo(T("cli.setup.ask_start"), true) {
+		cli.PrintProgress("%s", T("cli.setup.starting"))
 		if err := startService(); err != nil {
 			fmt.Println() // 换行
-			cli.PrintError("启动服务失败: %v", err)
+			cli.PrintError(T("cli.start_failed"), err)
 			return
 		}
 		fmt.Println() // 换行
-		cli.PrintSuccess("服务已启动")
+		cli.PrintSuccess("%s", T("cli.setup.started"))
 	}
 
 	fmt.Println()
-	cli.PrintSuccess("安装完成！")
+	cli.PrintSuccess("%s", T("cli.setup.done"))
 	fmt.Println()
-	fmt.Println("现在您可以:")
-	fmt.Println("  • 合上屏幕测试睡眠唤醒后触控是否自动恢复")
-	fmt.Println("  • 运行 'gpd-touch-fix -status' 查看服务状态和统计")
-	fmt.Println("  • 运行 'gpd-touch-fix -show-log' 查看服务日志")
+	fmt.Println(T("cli.setup.next"))
+	fmt.Println(T("cli.setup.next_test"))
+	fmt.Println(T("cli.setup.next_status"))
+	fmt.Println(T("cli.setup.next_log"))
 	fmt.Println()
 }
 
 // runShowStatus 显示服务状态和统计信息
 func runShowStatus() {
 	cli := NewCLI()
-	cli.PrintTitle("GPD 触屏修复工具 - 状态")
+	cli.PrintTitle(T("cli.status.title"))
 
 	// 检查服务状态
 	serviceStatus := getServiceStatus()
-	fmt.Printf("服务状态: %s\n", serviceStatus)
+	fmt.Println(T("cli.status.service", serviceStatus))
 
 	// 加载配置
 	cfgPath := GetConfigPath()
 	cfg, err := LoadConfig(cfgPath)
 	if err != nil {
-		cli.PrintWarning("未找到配置文件")
+		cli.PrintWarning("%s", T("cli.status.no_config"))
 	} else {
-		fmt.Printf("配置文件: %s\n", cfgPath)
-		fmt.Printf("监控设备: %s\n", cfg.DeviceName)
+		fmt.Println(T("cli.status.config", cfgPath))
+		fmt.Println(T("cli.status.device", cfg.DeviceName))
 
 		// 检查设备当前状态
 		if cfg.DeviceInstanceID != "" {
 			dm := NewDeviceManager(cfg.DeviceInstanceID)
-			status, err := dm.GetStatus()
+			status, err := dm.GetStatus(context.Background())
 			if err != nil {
-				fmt.Printf("设备状态: ❌ 无法获取 (%v)\n", err)
+				fmt.Println(T("cli.status.device_error", err))
 			} else if status == "OK" {
-				fmt.Printf("设备状态: ✅ %s\n", status)
+				fmt.Println(T("cli.status.device_ok", status))
 			} else {
-				fmt.Printf("设备状态: ⚠️ %s\n", status)
+				fmt.Println(T("cli.status.device_bad", status))
 			}
 		}
 
 		// 通知状态
 		if cfg.EnableNotification {
-			fmt.Println("通知状态: ✅ 已启用")
+			fmt.Println(T("cli.status.notify_on"))
+		} else {
+			fmt.Println(T("cli.status.notify_off"))
+		}
+	}
+
+	// 停止自动修复状态
+	stats := NewStatsManager(GetStatsDir())
+	if gaveUp := stats.GiveUpState(); gaveUp != nil {
+		fmt.Println()
+		fmt.Println(T("cli.status.gave_up", gaveUp.Since.Format("2006-01-02 15:04:05"), gaveUp.Attempts))
+		if !gaveUp.Until.IsZero() {
+			fmt.Println(T("cli.status.rearm_at", gaveUp.Until.Format("2006-01-02 15:04:05")))
 		} else {
-			fmt.Println("通知状态: ❌ 已禁用")
+			fmt.Println(T("cli.status.rearm_hint"))
+		}
+	}
+
+	// 重试计划（服务保存的轮询器状态）
+	if state, err := NewPollerStateStore(filepath.Join(GetStatsDir(), PollerStateFileName)).Load(); err == nil && state != nil {
+		var policy RetryPolicy
+		if cfg != nil {
+			policy, _ = cfg.RetryPolicy.Policy(cfg.RetryIntervals())
+		}
+		for _, line := range state.StatusLines(policy) {
+			fmt.Println(line)
 		}
 	}
 
+	// 轮询开销
+	if cost := stats.PollCost(); cost != nil {
+		fmt.Println(T("cli.status.poll_cost", cost.End.Format("2006-01-02 15:04:05"), cost.Period().Round(time.Minute),
+			cost.Checks, cost.Spawns, cost.SpawnsPerHour))
+	}
+
 	fmt.Println()
 
 	// 显示统计
-	stats := NewStatsManager(GetStatsDir())
 	fmt.Print(stats.FormatStats())
 }
 
+// runRearm 清除停止状态，运行中的服务在下次轮询时恢复自动修复
+func runRearm() {
+	cli := NewCLI()
+	stats := NewStatsManager(GetStatsDir())
+	if !stats.ClearGiveUp(context.Background(), "manual") {
+		cli.PrintInfo("%s", T("cli.rearm.not_needed"))
+		return
+	}
+	cli.PrintSuccess("%s", T("cli.rearm.done"))
+}
+
+// isFlagSet 命令行中是否显式指定了某个参数
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// showLogOptions -show-log 的显示和过滤选项
+type showLogOptions struct {
+	Lines   int
+	Follow  bool
+	Fields  string
+	Level   string
+	Tag     string
+	Since   string
+	Grep    string
+	Episode string
+}
+
+// buildLogFilter 根据命令行选项构建日志过滤条件（没有任何条件时返回 nil）
+func buildLogFilter(opts showLogOptions, now time.Time) (*LogFilter, error) {
+	filter := &LogFilter{}
+	empty := true
+
+	if opts.Fields != "" {
+		fields, err := ParseFieldFilter(opts.Fields)
+		if err != nil {
+			return nil, err
+		}
+		filter.Fields = fields
+		empty = false
+	}
+	if opts.Episode != "" {
+		if filter.Fields == nil {
+			filter.Fields = make(map[string]string)
+		}
+		filter.Fields["episode"] = opts.Episode
+		empty = false
+	}
+	if opts.Level != "" {
+		levels, err := ParseLevelFilter(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		filter.Levels = levels
+		empty = false
+	}
+	if opts.Tag != "" {
+		tags, err := ParseTagFilter(opts.Tag)
+		if err != nil {
+			return nil, err
+		}
+		filter.Tags = tags
+		empty = false
+	}
+	if opts.Since != "" {
+		since, err := ParseSince(opts.Since, now)
+		if err != nil {
+			return nil, err
+		}
+		filter.Since = since
+		empty = false
+	}
+	if opts.Grep != "" {
+		re, err := regexp.Compile("(?i)" + opts.Grep)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 -grep 表达式: %w", err)
+		}
+		filter.Grep = re
+		empty = false
+	}
+
+	if empty {
+		return nil, nil
+	}
+	return filter, nil
+}
+
 // runShowLog 显示服务日志
-func runShowLog(lines int) {
+func runShowLog(opts showLogOptions) {
 	cli := NewCLI()
 	cli.PrintTitle("GPD Touch Fix - Service Log")
 
-	logLines, err := ReadLogLines(lines)
+	filter, err := buildLogFilter(opts, time.Now())
 	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
+
+	logLines, err := ReadFilteredLogLines(opts.Lines, filter)
+	if err != nil && !opts.Follow {
 		cli.PrintWarning("Failed to read log: %v", err)
 		cli.PrintInfo("Log directory: %s", GetLogDir())
 		return
@@ -487,17 +685,149 @@ func runShowLog(lines int) {
 
 	fmt.Print(FormatLogForDisplay(logLines))
 	fmt.Printf("\nShowing last %d lines, log directory: %s\n", len(logLines), GetLogDir())
+
+	if !opts.Follow {
+		return
+	}
+
+	cli.PrintInfo("Following new log lines, press Ctrl+C to stop...")
+
+	stop := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		<-sigChan
+		close(stop)
+	}()
+
+	err = FollowLogs(GetLogDir(), filter, 500*time.Millisecond, stop, func(line string) {
+		entry, ok := ParseLogLine(line)
+		if !ok {
+			fmt.Printf("  %s\n", line)
+			return
+		}
+		fmt.Printf("%s%s\n", logDisplayPrefix(&entry), entry.FormatText())
+	})
+	if err != nil {
+		cli.PrintError("Failed to follow log: %v", err)
+	}
 }
 
 // runShowStats 显示统计信息
 func runShowStats() {
 	cli := NewCLI()
-	cli.PrintTitle("GPD 触屏修复工具 - 统计信息")
+	cli.PrintTitle(T("cli.stats.title"))
 
 	stats := NewStatsManager(GetStatsDir())
 	fmt.Print(stats.FormatStats())
 }
 
+// runDebugFor 临时提升服务日志级别，到期后服务自动恢复
+func runDebugFor(durationStr string) {
+	cli := NewCLI()
+
+	d, err := time.ParseDuration(durationStr)
+	if err != nil || d < 0 {
+		cli.PrintError(T("cli.invalid_duration"), durationStr)
+		os.Exit(2)
+	}
+
+	until := time.Now().Add(d)
+	override := LevelOverride{Level: "DEBUG", Until: until}
+	if err := WriteLevelOverride(GetLevelOverridePath(), override); err != nil {
+		cli.PrintError("%v", err)
+		os.Exit(1)
+	}
+
+	if d == 0 {
+		cli.PrintSuccess("%s", T("cli.debug_cancelled"))
+	} else {
+		cli.PrintSuccess(T("cli.debug_until"), until.Format("2006-01-02 15:04:05"))
+	}
+	cli.PrintInfo("%s", T("cli.debug_applied_soon"))
+}
+
+// runStatsCommand 处理统计子命令
+func runStatsCommand(args []string) {
+	if len(args) == 0 {
+		runShowStats()
+		return
+	}
+
+	switch args[0] {
+	case "export":
+		runStatsExport(args[1:])
+	case "merge":
+		runStatsMerge(args[1:])
+	default:
+		cli := NewCLI()
+		cli.PrintError(T("cli.stats.unknown_subcommand"), args[0])
+		os.Exit(2)
+	}
+}
+
+// runStatsExport 导出本机统计和事件历史
+func runStatsExport(args []string) {
+	fs := flag.NewFlagSet("stats export", flag.ExitOnError)
+	format := fs.String("format", "json", T("flag.export_format"))
+	output := fs.String("o", "", T("flag.export_output"))
+	_ = fs.Parse(args)
+
+	cli := NewCLI()
+	exportFormat, err := ParseExportFormat(*format)
+	if err != nil {
+		cli.PrintError("%v", err)
+		os.Exit(2)
+	}
+
+	stats := NewStatsManager(GetStatsDir())
+	export := NewStatsExport(GetMachineInfo(), stats.GetStats())
+
+	if *output == "" {
+		if err := export.Write(os.Stdout, exportFormat); err != nil {
+			cli.PrintError(T("cli.stats.export_failed"), err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		cli.PrintError(T("cli.stats.create_failed"), err)
+		os.Exit(1)
+	}
+	err = export.Write(f, exportFormat)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cli.PrintError(T("cli.stats.export_failed"), err)
+		os.Exit(1)
+	}
+	cli.PrintSuccess(T("cli.stats.exported"), len(export.Events), *output)
+}
+
+// runStatsMerge 合并多台机器的导出文件并输出汇总报告
+func runStatsMerge(files []string) {
+	cli := NewCLI()
+	if len(files) == 0 {
+		cli.PrintError("%s", T("cli.stats.merge_usage"))
+		os.Exit(2)
+	}
+
+	var events []FleetEvent
+	for _, path := range files {
+		fileEvents, err := LoadStatsExportFile(path)
+		if err != nil {
+			cli.PrintError("%v", err)
+			os.Exit(1)
+		}
+		events = append(events, fileEvents...)
+	}
+
+	fmt.Print(MergeFleetEvents(events).Format())
+}
+
 // runSetNotification 设置通知开关
 func runSetNotification(enable bool) {
 	cli := NewCLI()
@@ -511,33 +841,33 @@ func runSetNotification(enable bool) {
 	cfg.EnableNotification = enable
 
 	if err := cfg.SaveConfig(cfgPath); err != nil {
-		cli.PrintError("保存配置失败: %v", err)
+		cli.PrintError(T("cli.config_save_failed"), err)
 		return
 	}
 
 	if enable {
-		cli.PrintSuccess("已启用 Windows 通知")
+		cli.PrintSuccess("%s", T("cli.notification_enabled"))
 	} else {
-		cli.PrintSuccess("已禁用 Windows 通知")
+		cli.PrintSuccess("%s", T("cli.notification_disabled"))
 	}
 
-	cli.PrintInfo("重启服务后生效: gpd-touch-fix -stop && gpd-touch-fix -start")
+	cli.PrintInfo("%s", T("cli.restart_to_apply"))
 }
 
 // getServiceStatus 获取服务状态
 func getServiceStatus() string {
 	output, err := runPowerShell(`$svc = Get-Service -Name "GPDTouchFix" -ErrorAction SilentlyContinue; if ($svc) { $svc.Status } else { "NotInstalled" }`)
 	if err != nil {
-		return "❓ 未知"
+		return T("cli.status.unknown")
 	}
 
 	switch output {
 	case "Running":
-		return "✅ 运行中"
+		return T("cli.status.running")
 	case "Stopped":
-		return "⏹️ 已停止"
+		return T("cli.status.stopped")
 	case "NotInstalled":
-		return "❌ 未安装"
+		return T("cli.status.not_installed")
 	default:
 		return fmt.Sprintf("❓ %s", output)
 	}
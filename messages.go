@@ -0,0 +1,538 @@
+// Package main provides the zh-CN and en-US message catalog.
+package main
+
+// messageCatalog 界面文本，键按用途分组：notify.* 通知、stats.* 统计、log.* 日志查看、cli.* 命令行
+// 新增文本时两种语言都要添加（TestMessageCatalogComplete 会检查）
+var messageCatalog = map[Locale]map[string]string{
+	LocaleZhCN: {
+		// 通知
+		"notify.app_name":          "GPD 触屏修复工具",
+		"notify.failure.title":     "触屏修复失败",
+		"notify.failure.message":   "设备: %s\n错误: %v",
+		"notify.skip.title":        "触屏状态正常",
+		"notify.skip.message":      "设备 %s 状态正常，无需修复",
+		"notify.success.title":     "触屏已修复",
+		"notify.success.message":   "设备 %s 已成功修复",
+		"notify.give_up.title":     "触屏自动修复已停止",
+		"notify.give_up.message":   "设备 %s 连续修复失败 %d 次，已停止自动修复，请手动检查",
+		"notify.flap.title":        "触屏状态频繁变化，已暂停自动修复",
+		"notify.flap.message":      "设备 %s %s\n状态保持稳定后自动恢复（最早 %s）",
+		"notify.flap.snapshot":     "诊断快照: %s",
+		"notify.episode":           "事件: %s",
+		"notify.digest.title":      "触屏修复每日汇总",
+		"notify.digest.since":      "%s 以来: %s",
+		"notify.digest.count":      "%s %d 次",
+		"notify.digest.separator":  "，",
+		"notify.digest.suppressed": "另有 %d 条通知因限流、去重或静默时段未发送",
+		"notify.mail.event":        "事件: %s",
+		"notify.mail.device":       "设备: %s",
+		"notify.mail.status":       "状态: %s → %s",
+		"notify.mail.attempts":     "尝试次数: %d",
+		"notify.mail.episode":      "事件 ID: %s",
+		"notify.mail.host":         "主机: %s",
+		"notify.mail.time":         "时间: %s",
+		"event.success":            "修复成功",
+		"event.skip":               "状态正常",
+		"event.failure":            "修复失败",
+		"event.give_up":            "停止自动修复",
+		"event.quarantine":         "状态抖动隔离",
+		"event.other":              "其他通知",
+
+		// 统计
+		"stats.title":          "📊 统计信息",
+		"stats.today":          "📅 今日",
+		"stats.week":           "📆 本周",
+		"stats.month":          "🗓️  本月",
+		"stats.counts":         "   修复: %-3d  跳过: %-3d  失败: %-3d",
+		"stats.total":          "📈 累计",
+		"stats.total.resumes":  "   唤醒: %d",
+		"stats.total.resets":   "   修复: %d",
+		"stats.total.skips":    "   跳过: %d",
+		"stats.total.failures": "   失败: %d",
+		"stats.total.give_ups": "   停止修复: %d",
+		"stats.recent":         "🕐 最近事件",
+		"stats.last_resume":    "   上次唤醒: %s",
+		"stats.last_reset":     "   上次修复: %s",
+		"stats.no_record":      "无记录",
+		"stats.result":         "   结果: %s",
+		"stats.simple":         "今日: 修复%d/跳过%d/失败%d | 累计: 修复%d/跳过%d",
+
+		// 多机统计汇总
+		"fleet.title":    "========== 多机统计汇总 ==========",
+		"fleet.totals":   "机器数: %d  事件数: %d",
+		"fleet.by_model": "按型号:",
+		"fleet.by_bios":  "按 BIOS 版本:",
+		"fleet.counts":   "    机器: %-3d 唤醒: %-5d 修复: %-5d 失败: %-5d 跳过: %-5d",
+		"fleet.rates":    "    异常率: %5.1f%%  修复失败率: %5.1f%%",
+		"fleet.note":     "说明: 异常率 = (修复+失败)/唤醒，修复失败率 = 失败/(修复+失败)",
+		"fleet.footer":   "==================================",
+
+		// 重试策略、轮询和抖动隔离（日志、通知和 -status）
+		"retry.exponential": "指数退避 %s～%s",
+		"retry.jitter":      "（%s 抖动）",
+		"retry.linear":      "线性退避 %s 起每次 +%s，最多 %s",
+		"retry.fixed":       "固定间隔 [%s]",
+		"poll.every":        "每 %s",
+		"poll.adaptive":     "自适应（唤醒或失败后 %s 内每 %s，正常每 %s，稳定 %s 后每 %s）",
+		"poll.cost":         "%s 内检查 %d 次，PowerShell 进程 %d 个（%.1f 个/小时）",
+		"poll.cost.mode":    "，%s %s",
+		"flap.thresholds":   "%s 内切换 %d 次进入隔离，稳定 %s 后解除",
+		"flap.summary":      "%d 秒内切换 %d 次，最近状态: %s",
+
+		// 日志查看
+		"log.title":         "GPD 触屏修复工具 - 服务日志",
+		"log.header":        "========== 服务日志 ==========",
+		"log.footer":        "==============================",
+		"log.none":          "没有找到日志记录",
+		"log.read_failed":   "读取日志失败: %v",
+		"log.dir":           "日志目录: %s",
+		"log.shown":         "显示最近 %d 行，日志目录: %s",
+		"log.following":     "持续显示新日志，按 Ctrl+C 停止...",
+		"log.follow_failed": "跟踪日志失败: %v",
+
+		// 命令行参数说明
+		"flag.instance":             "I2C HID 设备的 InstanceId",
+		"flag.config":               "配置文件路径（默认为可执行文件同目录的 config.json）",
+		"flag.wait":                 "禁用后等待的秒数",
+		"flag.check":                "仅检查设备状态，不执行重置",
+		"flag.save_config":          "保存当前参数到配置文件",
+		"flag.setup":                "运行安装向导（自动检测设备并配置）",
+		"flag.scan":                 "扫描并列出所有 I2C HID 设备",
+		"flag.version":              "显示版本信息",
+		"flag.install":              "安装为 Windows 服务",
+		"flag.uninstall":            "卸载 Windows 服务",
+		"flag.start":                "启动 Windows 服务",
+		"flag.stop":                 "停止 Windows 服务",
+		"flag.service":              "以服务模式运行（由 Windows 服务管理器调用，内部使用）",
+		"flag.status":               "显示服务状态和统计信息",
+		"flag.rearm":                "清除停止状态，恢复自动修复",
+		"flag.state_table":          "显示睡眠/唤醒状态转换表",
+		"flag.show_log":             "显示服务日志",
+		"flag.stats":                "显示统计信息（子命令: export、merge）",
+		"flag.lines":                "显示日志行数（与 -show-log 配合使用）",
+		"flag.debug_for":            "临时将运行中服务的日志级别提升为 DEBUG，如 30m（0 表示立即恢复）",
+		"flag.field":                "按结构化字段过滤日志，如 status=Error,trigger=poll（与 -show-log 配合使用）",
+		"flag.follow":               "持续显示新写入的日志，跨日志轮转（与 -show-log 配合使用）",
+		"flag.level":                "按级别过滤日志，如 WARN+ 或 INFO,ERROR（与 -show-log 配合使用）",
+		"flag.tag":                  "按事件标签过滤日志，如 RESUME,FAIL（与 -show-log 配合使用）",
+		"flag.since":                "只显示此时间之后的日志，如 2h、1d、2025-12-24 08:00（与 -show-log 配合使用）",
+		"flag.grep":                 "按正则表达式过滤日志内容，不区分大小写（与 -show-log 配合使用）",
+		"flag.episode":              "只显示指定唤醒修复事件 ID 的日志（与 -show-log 配合使用）",
+		"flag.enable_notification":  "启用 Windows 通知",
+		"flag.disable_notification": "禁用 Windows 通知",
+		"flag.export_format":        "导出格式: csv 或 json",
+		"flag.export_output":        "输出文件路径（默认输出到标准输出）",
+
+		// 命令行：通用
+		"cli.need_admin":            "此操作需要管理员权限",
+		"cli.need_admin_prompt":     "需要管理员权限才能执行此操作",
+		"cli.not_admin":             "未以管理员身份运行！",
+		"cli.device_needs_admin":    "设备操作需要管理员权限。",
+		"cli.run_as_admin_hint":     "请右键程序 → '以管理员身份运行'",
+		"cli.ask_elevate":           "是否自动以管理员身份重新运行",
+		"cli.elevating":             "正在请求管理员权限...",
+		"cli.elevated":              "已在新窗口中以管理员身份启动程序",
+		"cli.continue_in_new":       "请在新窗口中继续操作",
+		"cli.elevate_failed":        "提升权限失败，可能是用户取消了 UAC 提示",
+		"cli.install_failed":        "安装服务失败: %v",
+		"cli.uninstall_failed":      "卸载服务失败: %v",
+		"cli.start_failed":          "启动服务失败: %v",
+		"cli.stop_failed":           "停止服务失败: %v",
+		"cli.config_load_failed":    "警告: 加载配置文件失败: %v",
+		"cli.config_loaded":         "已从配置文件加载: %s",
+		"cli.config_invalid":        "配置验证失败: %v",
+		"cli.config_save_failed":    "保存配置失败: %v",
+		"cli.config_saved":          "配置已保存到: %s",
+		"cli.autodetect":            "未配置设备，尝试自动检测...",
+		"cli.autodetect_failed":     "自动检测失败: %v",
+		"cli.autodetect_hint":       "请使用 -setup 运行安装向导，或使用 -instance 参数手动指定设备 InstanceId",
+		"cli.autodetected":          "自动检测到设备: %s",
+		"cli.device_id":             "设备 ID: %s",
+		"cli.save_config_hint":      "提示: 运行 -save-config 可保存此配置",
+		"cli.check_failed":          "检查设备状态失败: %v",
+		"cli.device_status":         "设备状态: %s",
+		"cli.reset_banner":          "GPD 触屏恢复工具",
+		"cli.reset_failed":          "修复失败: %v",
+		"cli.reset_vetoed":          "pre_reset 钩子阻止了本次修复: %v",
+		"cli.reset_done":            "触屏设备已成功重置！",
+		"cli.invalid_duration":      "无效的时长: %q（示例: 30m、2h、0）",
+		"cli.debug_cancelled":       "已取消临时日志级别，服务将恢复为配置的级别",
+		"cli.debug_until":           "服务日志级别将临时提升为 DEBUG，至 %s 自动恢复",
+		"cli.debug_applied_soon":    "运行中的服务会在几秒内应用此设置",
+		"cli.rearm.done":            "已恢复自动修复，服务将在下次轮询时重新尝试",
+		"cli.rearm.not_needed":      "自动修复未处于停止状态，无需恢复",
+		"cli.notification_enabled":  "已启用 Windows 通知",
+		"cli.notification_disabled": "已禁用 Windows 通知",
+		"cli.restart_to_apply":      "重启服务后生效: gpd-touch-fix -stop && gpd-touch-fix -start",
+
+		// 命令行：扫描与设备列表
+		"cli.scan.title":         "扫描 I2C HID 设备",
+		"cli.scan.progress":      "正在扫描设备...",
+		"cli.scan.failed":        "扫描失败: %v",
+		"cli.scan.none":          "未找到任何 I2C HID 设备",
+		"cli.scan.found":         "找到 %d 个设备",
+		"cli.device.none":        "未找到任何设备",
+		"cli.device.header":      "设备 #%d",
+		"cli.device.name":        "名称: %s",
+		"cli.device.status":      "状态: %s",
+		"cli.device.instance":    "实例ID: %s",
+		"cli.device.id":          "设备ID: %s",
+		"cli.device.class":       "类别: %s",
+		"cli.device.vendor":      "制造商: %s",
+		"cli.device.score":       "匹配度: %d 分",
+		"cli.device.detected_1":  "检测到 1 个设备:",
+		"cli.device.detected_n":  "检测到以下设备:",
+		"cli.device.recommended": " [推荐]",
+		"cli.device.use_this":    "是否使用此设备",
+		"cli.device.choose":      "请选择设备 (1-%d)",
+		"cli.device.bad_choice":  "无效的选择，请输入 1-%d",
+		"cli.device.no_choices":  "没有可选择的设备",
+		"cli.device.cancelled":   "用户取消选择",
+
+		// 命令行：安装向导
+		"cli.setup.title":            "GPD 触屏修复工具 - 安装向导",
+		"cli.setup.welcome":          "欢迎使用！本向导将帮助您：",
+		"cli.setup.step_detect":      "  1. 自动检测触控设备",
+		"cli.setup.step_test":        "  2. 测试修复功能",
+		"cli.setup.step_notify":      "  3. 配置通知选项",
+		"cli.setup.step_service":     "  4. 安装自动化服务",
+		"cli.setup.admin_partial":    "部分功能（测试修复、安装服务）需要管理员权限",
+		"cli.setup.limited":          "将以有限权限模式继续，部分功能可能无法使用",
+		"cli.setup.continue":         "是否继续",
+		"cli.setup.cancelled":        "已取消",
+		"cli.setup.detect_title":     "步骤 1/4: 检测设备",
+		"cli.setup.detecting":        "正在扫描 I2C HID 设备...",
+		"cli.setup.detect_failed":    "检测失败: %v",
+		"cli.setup.manual_hint":      "您可以稍后使用 -instance 参数手动指定设备",
+		"cli.setup.candidates":       "找到 %d 个候选设备",
+		"cli.setup.select_failed":    "选择设备失败: %v",
+		"cli.setup.selected":         "已选择: %s",
+		"cli.setup.test_title":       "步骤 2/4: 测试修复",
+		"cli.setup.ask_test":         "是否测试修复此设备",
+		"cli.setup.skip_test":        "跳过测试，直接保存配置",
+		"cli.setup.testing":          "正在测试设备修复...",
+		"cli.setup.test_failed":      "修复失败: %v",
+		"cli.setup.test_failed_hint": "您仍然可以保存配置，但请检查设备 ID 是否正确",
+		"cli.setup.test_ok":          "修复成功！触控设备应该已经恢复正常",
+		"cli.setup.saving":           "正在保存配置...",
+		"cli.setup.notify_title":     "步骤 3/4: 通知设置",
+		"cli.setup.notify_desc":      "启用通知后，每次睡眠唤醒时会收到修复结果的 Windows 通知。",
+		"cli.setup.ask_notify":       "是否启用 Windows 通知",
+		"cli.setup.notify_on":        "已启用通知",
+		"cli.setup.notify_off":       "已禁用通知（可通过 -enable-notification 命令重新启用）",
+		"cli.setup.service_title":    "步骤 4/4: 安装自动化服务",
+		"cli.setup.service_desc":     "将程序安装为 Windows 服务后，每次睡眠唤醒都会自动修复触控。",
+		"cli.setup.ask_service":      "是否安装为 Windows 服务",
+		"cli.setup.service_skipped":  "已跳过服务安装",
+		"cli.setup.service_later":    "您可以稍后运行以下命令安装服务:",
+		"cli.setup.configured":       "配置完成！",
+		"cli.setup.installing":       "正在安装服务...",
+		"cli.setup.run_as_admin":     "请以管理员身份运行本程序",
+		"cli.setup.installed":        "服务安装成功",
+		"cli.setup.ask_start":        "是否立即启动服务",
+		"cli.setup.starting":         "正在启动服务...",
+		"cli.setup.started":          "服务已启动",
+		"cli.setup.done":             "安装完成！",
+		"cli.setup.next":             "现在您可以:",
+		"cli.setup.next_test":        "  • 合上屏幕测试睡眠唤醒后触控是否自动恢复",
+		"cli.setup.next_status":      "  • 运行 'gpd-touch-fix -status' 查看服务状态和统计",
+		"cli.setup.next_log":         "  • 运行 'gpd-touch-fix -show-log' 查看服务日志",
+
+		// 命令行：状态与统计
+		"cli.status.title":              "GPD 触屏修复工具 - 状态",
+		"cli.status.service":            "服务状态: %s",
+		"cli.status.no_config":          "未找到配置文件",
+		"cli.status.config":             "配置文件: %s",
+		"cli.status.device":             "监控设备: %s",
+		"cli.status.device_error":       "设备状态: ❌ 无法获取 (%v)",
+		"cli.status.device_ok":          "设备状态: ✅ %s",
+		"cli.status.device_bad":         "设备状态: ⚠️ %s",
+		"cli.status.notify_on":          "通知状态: ✅ 已启用",
+		"cli.status.notify_off":         "通知状态: ❌ 已禁用",
+		"cli.status.gave_up":            "⛔ 自动修复已停止: 自 %s 起，连续失败 %d 次",
+		"cli.status.rearm_at":           "   将于 %s 自动恢复，或运行 -rearm 立即恢复",
+		"cli.status.rearm_hint":         "   下次唤醒时自动恢复，或运行 -rearm 立即恢复",
+		"cli.status.next_retry":         "🔁 下次自动重试: %s（已连续失败 %d 次）",
+		"cli.status.retry_blocked_wake": "⏸️ 本次唤醒已自动修复 %d 次，达到上限，等待下次唤醒",
+		"cli.status.retry_blocked_day":  "⏸️ 今天已自动修复 %d 次，达到上限，明天恢复",
+		"cli.status.retry_budget":       "重试策略: %s；本次唤醒已修复 %d 次，今天 %d 次",
+		"cli.status.quarantined":        "🚧 设备状态频繁变化，自 %s 起暂停自动修复，状态保持稳定时 %s 恢复",
+		"cli.status.poll_cost":          "轮询开销（截至 %s，%s 内）: 检查 %d 次，PowerShell 进程 %d 个（%.1f 个/小时）",
+		"cli.status.running":            "✅ 运行中",
+		"cli.status.stopped":            "⏹️ 已停止",
+		"cli.status.not_installed":      "❌ 未安装",
+		"cli.status.unknown":            "❓ 未知",
+		"cli.stats.title":               "GPD 触屏修复工具 - 统计信息",
+		"cli.stats.unknown_subcommand":  "未知的统计子命令: %s（可选 export、merge）",
+		"cli.stats.export_failed":       "导出失败: %v",
+		"cli.stats.create_failed":       "创建输出文件失败: %v",
+		"cli.stats.exported":            "已导出 %d 条事件到: %s",
+		"cli.stats.merge_usage":         "用法: gpd-touch-fix -stats merge file1 file2 ...",
+	},
+
+	LocaleEnUS: {
+		// Notifications
+		"notify.app_name":          "GPD Touch Fix",
+		"notify.failure.title":     "Touchscreen repair failed",
+		"notify.failure.message":   "Device: %s\nError: %v",
+		"notify.skip.title":        "Touchscreen is working",
+		"notify.skip.message":      "Device %s is OK, no repair needed",
+		"notify.success.title":     "Touchscreen repaired",
+		"notify.success.message":   "Device %s was repaired successfully",
+		"notify.give_up.title":     "Automatic touchscreen repair stopped",
+		"notify.give_up.message":   "Repairing device %s failed %d times in a row. Automatic repair has stopped, please check the device manually",
+		"notify.flap.title":        "Touchscreen keeps flapping, automatic repair paused",
+		"notify.flap.message":      "Device %s: %s\nAutomatic repair resumes once the status stays stable (at %s at the earliest)",
+		"notify.flap.snapshot":     "Diagnostic snapshot: %s",
+		"notify.episode":           "Episode: %s",
+		"notify.digest.title":      "Daily touchscreen repair summary",
+		"notify.digest.since":      "Since %s: %s",
+		"notify.digest.count":      "%s %d times",
+		"notify.digest.separator":  ", ",
+		"notify.digest.suppressed": "%d more notifications were held back by rate limits, deduplication or quiet hours",
+		"notify.mail.event":        "Event: %s",
+		"notify.mail.device":       "Device: %s",
+		"notify.mail.status":       "Status: %s → %s",
+		"notify.mail.attempts":     "Attempts: %d",
+		"notify.mail.episode":      "Episode ID: %s",
+		"notify.mail.host":         "Host: %s",
+		"notify.mail.time":         "Time: %s",
+		"event.success":            "repaired",
+		"event.skip":               "already OK",
+		"event.failure":            "failed",
+		"event.give_up":            "gave up",
+		"event.quarantine":         "quarantined",
+		"event.other":              "other",
+
+		// Statistics
+		"stats.title":          "📊 Statistics",
+		"stats.today":          "📅 Today",
+		"stats.week":           "📆 This week",
+		"stats.month":          "🗓️  This month",
+		"stats.counts":         "   Fixed: %-3d Skipped: %-3d Failed: %-3d",
+		"stats.total":          "📈 All time",
+		"stats.total.resumes":  "   Wakes:    %d",
+		"stats.total.resets":   "   Fixed:    %d",
+		"stats.total.skips":    "   Skipped:  %d",
+		"stats.total.failures": "   Failed:   %d",
+		"stats.total.give_ups": "   Gave up:  %d",
+		"stats.recent":         "🕐 Recent events",
+		"stats.last_resume":    "   Last wake:   %s",
+		"stats.last_reset":     "   Last repair: %s",
+		"stats.no_record":      "none",
+		"stats.result":         "   Result: %s",
+		"stats.simple":         "Today: fixed %d/skipped %d/failed %d | Total: fixed %d/skipped %d",
+
+		// Fleet report
+		"fleet.title":    "========== FLEET REPORT ==========",
+		"fleet.totals":   "Machines: %d  Events: %d",
+		"fleet.by_model": "By model:",
+		"fleet.by_bios":  "By BIOS version:",
+		"fleet.counts":   "    Machines: %-3d Wakes: %-5d Fixed: %-5d Failed: %-5d Skipped: %-5d",
+		"fleet.rates":    "    Issue rate: %5.1f%%  Repair failure rate: %5.1f%%",
+		"fleet.note":     "Note: issue rate = (fixed+failed)/wakes, repair failure rate = failed/(fixed+failed)",
+		"fleet.footer":   "==================================",
+
+		// Retry policy, polling and flap quarantine (log, notifications and -status)
+		"retry.exponential": "exponential backoff %s-%s",
+		"retry.jitter":      " (%s jitter)",
+		"retry.linear":      "linear backoff from %s, +%s per failure, up to %s",
+		"retry.fixed":       "fixed schedule [%s]",
+		"poll.every":        "every %s",
+		"poll.adaptive":     "adaptive (for %s after a wake or failure every %s, normally every %s, after %s stable every %s)",
+		"poll.cost":         "over %s: %d checks, %d PowerShell processes (%.1f/hour)",
+		"poll.cost.mode":    ", %s %s",
+		"flap.thresholds":   "quarantine after %s with %d transitions, release after %s stable",
+		"flap.summary":      "in %d seconds %d transitions, recent statuses: %s",
+
+		// Log viewer
+		"log.title":         "GPD Touch Fix - Service Log",
+		"log.header":        "========== SERVICE LOG ==========",
+		"log.footer":        "=================================",
+		"log.none":          "No log records found",
+		"log.read_failed":   "Failed to read log: %v",
+		"log.dir":           "Log directory: %s",
+		"log.shown":         "Showing last %d lines, log directory: %s",
+		"log.following":     "Following new log lines, press Ctrl+C to stop...",
+		"log.follow_failed": "Failed to follow log: %v",
+
+		// Command-line flags
+		"flag.instance":             "InstanceId of the I2C HID device",
+		"flag.config":               "Config file path (default: config.json next to the executable)",
+		"flag.wait":                 "Seconds to wait after disabling the device",
+		"flag.check":                "Only check the device status, do not reset",
+		"flag.save_config":          "Save the current options to the config file",
+		"flag.setup":                "Run the setup wizard (detect and configure the device)",
+		"flag.scan":                 "Scan and list all I2C HID devices",
+		"flag.version":              "Show version information",
+		"flag.install":              "Install as a Windows service",
+		"flag.uninstall":            "Uninstall the Windows service",
+		"flag.start":                "Start the Windows service",
+		"flag.stop":                 "Stop the Windows service",
+		"flag.service":              "Run in service mode (used by the Windows service manager)",
+		"flag.status":               "Show service status and statistics",
+		"flag.rearm":                "Clear the gave-up state and resume automatic repair",
+		"flag.state_table":          "Print the sleep/wake state transition table",
+		"flag.show_log":             "Show the service log",
+		"flag.stats":                "Show statistics (subcommands: export, merge)",
+		"flag.lines":                "Number of log lines to show (with -show-log)",
+		"flag.debug_for":            "Temporarily raise the running service's log level to DEBUG, e.g. 30m (0 restores it now)",
+		"flag.field":                "Filter log by structured fields, e.g. status=Error,trigger=poll (with -show-log)",
+		"flag.follow":               "Keep showing new log lines across rotations (with -show-log)",
+		"flag.level":                "Filter log by level, e.g. WARN+ or INFO,ERROR (with -show-log)",
+		"flag.tag":                  "Filter log by event tag, e.g. RESUME,FAIL (with -show-log)",
+		"flag.since":                "Only show log lines after this time, e.g. 2h, 1d, 2025-12-24 08:00 (with -show-log)",
+		"flag.grep":                 "Filter log lines by case-insensitive regular expression (with -show-log)",
+		"flag.episode":              "Only show log lines of the given wake/repair episode ID (with -show-log)",
+		"flag.enable_notification":  "Enable Windows notifications",
+		"flag.disable_notification": "Disable Windows notifications",
+		"flag.export_format":        "Export format: csv or json",
+		"flag.export_output":        "Output file path (default: standard output)",
+
+		// CLI: common
+		"cli.need_admin":            "This operation requires administrator privileges",
+		"cli.need_admin_prompt":     "Administrator privileges are required for this operation",
+		"cli.not_admin":             "Not running as administrator!",
+		"cli.device_needs_admin":    "Device operations require administrator privileges.",
+		"cli.run_as_admin_hint":     "Right-click the program → 'Run as administrator'",
+		"cli.ask_elevate":           "Restart as administrator automatically",
+		"cli.elevating":             "Requesting administrator privileges...",
+		"cli.elevated":              "Started the program as administrator in a new window",
+		"cli.continue_in_new":       "Please continue in the new window",
+		"cli.elevate_failed":        "Failed to elevate, the UAC prompt may have been cancelled",
+		"cli.install_failed":        "Failed to install service: %v",
+		"cli.uninstall_failed":      "Failed to uninstall service: %v",
+		"cli.start_failed":          "Failed to start service: %v",
+		"cli.stop_failed":           "Failed to stop service: %v",
+		"cli.config_load_failed":    "Warning: failed to load config file: %v",
+		"cli.config_loaded":         "Loaded config file: %s",
+		"cli.config_invalid":        "Invalid config: %v",
+		"cli.config_save_failed":    "Failed to save config: %v",
+		"cli.config_saved":          "Config saved to: %s",
+		"cli.autodetect":            "No device configured, trying to detect one...",
+		"cli.autodetect_failed":     "Automatic detection failed: %v",
+		"cli.autodetect_hint":       "Run -setup to start the setup wizard, or pass the device InstanceId with -instance",
+		"cli.autodetected":          "Detected device: %s",
+		"cli.device_id":             "Device ID: %s",
+		"cli.save_config_hint":      "Tip: run -save-config to save this configuration",
+		"cli.check_failed":          "Failed to check device status: %v",
+		"cli.device_status":         "Device status: %s",
+		"cli.reset_banner":          "GPD Touch Fix",
+		"cli.reset_failed":          "Repair failed: %v",
+		"cli.reset_vetoed":          "Reset vetoed by pre_reset hook: %v",
+		"cli.reset_done":            "Touchscreen device reset successfully!",
+		"cli.invalid_duration":      "Invalid duration: %q (examples: 30m, 2h, 0)",
+		"cli.debug_cancelled":       "Temporary log level cancelled, the service will return to the configured level",
+		"cli.debug_until":           "The service log level is raised to DEBUG until %s",
+		"cli.debug_applied_soon":    "The running service will apply this within a few seconds",
+		"cli.rearm.done":            "Automatic repair resumed; the service will retry on its next poll",
+		"cli.rearm.not_needed":      "Automatic repair is not stopped, nothing to do",
+		"cli.notification_enabled":  "Windows notifications enabled",
+		"cli.notification_disabled": "Windows notifications disabled",
+		"cli.restart_to_apply":      "Restart the service to apply: gpd-touch-fix -stop && gpd-touch-fix -start",
+
+		// CLI: scan and device list
+		"cli.scan.title":         "Scan I2C HID devices",
+		"cli.scan.progress":      "Scanning devices...",
+		"cli.scan.failed":        "Scan failed: %v",
+		"cli.scan.none":          "No I2C HID devices found",
+		"cli.scan.found":         "Found %d devices",
+		"cli.device.none":        "No devices found",
+		"cli.device.header":      "Device #%d",
+		"cli.device.name":        "Name: %s",
+		"cli.device.status":      "Status: %s",
+		"cli.device.instance":    "Instance ID: %s",
+		"cli.device.id":          "Device ID: %s",
+		"cli.device.class":       "Class: %s",
+		"cli.device.vendor":      "Manufacturer: %s",
+		"cli.device.score":       "Match score: %d",
+		"cli.device.detected_1":  "Detected 1 device:",
+		"cli.device.detected_n":  "Detected the following devices:",
+		"cli.device.recommended": " [recommended]",
+		"cli.device.use_this":    "Use this device",
+		"cli.device.choose":      "Select a device (1-%d)",
+		"cli.device.bad_choice":  "Invalid choice, enter 1-%d",
+		"cli.device.no_choices":  "no devices to choose from",
+		"cli.device.cancelled":   "selection cancelled by user",
+
+		// CLI: setup wizard
+		"cli.setup.title":            "GPD Touch Fix - Setup Wizard",
+		"cli.setup.welcome":          "Welcome! This wizard will help you:",
+		"cli.setup.step_detect":      "  1. Detect the touch device",
+		"cli.setup.step_test":        "  2. Test the repair",
+		"cli.setup.step_notify":      "  3. Configure notifications",
+		"cli.setup.step_service":     "  4. Install the background service",
+		"cli.setup.admin_partial":    "Some steps (test repair, install service) require administrator privileges",
+		"cli.setup.limited":          "Continuing with limited privileges, some steps may not work",
+		"cli.setup.continue":         "Continue",
+		"cli.setup.cancelled":        "Cancelled",
+		"cli.setup.detect_title":     "Step 1/4: Detect device",
+		"cli.setup.detecting":        "Scanning I2C HID devices...",
+		"cli.setup.detect_failed":    "Detection failed: %v",
+		"cli.setup.manual_hint":      "You can specify the device later with -instance",
+		"cli.setup.candidates":       "Found %d candidate devices",
+		"cli.setup.select_failed":    "Failed to select device: %v",
+		"cli.setup.selected":         "Selected: %s",
+		"cli.setup.test_title":       "Step 2/4: Test repair",
+		"cli.setup.ask_test":         "Test repairing this device",
+		"cli.setup.skip_test":        "Skipping the test, saving the configuration",
+		"cli.setup.testing":          "Testing device repair...",
+		"cli.setup.test_failed":      "Repair failed: %v",
+		"cli.setup.test_failed_hint": "You can still save the configuration, but please check the device ID",
+		"cli.setup.test_ok":          "Repair succeeded! The touch device should work again",
+		"cli.setup.saving":           "Saving configuration...",
+		"cli.setup.notify_title":     "Step 3/4: Notifications",
+		"cli.setup.notify_desc":      "With notifications enabled, you get a Windows notification with the repair result after every wake.",
+		"cli.setup.ask_notify":       "Enable Windows notifications",
+		"cli.setup.notify_on":        "Notifications enabled",
+		"cli.setup.notify_off":       "Notifications disabled (re-enable with -enable-notification)",
+		"cli.setup.service_title":    "Step 4/4: Install background service",
+		"cli.setup.service_desc":     "Once installed as a Windows service, the touchscreen is repaired automatically after every wake.",
+		"cli.setup.ask_service":      "Install as a Windows service",
+		"cli.setup.service_skipped":  "Service installation skipped",
+		"cli.setup.service_later":    "You can install the service later with:",
+		"cli.setup.configured":       "Configuration complete!",
+		"cli.setup.installing":       "Installing service...",
+		"cli.setup.run_as_admin":     "Please run this program as administrator",
+		"cli.setup.installed":        "Service installed",
+		"cli.setup.ask_start":        "Start the service now",
+		"cli.setup.starting":         "Starting service...",
+		"cli.setup.started":          "Service started",
+		"cli.setup.done":             "Setup complete!",
+		"cli.setup.next":             "Now you can:",
+		"cli.setup.next_test":        "  • Close the lid and check that touch works again after wake",
+		"cli.setup.next_status":      "  • Run 'gpd-touch-fix -status' to see service status and statistics",
+		"cli.setup.next_log":         "  • Run 'gpd-touch-fix -show-log' to see the service log",
+
+		// CLI: status and statistics
+		"cli.status.title":              "GPD Touch Fix - Status",
+		"cli.status.service":            "Service: %s",
+		"cli.status.no_config":          "Config file not found",
+		"cli.status.config":             "Config file: %s",
+		"cli.status.device":             "Device: %s",
+		"cli.status.device_error":       "Device status: ❌ unavailable (%v)",
+		"cli.status.device_ok":          "Device status: ✅ %s",
+		"cli.status.device_bad":         "Device status: ⚠️ %s",
+		"cli.status.notify_on":          "Notifications: ✅ enabled",
+		"cli.status.notify_off":         "Notifications: ❌ disabled",
+		"cli.status.gave_up":            "⛔ Automatic repair stopped: since %s after %d consecutive failures",
+		"cli.status.rearm_at":           "   Resumes automatically at %s, or run -rearm to resume now",
+		"cli.status.rearm_hint":         "   Resumes on the next wake, or run -rearm to resume now",
+		"cli.status.next_retry":         "🔁 Next automatic retry: %s (%d consecutive failures)",
+		"cli.status.retry_blocked_wake": "⏸️ %d automatic repairs since this wake reached the limit, waiting for the next wake",
+		"cli.status.retry_blocked_day":  "⏸️ %d automatic repairs today reached the daily limit, resuming tomorrow",
+		"cli.status.retry_budget":       "Retry policy: %s; %d repairs since this wake, %d today",
+		"cli.status.quarantined":        "🚧 Device status keeps flapping, automatic repair paused since %s; resumes at %s if the status stays stable",
+		"cli.status.poll_cost":          "Polling cost (as of %s, over %s): %d checks, %d PowerShell processes (%.1f/hour)",
+		"cli.status.running":            "✅ running",
+		"cli.status.stopped":            "⏹️ stopped",
+		"cli.status.not_installed":      "❌ not installed",
+		"cli.status.unknown":            "❓ unknown",
+		"cli.stats.title":               "GPD Touch Fix - Statistics",
+		"cli.stats.unknown_subcommand":  "Unknown stats subcommand: %s (choose export or merge)",
+		"cli.stats.export_failed":       "Export failed: %v",
+		"cli.stats.create_failed":       "Failed to create output file: %v",
+		"cli.stats.exported":            "Exported %d events to: %s",
+		"cli.stats.merge_usage":         "Usage: gpd-touch-fix -stats merge file1 file2 ...",
+	},
+}
@@ -1,13 +1,18 @@
-// Package main provides Windows toast notification functionality for user notifications.
+// Package main provides user notifications dispatched to pluggable sinks (toast, webhook, email, command, file).
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
-// NotificationType 通知类型
+// NotificationType 通知类型（决定图标和严重程度）
 type NotificationType int
 
 const (
@@ -17,18 +22,183 @@ const (
 	NotifyError
 )
 
-// Notifier Windows 通知管理器
+// String 返回通知类型名称
+func (t NotificationType) String() string {
+	switch t {
+	case NotifySuccess:
+		return "success"
+	case NotifyWarning:
+		return "warning"
+	case NotifyError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// NotificationEvent 触发通知的事件，可按事件配置每个通知渠道
+type NotificationEvent string
+
+const (
+	NotifyEventSuccess    NotificationEvent = "success"    // 修复成功
+	NotifyEventSkip       NotificationEvent = "skip"       // 状态正常，跳过修复
+	NotifyEventFailure    NotificationEvent = "failure"    // 修复失败
+	NotifyEventGiveUp     NotificationEvent = "give_up"    // 达到最大重试次数，停止自动修复
+	NotifyEventQuarantine NotificationEvent = "quarantine" // 设备状态频繁变化，暂停自动修复（隔离）
+	NotifyEventInfo       NotificationEvent = "info"       // 其他提示（如测试通知）
+	NotifyEventDigest     NotificationEvent = "digest"     // 每日汇总
+)
+
+// allNotificationEvents 可在配置中使用的事件
+var allNotificationEvents = []NotificationEvent{
+	NotifyEventSuccess, NotifyEventSkip, NotifyEventFailure, NotifyEventGiveUp, NotifyEventQuarantine, NotifyEventInfo, NotifyEventDigest,
+}
+
+// Notification 一条通知
+type Notification struct {
+	Event    NotificationEvent `json:"event"`
+	Level    string            `json:"level"` // info / success / warning / error
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Device   string            `json:"device,omitempty"`
+	Episode  string            `json:"episode,omitempty"` // 唤醒修复事件 ID
+	Error    string            `json:"error,omitempty"`
+	Hostname string            `json:"hostname,omitempty"`
+	Version  string            `json:"version,omitempty"` // 工具版本
+	Time     time.Time         `json:"time"`
+
+	StatusBefore string `json:"status_before,omitempty"` // 修复前设备状态
+	StatusAfter  string `json:"status_after,omitempty"`  // 修复后设备状态
+	Attempts     int    `json:"attempts,omitempty"`      // 第几次尝试（含本次的连续失败次数）
+	Priority     string `json:"priority,omitempty"`      // "high" 表示需要用户处理（如已停止自动修复）
+
+	Type NotificationType `json:"-"`
+}
+
+// PriorityHigh 高优先级通知：Toast 常驻屏幕、邮件标记为重要、静默时段仍然发送
+const PriorityHigh = "high"
+
+// NotificationSink 通知渠道
+type NotificationSink interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// notifierRoute 通知渠道及其订阅的事件
+type notifierRoute struct {
+	sink   NotificationSink
+	events map[NotificationEvent]bool // 为空表示所有事件
+}
+
+// Notifier 通知管理器，将通知分发到所有订阅了该事件的渠道
 type Notifier struct {
 	enabled bool
 	appName string
+	routes  []notifierRoute
+	logger  TagLogger
+	policy  *NotificationPolicy // 限流、去重、静默时段和每日汇总（可选）
+	now     func() time.Time
+
+	templates map[NotificationEvent]*notificationTemplate // 按事件自定义标题和正文（可选）
 }
 
-// NewNotifier 创建通知管理器
+// NewNotifier 创建通知管理器（默认只有 Windows Toast 渠道，订阅所有事件）
 func NewNotifier(enabled bool) *Notifier {
-	return &Notifier{
+	n := &Notifier{
 		enabled: enabled,
-		appName: "GPD 触屏修复工具",
+		appName: T("notify.app_name"),
+		logger:  ConsoleLogger{},
+		now:     time.Now,
 	}
+	n.AddSink(NewToastSink(n.appName))
+	return n
+}
+
+// NewNotifierFromConfig 根据配置创建通知管理器
+// 未配置 notifications 时保持原有行为：只发送 Windows Toast
+func NewNotifierFromConfig(cfg *Config) (*Notifier, error) {
+	n := NewNotifier(cfg.EnableNotification)
+	if err := n.applyPolicy(cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.NotificationTemplates) > 0 {
+		templates, err := parseNotificationTemplates(cfg.NotificationTemplates)
+		if err != nil {
+			return nil, fmt.Errorf("notification_templates: %w", err)
+		}
+		n.templates = templates
+	}
+	if len(cfg.Notifications) == 0 {
+		return n, nil
+	}
+
+	n.routes = nil
+	for i := range cfg.Notifications {
+		sc := &cfg.Notifications[i]
+		sink, err := sc.NewSink(n.appName)
+		if err != nil {
+			return nil, fmt.Errorf("notifications[%d]: %w", i, err)
+		}
+		events, err := sc.ParseEvents()
+		if err != nil {
+			return nil, fmt.Errorf("notifications[%d]: %w", i, err)
+		}
+		n.AddSink(sink, events...)
+	}
+	return n, nil
+}
+
+// applyPolicy 根据配置设置通知策略
+func (n *Notifier) applyPolicy(cfg *Config) error {
+	if cfg.NotificationPolicy == nil {
+		return nil
+	}
+	policy, err := NewNotificationPolicy(*cfg.NotificationPolicy)
+	if err != nil {
+		return fmt.Errorf("notification_policy: %w", err)
+	}
+	n.SetPolicy(policy)
+	return nil
+}
+
+// AddSink 添加通知渠道，events 为空表示订阅所有事件
+func (n *Notifier) AddSink(sink NotificationSink, events ...NotificationEvent) {
+	route := notifierRoute{sink: sink}
+	if len(events) > 0 {
+		route.events = make(map[NotificationEvent]bool, len(events))
+		for _, e := range events {
+			route.events[e] = true
+		}
+	}
+	n.routes = append(n.routes, route)
+}
+
+// SetLogger 设置日志输出（记录通知发送失败），同时传给需要记录日志的渠道
+func (n *Notifier) SetLogger(logger TagLogger) {
+	n.logger = logger
+	for _, route := range n.routes {
+		if s, ok := route.sink.(interface{ SetLogger(TagLogger) }); ok {
+			s.SetLogger(logger)
+		}
+	}
+}
+
+// Close 关闭需要释放资源的渠道（如停止 Webhook 离线队列的后台补发）
+func (n *Notifier) Close() error {
+	var errs []error
+	for _, route := range n.routes {
+		if c, ok := route.sink.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", route.sink.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetPolicy 设置通知策略（nil 表示所有通知立即发送）
+func (n *Notifier) SetPolicy(policy *NotificationPolicy) {
+	n.policy = policy
 }
 
 // SetEnabled 设置是否启用通知
@@ -41,13 +211,117 @@ func (n *Notifier) IsEnabled() bool {
 	return n.enabled
 }
 
-// Send 发送通知
-func (n *Notifier) Send(notifyType NotificationType, title, message string) error {
+// Dispatch 套用自定义模板后按通知策略（限流、去重、静默时段、每日汇总）决定是否发送，
+// 然后并行发送到所有订阅了该事件的渠道，等待全部完成；单个渠道失败不影响其他渠道，所有错误合并返回
+func (n *Notifier) Dispatch(ctx context.Context, notif Notification) error {
 	if !n.enabled {
 		return nil
 	}
 
-	return n.sendToast(notifyType, title, message)
+	if notif.Time.IsZero() {
+		notif.Time = n.now()
+	}
+	if notif.Level == "" {
+		notif.Level = notif.Type.String()
+	}
+	if notif.Episode == "" {
+		notif.Episode = EpisodeFromContext(ctx)
+	}
+	if notif.Hostname == "" {
+		notif.Hostname, _ = os.Hostname()
+	}
+	if notif.Version == "" {
+		notif.Version = Version
+	}
+
+	if n.policy != nil && notif.Event != NotifyEventDigest {
+		switch decision, reason := n.policy.admit(notif, notif.Time); decision {
+		case policyDigest:
+			n.logger.LogTag(ctx, DEBUG, TagService, "通知 %q 计入每日汇总", notif.Title)
+			return nil
+		case policySuppressed:
+			n.logger.LogTag(ctx, DEBUG, TagService, "通知 %q 未发送: %s", notif.Title, reason)
+			return nil
+		}
+	}
+
+	// 模板在策略之后应用：去重按默认文本判断，模板中的时间、事件 ID 等每次不同的字段不会让去重失效
+	if t := n.templates[notif.Event]; t != nil {
+		rendered, err := t.apply(notif)
+		if err != nil {
+			n.logger.LogTag(ctx, WARNING, TagService, "通知模板无效，使用默认文本: %v", err)
+		} else {
+			notif = rendered
+		}
+	}
+
+	return n.fanOut(ctx, notif)
+}
+
+// fanOut 并行发送到所有订阅了该事件的渠道
+func (n *Notifier) fanOut(ctx context.Context, notif Notification) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, route := range n.routes {
+		if route.events != nil && !route.events[notif.Event] {
+			continue
+		}
+		wg.Add(1)
+		go func(sink NotificationSink) {
+			defer wg.Done()
+			if err := sink.Send(ctx, notif); err != nil {
+				n.logger.LogTag(ctx, WARNING, TagService, "通知渠道 %s 发送失败: %v", sink.Name(), err)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+				mu.Unlock()
+			}
+		}(route.sink)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// FlushDigest 到达汇总时刻时发送每日汇总
+func (n *Notifier) FlushDigest(ctx context.Context) error {
+	if n.policy == nil || !n.enabled {
+		return nil
+	}
+	digest, ok := n.policy.takeDigest(n.now())
+	if !ok {
+		return nil
+	}
+	return n.Dispatch(ctx, digest)
+}
+
+// RunDigest 每分钟检查一次是否需要发送每日汇总，直到 stop 关闭
+func (n *Notifier) RunDigest(stop <-chan struct{}) {
+	if n.policy == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_ = n.FlushDigest(context.Background())
+		}
+	}
+}
+
+// Send 发送通知
+func (n *Notifier) Send(notifyType NotificationType, title, message string) error {
+	return n.Dispatch(context.Background(), Notification{
+		Event:   NotifyEventInfo,
+		Type:    notifyType,
+		Title:   title,
+		Message: message,
+	})
 }
 
 // SendSuccess 发送成功通知
@@ -70,72 +344,6 @@ func (n *Notifier) SendWarning(title, message string) error {
 	return n.Send(NotifyWarning, title, message)
 }
 
-// sendToast 使用 PowerShell 发送 Windows Toast 通知
-func (n *Notifier) sendToast(notifyType NotificationType, title, message string) error {
-	// 根据类型选择图标
-	iconHint := ""
-	switch notifyType {
-	case NotifySuccess:
-		iconHint = "ms-winsoundevent:Notification.Default"
-	case NotifyError:
-		iconHint = "ms-winsoundevent:Notification.Looping.Alarm"
-	case NotifyWarning:
-		iconHint = "ms-winsoundevent:Notification.Looping.Alarm2"
-	default:
-		iconHint = "ms-winsoundevent:Notification.Default"
-	}
-
-	// 使用 BurntToast 模块（如果可用）或者回退到基础通知
-	script := fmt.Sprintf(`
-$ErrorActionPreference = 'SilentlyContinue'
-
-# 尝试使用 BurntToast 模块
-if (Get-Module -ListAvailable -Name BurntToast) {
-    Import-Module BurntToast
-    New-BurntToastNotification -Text '%s', '%s' -AppLogo $null
-} else {
-    # 回退到基础 Windows 通知
-    [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
-    [Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
-    
-    $template = @"
-<toast>
-    <visual>
-        <binding template="ToastText02">
-            <text id="1">%s</text>
-            <text id="2">%s</text>
-        </binding>
-    </visual>
-    <audio src="%s"/>
-</toast>
-"@
-    
-    $xml = New-Object Windows.Data.Xml.Dom.XmlDocument
-    $xml.LoadXml($template)
-    
-    $toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
-    $notifier = [Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s')
-    $notifier.Show($toast)
-}
-`,
-		escapeForPowerShell(title),
-		escapeForPowerShell(message),
-		escapeForPowerShell(title),
-		escapeForPowerShell(message),
-		iconHint,
-		escapeForPowerShell(n.appName),
-	)
-
-	cmd := exec.Command("powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// 通知失败不应影响主程序，只记录警告
-		return fmt.Errorf("发送通知失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
-	}
-
-	return nil
-}
-
 // escapeForPowerShell 转义 PowerShell 字符串中的特殊字符
 func escapeForPowerShell(s string) string {
 	s = strings.ReplaceAll(s, "'", "''")
@@ -144,25 +352,92 @@ func escapeForPowerShell(s string) string {
 	return s
 }
 
-// NotifyResumeResult 通知睡眠唤醒结果
-func (n *Notifier) NotifyResumeResult(fixed bool, skipped bool, deviceName string, err error) {
+// RepairReport 一次修复的结果，用于发送通知
+type RepairReport struct {
+	Device       string
+	StatusBefore string // 修复前状态（未检查时为空）
+	StatusAfter  string // 修复后状态（未验证时为空）
+	Attempts     int    // 第几次尝试
+	Fixed        bool
+	Skipped      bool
+	Err          error
+}
+
+// NotifyResumeResult 通知睡眠唤醒结果（context 中的事件 ID 随通知一起发送，便于对照日志）
+func (n *Notifier) NotifyResumeResult(ctx context.Context, fixed bool, skipped bool, deviceName string, err error) {
+	n.NotifyRepair(ctx, RepairReport{Device: deviceName, Fixed: fixed, Skipped: skipped, Err: err})
+}
+
+// NotifyRepair 通知修复结果，附带修复前后的设备状态和尝试次数
+func (n *Notifier) NotifyRepair(ctx context.Context, r RepairReport) {
 	if !n.enabled {
 		return
 	}
 
-	var title, message string
+	deviceName := r.Device
+	notif := Notification{
+		Device:       deviceName,
+		StatusBefore: r.StatusBefore,
+		StatusAfter:  r.StatusAfter,
+		Attempts:     r.Attempts,
+	}
 
-	if err != nil {
-		title = "触屏修复失败"
-		message = fmt.Sprintf("设备: %s\n错误: %v", deviceName, err)
-		_ = n.SendError(title, message)
-	} else if skipped {
-		title = "触屏状态正常"
-		message = fmt.Sprintf("设备 %s 状态正常，无需修复", deviceName)
-		_ = n.SendInfo(title, message)
-	} else if fixed {
-		title = "触屏已修复"
-		message = fmt.Sprintf("设备 %s 已成功修复", deviceName)
-		_ = n.SendSuccess(title, message)
+	if r.Err != nil {
+		notif.Event = NotifyEventFailure
+		notif.Type = NotifyError
+		notif.Title = T("notify.failure.title")
+		notif.Message = T("notify.failure.message", deviceName, r.Err)
+		notif.Error = r.Err.Error()
+	} else if r.Skipped {
+		notif.Event = NotifyEventSkip
+		notif.Type = NotifyInfo
+		notif.Title = T("notify.skip.title")
+		notif.Message = T("notify.skip.message", deviceName)
+	} else if r.Fixed {
+		notif.Event = NotifyEventSuccess
+		notif.Type = NotifySuccess
+		notif.Title = T("notify.success.title")
+		notif.Message = T("notify.success.message", deviceName)
+	} else {
+		return
 	}
+
+	_ = n.Dispatch(ctx, notif)
+}
+
+// NotifyQuarantine 通知设备状态频繁变化、已暂停自动修复；summary 为诊断快照摘要，snapshotPath 为快照文件（可为空）
+func (n *Notifier) NotifyQuarantine(ctx context.Context, deviceName, summary string, releaseAt time.Time, snapshotPath string) {
+	if !n.enabled {
+		return
+	}
+
+	message := T("notify.flap.message", deviceName, summary, releaseAt.Format("15:04"))
+	if snapshotPath != "" {
+		message += "\n" + T("notify.flap.snapshot", snapshotPath)
+	}
+	_ = n.Dispatch(ctx, Notification{
+		Event:    NotifyEventQuarantine,
+		Type:     NotifyWarning,
+		Title:    T("notify.flap.title"),
+		Message:  message,
+		Device:   deviceName,
+		Priority: PriorityHigh,
+	})
+}
+
+// NotifyGiveUp 通知已达到最大重试次数，停止自动修复
+func (n *Notifier) NotifyGiveUp(ctx context.Context, deviceName string, attempts int) {
+	if !n.enabled {
+		return
+	}
+
+	_ = n.Dispatch(ctx, Notification{
+		Event:    NotifyEventGiveUp,
+		Type:     NotifyError,
+		Title:    T("notify.give_up.title"),
+		Message:  T("notify.give_up.message", deviceName, attempts),
+		Device:   deviceName,
+		Attempts: attempts,
+		Priority: PriorityHigh,
+	})
 }
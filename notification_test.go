@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"testing"
 )
 
@@ -104,9 +105,9 @@ func TestNotifier_NotifyResumeResult_Disabled(t *testing.T) {
 	n := NewNotifier(false)
 
 	// 禁用时不应该有任何错误（不执行任何操作）
-	n.NotifyResumeResult(true, false, "TestDevice", nil)
-	n.NotifyResumeResult(false, true, "TestDevice", nil)
-	n.NotifyResumeResult(false, false, "TestDevice", nil)
+	n.NotifyResumeResult(context.Background(), true, false, "TestDevice", nil)
+	n.NotifyResumeResult(context.Background(), false, true, "TestDevice", nil)
+	n.NotifyResumeResult(context.Background(), false, false, "TestDevice", nil)
 }
 
 func TestEscapeForPowerShell(t *testing.T) {
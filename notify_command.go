@@ -0,0 +1,64 @@
+// Package main provides a notification sink that runs a user command with event details in the environment.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommandSink 运行用户指定的命令，通知内容通过环境变量传入
+type CommandSink struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+}
+
+// Name 渠道名称
+func (c *CommandSink) Name() string {
+	return "command"
+}
+
+// Send 运行命令，非零退出码视为失败
+func (c *CommandSink) Send(ctx context.Context, n Notification) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, c.Command, c.Args...)
+	cmd.Env = append(os.Environ(), notificationEnv(n)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		out := strings.TrimSpace(string(output))
+		if len(out) > 200 {
+			out = out[:200] + "..."
+		}
+		if out != "" {
+			return fmt.Errorf("命令执行失败: %w，输出: %s", err, out)
+		}
+		return fmt.Errorf("命令执行失败: %w", err)
+	}
+	return nil
+}
+
+// notificationEnv 通知内容对应的环境变量
+func notificationEnv(n Notification) []string {
+	return []string{
+		"GPD_EVENT=" + string(n.Event),
+		"GPD_LEVEL=" + n.Level,
+		"GPD_PRIORITY=" + n.Priority,
+		"GPD_TITLE=" + n.Title,
+		"GPD_MESSAGE=" + n.Message,
+		"GPD_DEVICE=" + n.Device,
+		"GPD_EPISODE=" + n.Episode,
+		"GPD_ERROR=" + n.Error,
+		"GPD_HOSTNAME=" + n.Hostname,
+		"GPD_TIME=" + n.Time.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,157 @@
+// Package main provides configuration for notification sinks.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NotificationSinkConfig 一个通知渠道的配置
+type NotificationSinkConfig struct {
+	Type   string   `json:"type"`             // toast、webhook、smtp、command、file
+	Events []string `json:"events,omitempty"` // 订阅的事件：success、skip、failure、give_up、info、digest（为空表示全部）
+
+	// webhook
+	URL             string            `json:"url,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Secret          string            `json:"secret,omitempty"`            // HMAC-SHA256 签名密钥（X-GPD-Signature 请求头）
+	QueueDir        string            `json:"queue_dir,omitempty"`         // 离线队列目录（默认程序目录下的 notify-queue）
+	QueueMax        int               `json:"queue_max,omitempty"`         // 离线队列最多保留的条数（默认 500）
+	RetryMaxSeconds int               `json:"retry_max_seconds,omitempty"` // 补发退避间隔上限（默认 600 秒）
+
+	// smtp
+	SMTPHost string   `json:"smtp_host,omitempty"`
+	SMTPPort int      `json:"smtp_port,omitempty"` // 默认 25
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+
+	// command
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+
+	// file
+	Path string `json:"path,omitempty"` // JSONL 文件路径
+
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"` // 单次发送超时（默认 10 秒，command 默认 30 秒）
+}
+
+// timeout 返回发送超时
+func (c *NotificationSinkConfig) timeout(def time.Duration) time.Duration {
+	if c.TimeoutSeconds > 0 {
+		return time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return def
+}
+
+// ParseEvents 解析订阅的事件
+func (c *NotificationSinkConfig) ParseEvents() ([]NotificationEvent, error) {
+	return parseNotificationEvents(c.Events)
+}
+
+// parseNotificationEvents 解析事件名称（不区分大小写）
+func parseNotificationEvents(names []string) ([]NotificationEvent, error) {
+	events := make([]NotificationEvent, 0, len(names))
+	for _, name := range names {
+		event := NotificationEvent(strings.ToLower(strings.TrimSpace(name)))
+		known := false
+		for _, e := range allNotificationEvents {
+			if e == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("未知的通知事件: %q（可选 success、skip、failure、give_up、quarantine、info、digest）", name)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Validate 校验渠道配置
+func (c *NotificationSinkConfig) Validate() error {
+	if _, err := c.ParseEvents(); err != nil {
+		return err
+	}
+	if c.TimeoutSeconds < 0 || c.QueueMax < 0 || c.RetryMaxSeconds < 0 {
+		return fmt.Errorf("timeout_seconds、queue_max、retry_max_seconds 必须为非负数")
+	}
+
+	switch c.Type {
+	case "toast":
+	case "webhook":
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return fmt.Errorf("webhook 需要 http:// 或 https:// 开头的 url")
+		}
+	case "smtp":
+		if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+			return fmt.Errorf("smtp 需要 smtp_host、from 和 to")
+		}
+	case "command":
+		if c.Command == "" {
+			return fmt.Errorf("command 不能为空")
+		}
+	case "file":
+		if c.Path == "" {
+			return fmt.Errorf("file 需要 path")
+		}
+	default:
+		return fmt.Errorf("不支持的通知渠道类型: %q（可选 toast、webhook、smtp、command、file）", c.Type)
+	}
+	return nil
+}
+
+// NewSink 根据配置创建通知渠道
+func (c *NotificationSinkConfig) NewSink(appName string) (NotificationSink, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch c.Type {
+	case "webhook":
+		queueDir := c.QueueDir
+		if queueDir == "" {
+			// 每个地址使用独立的队列目录，修改 url 后不会把旧通知发到新地址
+			sum := sha256.Sum256([]byte(c.URL))
+			queueDir = filepath.Join(GetStatsDir(), "notify-queue", "webhook-"+hex.EncodeToString(sum[:4]))
+		}
+		queueMax := c.QueueMax
+		if queueMax == 0 {
+			queueMax = 500
+		}
+		return NewWebhookSink(WebhookOptions{
+			URL:      c.URL,
+			Headers:  c.Headers,
+			Secret:   c.Secret,
+			Timeout:  c.timeout(10 * time.Second),
+			QueueDir: queueDir,
+			MaxQueue: queueMax,
+			RetryMax: time.Duration(c.RetryMaxSeconds) * time.Second,
+		})
+	case "smtp":
+		port := c.SMTPPort
+		if port == 0 {
+			port = 25
+		}
+		return &SMTPSink{
+			Addr:     fmt.Sprintf("%s:%d", c.SMTPHost, port),
+			Username: c.Username,
+			Password: c.Password,
+			From:     c.From,
+			To:       c.To,
+			Timeout:  c.timeout(10 * time.Second),
+		}, nil
+	case "command":
+		return &CommandSink{Command: c.Command, Args: c.Args, Timeout: c.timeout(30 * time.Second)}, nil
+	case "file":
+		return NewFileSink(c.Path), nil
+	default:
+		return NewToastSink(appName), nil
+	}
+}
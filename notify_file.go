@@ -0,0 +1,52 @@
+// Package main provides a notification sink that appends JSON lines to a file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink 以 JSONL 格式追加通知到文件
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink 创建文件通知渠道
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Name 渠道名称
+func (f *FileSink) Name() string {
+	return "file"
+}
+
+// Send 追加一行 JSON
+func (f *FileSink) Send(_ context.Context, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %w", err)
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("创建通知文件目录失败: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开通知文件失败: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("写入通知文件失败: %w", err)
+	}
+	return file.Close()
+}
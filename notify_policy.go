@@ -0,0 +1,304 @@
+// Package main provides notification throttling, deduplication, quiet hours and daily digests.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotificationPolicyConfig 通知发送策略配置
+type NotificationPolicyConfig struct {
+	RateLimits         map[string]RateLimitConfig `json:"rate_limits,omitempty"`          // 按事件限流，如 {"skip": {"max": 1, "window_minutes": 60}}
+	DedupWindowMinutes int                        `json:"dedup_window_minutes,omitempty"` // 相同内容的通知在窗口内只发送一次（0=不去重）
+	QuietHours         *QuietHoursConfig          `json:"quiet_hours,omitempty"`          // 静默时段
+	Digest             *DigestConfig              `json:"digest,omitempty"`               // 每日汇总
+}
+
+// RateLimitConfig 单个事件的限流：window_minutes 分钟内最多发送 max 条
+type RateLimitConfig struct {
+	Max           int `json:"max"`
+	WindowMinutes int `json:"window_minutes"`
+}
+
+// QuietHoursConfig 静默时段，期间只发送 allow_events 中的事件（默认只发送失败和停止修复）
+type QuietHoursConfig struct {
+	Start       string   `json:"start"`                  // HH:MM
+	End         string   `json:"end"`                    // HH:MM，早于 start 表示跨午夜
+	AllowEvents []string `json:"allow_events,omitempty"` // 默认 ["failure", "give_up", "quarantine"]
+}
+
+// DigestConfig 每日汇总：events 中的事件不单独发送，每天 time 时刻汇总成一条通知
+// 注意：限流窗口、去重记录和汇总计数只保存在内存中，服务重启后清零（重启前未发送的汇总计数会丢失，
+// 重启后限流和去重重新计算）
+type DigestConfig struct {
+	Events []string `json:"events,omitempty"` // 默认 ["skip", "success"]
+	Time   string   `json:"time,omitempty"`   // HH:MM，默认 09:00
+}
+
+// Validate 校验通知策略配置
+func (c *NotificationPolicyConfig) Validate() error {
+	_, err := c.build()
+	return err
+}
+
+// build 解析配置
+func (c *NotificationPolicyConfig) build() (*NotificationPolicy, error) {
+	p := &NotificationPolicy{
+		rateLimits: make(map[NotificationEvent]RateLimitConfig),
+		sent:       make(map[NotificationEvent][]time.Time),
+		lastSeen:   make(map[string]time.Time),
+		dedup:      time.Duration(c.DedupWindowMinutes) * time.Minute,
+	}
+	if c.DedupWindowMinutes < 0 {
+		return nil, fmt.Errorf("dedup_window_minutes 必须为非负数")
+	}
+
+	for name, limit := range c.RateLimits {
+		events, err := parseNotificationEvents([]string{name})
+		if err != nil {
+			return nil, fmt.Errorf("rate_limits: %w", err)
+		}
+		if limit.Max <= 0 || limit.WindowMinutes <= 0 {
+			return nil, fmt.Errorf("rate_limits.%s: max 和 window_minutes 必须为正数", name)
+		}
+		p.rateLimits[events[0]] = limit
+	}
+
+	if q := c.QuietHours; q != nil {
+		start, err := parseClock(q.Start)
+		if err != nil {
+			return nil, fmt.Errorf("quiet_hours.start: %w", err)
+		}
+		end, err := parseClock(q.End)
+		if err != nil {
+			return nil, fmt.Errorf("quiet_hours.end: %w", err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("quiet_hours 的 start 和 end 不能相同")
+		}
+		allow := q.AllowEvents
+		if len(allow) == 0 {
+			allow = []string{string(NotifyEventFailure), string(NotifyEventGiveUp), string(NotifyEventQuarantine)}
+		}
+		events, err := parseNotificationEvents(allow)
+		if err != nil {
+			return nil, fmt.Errorf("quiet_hours.allow_events: %w", err)
+		}
+		p.quiet = &quietHours{start: start, end: end, allow: eventSet(events)}
+	}
+
+	if d := c.Digest; d != nil {
+		names := d.Events
+		if len(names) == 0 {
+			names = []string{string(NotifyEventSkip), string(NotifyEventSuccess)}
+		}
+		events, err := parseNotificationEvents(names)
+		if err != nil {
+			return nil, fmt.Errorf("digest.events: %w", err)
+		}
+		at := 9 * time.Hour
+		if d.Time != "" {
+			if at, err = parseClock(d.Time); err != nil {
+				return nil, fmt.Errorf("digest.time: %w", err)
+			}
+		}
+		p.digestEvents = eventSet(events)
+		p.digestAt = at
+		p.digestCounts = make(map[NotificationEvent]int)
+	}
+
+	return p, nil
+}
+
+// parseClock 解析 HH:MM，返回距午夜的时长
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// sinceMidnight 返回当天已过去的时长
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// eventSet 事件列表转为集合
+func eventSet(events []NotificationEvent) map[NotificationEvent]bool {
+	set := make(map[NotificationEvent]bool, len(events))
+	for _, e := range events {
+		set[e] = true
+	}
+	return set
+}
+
+// quietHours 静默时段
+type quietHours struct {
+	start, end time.Duration
+	allow      map[NotificationEvent]bool
+}
+
+// contains 判断时刻是否处于静默时段
+func (q *quietHours) contains(t time.Time) bool {
+	now := sinceMidnight(t)
+	if q.start < q.end {
+		return now >= q.start && now < q.end
+	}
+	return now >= q.start || now < q.end // 跨午夜
+}
+
+// policyDecision 策略对一条通知的处理结果
+type policyDecision int
+
+const (
+	policySend       policyDecision = iota // 立即发送
+	policyDigest                           // 计入每日汇总
+	policySuppressed                       // 被抑制
+)
+
+// NotificationPolicy 通知发送策略：限流、去重、静默时段和每日汇总
+// 状态只保存在内存中，不随轮询器状态持久化（见 DigestConfig）
+type NotificationPolicy struct {
+	mu sync.Mutex
+
+	rateLimits map[NotificationEvent]RateLimitConfig
+	sent       map[NotificationEvent][]time.Time // 限流窗口内已发送的时间
+
+	dedup    time.Duration
+	lastSeen map[string]time.Time // 内容 -> 上次发送时间
+
+	quiet *quietHours
+
+	digestEvents map[NotificationEvent]bool
+	digestAt     time.Duration
+	digestCounts map[NotificationEvent]int // 本期汇总的事件计数
+	digestFirst  time.Time                 // 本期汇总的第一条事件时间
+	digestLast   time.Time                 // 上次发送汇总的时间
+	suppressed   int                       // 本期被限流、去重或静默抑制的通知数
+}
+
+// NewNotificationPolicy 根据配置创建通知策略
+func NewNotificationPolicy(cfg NotificationPolicyConfig) (*NotificationPolicy, error) {
+	return cfg.build()
+}
+
+// admit 判断通知如何处理，返回处理结果和抑制原因
+// 顺序：汇总事件 → 静默时段（高优先级通知不受限制）→ 去重 → 限流；只有真正发送的通知才计入去重和限流
+func (p *NotificationPolicy) admit(n Notification, now time.Time) (policyDecision, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.digestEvents[n.Event] {
+		if len(p.digestCounts) == 0 {
+			p.digestFirst = now
+		}
+		p.digestCounts[n.Event]++
+		return policyDigest, ""
+	}
+
+	if p.quiet != nil && p.quiet.contains(now) && !p.quiet.allow[n.Event] && n.Priority != PriorityHigh {
+		p.suppressed++
+		return policySuppressed, "静默时段"
+	}
+
+	key := string(n.Event) + "\x00" + n.Device + "\x00" + n.Title + "\x00" + n.Message
+	if p.dedup > 0 {
+		if last, ok := p.lastSeen[key]; ok && now.Sub(last) < p.dedup {
+			p.suppressed++
+			return policySuppressed, fmt.Sprintf("%s 内已发送过相同通知", p.dedup)
+		}
+	}
+
+	if limit, ok := p.rateLimits[n.Event]; ok {
+		window := time.Duration(limit.WindowMinutes) * time.Minute
+		kept := p.sent[n.Event][:0]
+		for _, t := range p.sent[n.Event] {
+			if now.Sub(t) < window {
+				kept = append(kept, t)
+			}
+		}
+		p.sent[n.Event] = kept
+		if len(kept) >= limit.Max {
+			p.suppressed++
+			return policySuppressed, fmt.Sprintf("%s 事件 %s 内已发送 %d 条", n.Event, window, len(kept))
+		}
+		p.sent[n.Event] = append(kept, now)
+	}
+
+	if p.dedup > 0 {
+		p.lastSeen[key] = now
+		for k, t := range p.lastSeen {
+			if now.Sub(t) >= p.dedup {
+				delete(p.lastSeen, k)
+			}
+		}
+	}
+	return policySend, ""
+}
+
+// takeDigest 到达汇总时刻时取出本期汇总；没有到时或没有内容时返回 false
+func (p *NotificationPolicy) takeDigest(now time.Time) (Notification, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.digestEvents == nil {
+		return Notification{}, false
+	}
+
+	// 今天的汇总时刻；当前还没到就看昨天的
+	due := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(p.digestAt)
+	if now.Before(due) {
+		due = due.AddDate(0, 0, -1)
+	}
+	if !p.digestLast.Before(due) {
+		return Notification{}, false
+	}
+	if p.digestLast.IsZero() {
+		// 服务刚启动：从下一个汇总时刻开始计算，避免启动后立刻发送
+		p.digestLast = due
+		return Notification{}, false
+	}
+	p.digestLast = now
+
+	if len(p.digestCounts) == 0 && p.suppressed == 0 {
+		return Notification{}, false
+	}
+
+	var lines []string
+	var parts []string
+	for _, e := range allNotificationEvents {
+		if c := p.digestCounts[e]; c > 0 {
+			parts = append(parts, T("notify.digest.count", notificationEventLabel(e), c))
+		}
+	}
+	if len(parts) > 0 {
+		lines = append(lines, T("notify.digest.since", p.digestFirst.Format("01-02 15:04"), strings.Join(parts, T("notify.digest.separator"))))
+	}
+	if p.suppressed > 0 {
+		lines = append(lines, T("notify.digest.suppressed", p.suppressed))
+	}
+
+	p.digestCounts = make(map[NotificationEvent]int)
+	p.digestFirst = time.Time{}
+	p.suppressed = 0
+
+	return Notification{
+		Event:   NotifyEventDigest,
+		Type:    NotifyInfo,
+		Title:   T("notify.digest.title"),
+		Message: strings.Join(lines, "\n"),
+	}, true
+}
+
+// notificationEventLabel 事件在汇总中的显示名称
+func notificationEventLabel(e NotificationEvent) string {
+	switch e {
+	case NotifyEventSuccess, NotifyEventSkip, NotifyEventFailure, NotifyEventGiveUp, NotifyEventQuarantine:
+		return T("event." + string(e))
+	default:
+		return T("event.other")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// policyAt 返回 2025-12-24 的指定时刻
+func policyAt(hour, minute int) time.Time {
+	return time.Date(2025, 12, 24, hour, minute, 0, 0, time.Local)
+}
+
+func mustPolicy(t *testing.T, cfg NotificationPolicyConfig) *NotificationPolicy {
+	t.Helper()
+	p, err := NewNotificationPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewNotificationPolicy() error = %v", err)
+	}
+	return p
+}
+
+func TestNotificationPolicy_QuietHours(t *testing.T) {
+	p := mustPolicy(t, NotificationPolicyConfig{QuietHours: &QuietHoursConfig{Start: "22:00", End: "08:00"}})
+
+	tests := []struct {
+		name  string
+		event NotificationEvent
+		at    time.Time
+		want  policyDecision
+	}{
+		{"白天的成功通知", NotifyEventSuccess, policyAt(12, 0), policySend},
+		{"深夜的成功通知", NotifyEventSuccess, policyAt(23, 30), policySuppressed},
+		{"凌晨的状态正常", NotifyEventSkip, policyAt(7, 59), policySuppressed},
+		{"结束时刻", NotifyEventSkip, policyAt(8, 0), policySend},
+		{"深夜的失败通知", NotifyEventFailure, policyAt(2, 0), policySend},
+		{"深夜的停止修复", NotifyEventGiveUp, policyAt(3, 0), policySend},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := p.admit(Notification{Event: tt.event, Title: tt.name}, tt.at); got != tt.want {
+				t.Errorf("admit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 高优先级通知不受静默时段限制
+	if got, _ := p.admit(Notification{Event: NotifyEventInfo, Title: "高优先级", Priority: PriorityHigh}, policyAt(23, 30)); got != policySend {
+		t.Errorf("高优先级通知 admit() = %v, want %v", got, policySend)
+	}
+}
+
+func TestNotificationPolicy_Dedup(t *testing.T) {
+	p := mustPolicy(t, NotificationPolicyConfig{DedupWindowMinutes: 10})
+	n := Notification{Event: NotifyEventSkip, Title: "触屏状态正常", Message: "设备 触摸屏 状态正常，无需修复"}
+
+	steps := []struct {
+		at   time.Time
+		n    Notification
+		want policyDecision
+	}{
+		{policyAt(9, 0), n, policySend},
+		{policyAt(9, 5), n, policySuppressed},
+		{policyAt(9, 6), Notification{Event: NotifyEventSkip, Title: "触屏状态正常", Message: "设备 另一个 状态正常"}, policySend},
+		{policyAt(9, 10), n, policySend}, // 窗口从上次发送算起
+	}
+	for i, s := range steps {
+		if got, _ := p.admit(s.n, s.at); got != s.want {
+			t.Errorf("第 %d 步 admit() = %v, want %v", i+1, got, s.want)
+		}
+	}
+}
+
+func TestNotificationPolicy_RateLimit(t *testing.T) {
+	p := mustPolicy(t, NotificationPolicyConfig{
+		RateLimits: map[string]RateLimitConfig{"failure": {Max: 2, WindowMinutes: 60}},
+	})
+
+	var got []policyDecision
+	for _, at := range []time.Time{policyAt(9, 0), policyAt(9, 10), policyAt(9, 20), policyAt(10, 1)} {
+		d, _ := p.admit(Notification{Event: NotifyEventFailure, Title: at.String()}, at)
+		got = append(got, d)
+	}
+	want := []policyDecision{policySend, policySend, policySuppressed, policySend}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 条 = %v, want %v", i+1, got[i], want[i])
+		}
+	}
+
+	// 未配置限流的事件不受影响
+	for i := 0; i < 5; i++ {
+		if d, _ := p.admit(Notification{Event: NotifyEventSuccess}, policyAt(9, 30)); d != policySend {
+			t.Fatalf("success 事件不应被限流")
+		}
+	}
+}
+
+func TestNotifier_Digest(t *testing.T) {
+	now := policyAt(9, 30)
+	n := newTestNotifier()
+	n.now = func() time.Time { return now }
+	sink := &fakeSink{name: "fake"}
+	n.AddSink(sink)
+	n.SetPolicy(mustPolicy(t, NotificationPolicyConfig{
+		Digest:     &DigestConfig{Time: "09:00"},
+		QuietHours: &QuietHoursConfig{Start: "22:00", End: "08:00"},
+	}))
+	ctx := context.Background()
+
+	// 服务启动时不发送汇总
+	if err := n.FlushDigest(ctx); err != nil || len(sink.events()) != 0 {
+		t.Fatalf("启动时不应发送汇总, events = %v", sink.events())
+	}
+
+	// 一天中的事件：成功和跳过计入汇总，失败立即发送，深夜的 info 被静默
+	n.NotifyResumeResult(ctx, true, false, "触摸屏", nil)
+	n.NotifyResumeResult(ctx, false, true, "触摸屏", nil)
+	now = policyAt(12, 0)
+	n.NotifyResumeResult(ctx, false, true, "触摸屏", nil)
+	n.NotifyResumeResult(ctx, false, false, "触摸屏", context.DeadlineExceeded)
+	now = policyAt(23, 0)
+	_ = n.SendInfo("测试", "深夜")
+
+	if got := sink.events(); len(got) != 1 || got[0] != NotifyEventFailure {
+		t.Fatalf("汇总前只应发送失败通知, got %v", got)
+	}
+
+	// 次日 09:00 之前不发送
+	now = policyAt(8, 59).AddDate(0, 0, 1)
+	_ = n.FlushDigest(ctx)
+	if len(sink.events()) != 1 {
+		t.Fatal("未到汇总时刻不应发送")
+	}
+
+	now = policyAt(9, 0).AddDate(0, 0, 1)
+	_ = n.FlushDigest(ctx)
+	events := sink.events()
+	if len(events) != 2 || events[1] != NotifyEventDigest {
+		t.Fatalf("应发送一条汇总, got %v", events)
+	}
+	msg := sink.got[1].Message
+	for _, want := range []string{"修复成功 1 次", "状态正常 2 次", "另有 1 条通知"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("汇总内容缺少 %q: %s", want, msg)
+		}
+	}
+
+	// 同一天不重复发送；没有新事件时第二天也不发送
+	now = now.Add(time.Hour)
+	_ = n.FlushDigest(ctx)
+	now = now.AddDate(0, 0, 1)
+	_ = n.FlushDigest(ctx)
+	if len(sink.events()) != 2 {
+		t.Errorf("不应重复发送汇总, got %v", sink.events())
+	}
+}
+
+func TestNotificationPolicyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     NotificationPolicyConfig
+		wantErr bool
+	}{
+		{"空配置", NotificationPolicyConfig{}, false},
+		{"完整配置", NotificationPolicyConfig{
+			RateLimits:         map[string]RateLimitConfig{"skip": {Max: 1, WindowMinutes: 60}},
+			DedupWindowMinutes: 10,
+			QuietHours:         &QuietHoursConfig{Start: "22:00", End: "08:00", AllowEvents: []string{"give_up"}},
+			Digest:             &DigestConfig{Events: []string{"skip"}, Time: "20:30"},
+		}, false},
+		{"限流未知事件", NotificationPolicyConfig{RateLimits: map[string]RateLimitConfig{"crash": {Max: 1, WindowMinutes: 1}}}, true},
+		{"限流次数为 0", NotificationPolicyConfig{RateLimits: map[string]RateLimitConfig{"skip": {WindowMinutes: 1}}}, true},
+		{"去重窗口为负", NotificationPolicyConfig{DedupWindowMinutes: -1}, true},
+		{"静默时间格式错误", NotificationPolicyConfig{QuietHours: &QuietHoursConfig{Start: "10pm", End: "08:00"}}, true},
+		{"静默起止相同", NotificationPolicyConfig{QuietHours: &QuietHoursConfig{Start: "08:00", End: "08:00"}}, true},
+		{"汇总时间错误", NotificationPolicyConfig{Digest: &DigestConfig{Time: "25:00"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package main provides a persistent on-disk queue for notifications that could not be delivered.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queuedNotification 离线队列中的一条通知
+type queuedNotification struct {
+	ID           string       `json:"id"` // 投递 ID，重试时保持不变，接收方可据此去重
+	QueuedAt     time.Time    `json:"queued_at"`
+	Notification Notification `json:"notification"`
+}
+
+// NotificationQueue 以目录保存的先进先出队列，每条通知一个文件
+// 文件名以入队时间开头，按名称排序即为入队顺序；服务重启后队列中的通知仍然保留
+type NotificationQueue struct {
+	dir      string
+	maxItems int
+	mu       sync.Mutex
+	seq      uint64
+}
+
+// NewNotificationQueue 创建（或打开已有的）离线队列，maxItems 为最多保留的条数（0 表示不限制）
+func NewNotificationQueue(dir string, maxItems int) (*NotificationQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建通知队列目录失败: %w", err)
+	}
+	return &NotificationQueue{dir: dir, maxItems: maxItems}, nil
+}
+
+// Push 加入队列，超出上限时丢弃最早的通知，返回丢弃的条数
+func (q *NotificationQueue) Push(item queuedNotification) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return 0, fmt.Errorf("序列化通知失败: %w", err)
+	}
+
+	q.seq++
+	name := fmt.Sprintf("%020d-%04d-%s.json", item.QueuedAt.UnixNano(), q.seq%10000, item.ID)
+	path := filepath.Join(q.dir, name)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return 0, fmt.Errorf("写入通知队列失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, fmt.Errorf("写入通知队列失败: %w", err)
+	}
+
+	if q.maxItems <= 0 {
+		return 0, nil
+	}
+	names, err := q.listLocked()
+	if err != nil {
+		return 0, err
+	}
+	dropped := 0
+	for len(names)-dropped > q.maxItems {
+		_ = os.Remove(filepath.Join(q.dir, names[dropped]))
+		dropped++
+	}
+	return dropped, nil
+}
+
+// List 按入队顺序返回队列中的文件名
+func (q *NotificationQueue) List() ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.listLocked()
+}
+
+// listLocked 列出队列文件（调用方需持有 mu）
+func (q *NotificationQueue) listLocked() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取通知队列失败: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Len 返回队列中的通知数
+func (q *NotificationQueue) Len() int {
+	names, _ := q.List()
+	return len(names)
+}
+
+// Load 读取队列中的一条通知；文件损坏时改名为 .bad 移出队列并返回错误
+// 文件已被 Push 按上限丢弃时返回的错误满足 errors.Is(err, fs.ErrNotExist)
+func (q *NotificationQueue) Load(name string) (queuedNotification, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var item queuedNotification
+	path := filepath.Join(q.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return item, fmt.Errorf("读取队列中的通知失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		_ = os.Rename(path, path+".bad")
+		return item, fmt.Errorf("队列中的通知 %s 已损坏: %w", name, err)
+	}
+	return item, nil
+}
+
+// Remove 从队列中移除一条通知
+func (q *NotificationQueue) Remove(name string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := os.Remove(filepath.Join(q.dir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("移除队列中的通知失败: %w", err)
+	}
+	return nil
+}
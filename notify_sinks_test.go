@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSink 记录收到的通知
+type fakeSink struct {
+	name string
+	err  error
+	mu   sync.Mutex
+	got  []Notification
+}
+
+func (f *fakeSink) Name() string { return f.name }
+
+func (f *fakeSink) Send(_ context.Context, n Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.got = append(f.got, n)
+	return f.err
+}
+
+func (f *fakeSink) events() []NotificationEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var events []NotificationEvent
+	for _, n := range f.got {
+		events = append(events, n.Event)
+	}
+	return events
+}
+
+// newTestNotifier 创建没有默认 Toast 渠道的通知管理器
+func newTestNotifier() *Notifier {
+	n := NewNotifier(true)
+	n.routes = nil
+	n.logger = ConsoleLogger{MinLevel: ERROR}
+	n.now = func() time.Time { return time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC) }
+	return n
+}
+
+func TestNotifier_DispatchRouting(t *testing.T) {
+	n := newTestNotifier()
+	all := &fakeSink{name: "all"}
+	failures := &fakeSink{name: "failures"}
+	n.AddSink(all)
+	n.AddSink(failures, NotifyEventFailure, NotifyEventGiveUp, NotifyEventQuarantine)
+
+	ctx := WithEpisode(context.Background(), "20251224-083000-abcd")
+	n.NotifyResumeResult(ctx, true, false, "触摸屏", nil)
+	n.NotifyResumeResult(ctx, false, true, "触摸屏", nil)
+	n.NotifyResumeResult(ctx, false, false, "触摸屏", errors.New("设备状态: Error"))
+	n.NotifyGiveUp(ctx, "触摸屏", 10)
+	n.NotifyQuarantine(ctx, "触摸屏", "300 秒内切换 6 次", time.Date(2025, 12, 24, 8, 45, 0, 0, time.UTC), "diagnostics/flap-20251224-083000.json")
+
+	want := []NotificationEvent{NotifyEventSuccess, NotifyEventSkip, NotifyEventFailure, NotifyEventGiveUp, NotifyEventQuarantine}
+	if got := all.events(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("全部事件渠道收到 %v, want %v", got, want)
+	}
+	want = []NotificationEvent{NotifyEventFailure, NotifyEventGiveUp, NotifyEventQuarantine}
+	if got := failures.events(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("失败事件渠道收到 %v, want %v", got, want)
+	}
+	if q := failures.got[2]; q.Priority != PriorityHigh || !strings.Contains(q.Message, "flap-20251224-083000.json") {
+		t.Errorf("隔离通知 = %+v, want 高优先级并包含诊断快照路径", q)
+	}
+
+	first := all.got[0]
+	if first.Episode != "20251224-083000-abcd" || first.Level != "success" || first.Device != "触摸屏" || first.Time.IsZero() {
+		t.Errorf("通知字段未填充: %+v", first)
+	}
+	if failures.got[0].Error != "设备状态: Error" {
+		t.Errorf("Error = %q", failures.got[0].Error)
+	}
+}
+
+func TestNotifier_DispatchErrors(t *testing.T) {
+	n := newTestNotifier()
+	ok := &fakeSink{name: "ok"}
+	bad := &fakeSink{name: "bad", err: errors.New("连接被拒绝")}
+	n.AddSink(bad)
+	n.AddSink(ok)
+
+	err := n.Dispatch(context.Background(), Notification{Event: NotifyEventInfo, Title: "测试"})
+	if err == nil || !strings.Contains(err.Error(), "bad: 连接被拒绝") {
+		t.Errorf("Dispatch() error = %v, want 包含 bad 渠道的错误", err)
+	}
+	if len(ok.events()) != 1 {
+		t.Error("单个渠道失败不应影响其他渠道")
+	}
+
+	n.SetEnabled(false)
+	if err := n.Dispatch(context.Background(), Notification{Event: NotifyEventInfo}); err != nil {
+		t.Errorf("禁用后 Dispatch() = %v, want nil", err)
+	}
+	if len(ok.events()) != 1 {
+		t.Error("禁用后不应发送")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var (
+		got    Notification
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got.Event == NotifyEventFailure {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := newWebhookSink(WebhookOptions{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := Notification{Event: NotifyEventSuccess, Level: "success", Title: "触屏已修复", Episode: "ep-1"}
+	if err := sink.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Title != "触屏已修复" || got.Episode != "ep-1" {
+		t.Errorf("收到 %+v", got)
+	}
+	if header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("请求头 = %v", header)
+	}
+
+	n.Event = NotifyEventFailure
+	if err := sink.Send(context.Background(), n); err == nil {
+		t.Error("非 2xx 响应应返回错误")
+	}
+}
+
+// fakeSMTPServer 最小的 SMTP 服务器，记录收到的邮件
+func fakeSMTPServer(t *testing.T) (addr string, messages <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { fmt.Fprintf(conn, "%s\r\n", s) }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				ch <- data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTPSink(t *testing.T) {
+	addr, messages := fakeSMTPServer(t)
+
+	sink := &SMTPSink{Addr: addr, From: "gpd@example.com", To: []string{"me@example.com"}, Timeout: 5 * time.Second}
+	n := Notification{
+		Event:    NotifyEventGiveUp,
+		Title:    "触屏自动修复已停止",
+		Message:  "连续修复失败 10 次",
+		Device:   "触摸屏",
+		Episode:  "ep-1",
+		Priority: PriorityHigh,
+		Time:     time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC),
+	}
+	if err := sink.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case msg := <-messages:
+		for _, want := range []string{"To: me@example.com", "Subject: =?UTF-8?b?", "连续修复失败 10 次", "事件: give_up", "事件 ID: ep-1", "X-Priority: 1"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("邮件内容缺少 %q:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP 服务器未收到邮件")
+	}
+}
+
+// stalledSMTPServer 接受连接后不发送问候的服务器；连接被对方关闭时关闭返回的通道
+func stalledSMTPServer(t *testing.T) (addr string, closed <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+		close(ch)
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTPSink_StalledServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  time.Duration // 大于 0 时在该时间后取消 ctx
+		want    string
+	}{
+		{"超时", 100 * time.Millisecond, 0, "超时"},
+		{"取消", time.Minute, 100 * time.Millisecond, "已取消"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, closed := stalledSMTPServer(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+
+			sink := &SMTPSink{Addr: addr, From: "gpd@example.com", To: []string{"me@example.com"}, Timeout: tt.timeout}
+			start := time.Now()
+			err := sink.Send(ctx, Notification{Event: NotifyEventFailure, Title: "t", Time: start})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Send() error = %v, want 包含 %q", err, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Send() 用时 %s，未按时返回", elapsed)
+			}
+			// 发送返回时连接已关闭，不会留下后台发送
+			select {
+			case <-closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("发送返回后连接仍未关闭")
+			}
+		})
+	}
+}
+
+// TestCommandSinkHelper 被 CommandSink 测试作为外部命令运行，将 GPD_* 环境变量写入文件
+func TestCommandSinkHelper(t *testing.T) {
+	out := os.Getenv("GPD_TEST_HELPER_OUT")
+	if out == "" {
+		t.Skip("仅作为 CommandSink 测试的辅助进程运行")
+	}
+	var lines []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GPD_") && !strings.HasPrefix(kv, "GPD_TEST_") {
+			lines = append(lines, kv)
+		}
+	}
+	if err := os.WriteFile(out, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("GPD_EVENT") == string(NotifyEventFailure) {
+		fmt.Fprint(os.Stderr, "模拟失败")
+		os.Exit(3)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "env.txt")
+	t.Setenv("GPD_TEST_HELPER_OUT", out)
+
+	sink := &CommandSink{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestCommandSinkHelper$"},
+		Timeout: 30 * time.Second,
+	}
+	n := Notification{Event: NotifyEventSuccess, Level: "success", Title: "触屏已修复", Device: "触摸屏", Episode: "ep-1"}
+	if err := sink.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"GPD_EVENT=success", "GPD_TITLE=触屏已修复", "GPD_DEVICE=触摸屏", "GPD_EPISODE=ep-1"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("环境变量缺少 %q:\n%s", want, data)
+		}
+	}
+
+	n.Event = NotifyEventFailure
+	if err := sink.Send(context.Background(), n); err == nil || !strings.Contains(err.Error(), "模拟失败") {
+		t.Errorf("非零退出码应返回包含输出的错误, got %v", err)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "notifications.jsonl")
+	sink := NewFileSink(path)
+
+	for _, e := range []NotificationEvent{NotifyEventSuccess, NotifyEventFailure} {
+		if err := sink.Send(context.Background(), Notification{Event: e, Title: "t"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	var got []NotificationEvent
+	for {
+		var n Notification
+		if err := dec.Decode(&n); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n.Event)
+	}
+	if fmt.Sprint(got) != "[success failure]" {
+		t.Errorf("文件内容 = %v", got)
+	}
+}
+
+func TestNotificationSinkConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     NotificationSinkConfig
+		wantErr bool
+	}{
+		{"toast", NotificationSinkConfig{Type: "toast"}, false},
+		{"webhook", NotificationSinkConfig{Type: "webhook", URL: "https://example.com/hook"}, false},
+		{"webhook 缺少地址", NotificationSinkConfig{Type: "webhook"}, true},
+		{"smtp", NotificationSinkConfig{Type: "smtp", SMTPHost: "mail", From: "a@b", To: []string{"c@d"}}, false},
+		{"smtp 缺少收件人", NotificationSinkConfig{Type: "smtp", SMTPHost: "mail", From: "a@b"}, true},
+		{"command", NotificationSinkConfig{Type: "command", Command: "notify.cmd"}, false},
+		{"command 为空", NotificationSinkConfig{Type: "command"}, true},
+		{"file", NotificationSinkConfig{Type: "file", Path: "n.jsonl"}, false},
+		{"未知类型", NotificationSinkConfig{Type: "pager"}, true},
+		{"订阅事件", NotificationSinkConfig{Type: "toast", Events: []string{"Failure", "give_up"}}, false},
+		{"未知事件", NotificationSinkConfig{Type: "toast", Events: []string{"crash"}}, true},
+		{"负数超时", NotificationSinkConfig{Type: "toast", TimeoutSeconds: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewNotifierFromConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	n, err := NewNotifierFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.routes) != 1 || n.routes[0].sink.Name() != "toast" {
+		t.Errorf("未配置 notifications 时应只有 toast 渠道")
+	}
+
+	cfg.Notifications = []NotificationSinkConfig{
+		{Type: "file", Path: filepath.Join(t.TempDir(), "n.jsonl"), Events: []string{"failure"}},
+		{Type: "webhook", URL: "http://127.0.0.1:1/hook"},
+	}
+	n, err = NewNotifierFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.routes) != 2 || n.routes[0].sink.Name() != "file" || !n.routes[0].events[NotifyEventFailure] || n.routes[1].events != nil {
+		t.Errorf("routes 配置不正确: %+v", n.routes)
+	}
+
+	cfg.Notifications = []NotificationSinkConfig{{Type: "pager"}}
+	if _, err := NewNotifierFromConfig(cfg); err == nil {
+		t.Error("无效配置应返回错误")
+	}
+}
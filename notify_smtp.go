@@ -0,0 +1,171 @@
+// Package main provides the SMTP email notification sink.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPSink 通过 SMTP 发送邮件通知
+type SMTPSink struct {
+	Addr     string // host:port
+	Username string // 为空时不进行认证
+	Password string
+	From     string
+	To       []string
+	Timeout  time.Duration
+}
+
+// Name 渠道名称
+func (s *SMTPSink) Name() string {
+	return "smtp"
+}
+
+// Send 发送邮件
+// 连接和每次读写都受截止时间约束（Timeout 与 ctx 截止时间取较早者），超时或取消时连接随之关闭，不会留下后台发送
+func (s *SMTPSink) Send(ctx context.Context, n Notification) error {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := s.send(ctx, deadline, s.buildMessage(n)); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("发送邮件已取消: %w", ctx.Err())
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return fmt.Errorf("发送邮件超时（%s）: %w", timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// send 连接服务器并发送（支持 STARTTLS 时自动启用）
+func (s *SMTPSink) send(ctx context.Context, deadline time.Time, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp 地址无效: %w", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", s.Addr, time.Until(deadline))
+	if err != nil {
+		return fmt.Errorf("连接邮件服务器失败: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("连接邮件服务器失败: %w", err)
+	}
+	// ctx 取消时关闭连接，使阻塞中的读写立即返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	defer c.Close()
+	if err := s.deliver(c, host, msg); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// deliver 完成一次 SMTP 会话（与 smtp.SendMail 的流程一致）
+func (s *SMTPSink) deliver(c *smtp.Client, host string, msg []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("服务器不支持认证")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage 组装邮件内容（UTF-8 纯文本，标题按 RFC 2047 编码）
+func (s *SMTPSink) buildMessage(n Notification) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", s.From)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mimeEncodeWord("[gpd-touch-fix] "+n.Title))
+	fmt.Fprintf(&sb, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	if n.Priority == PriorityHigh {
+		sb.WriteString("X-Priority: 1\r\n")
+		sb.WriteString("Importance: high\r\n")
+	}
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	sb.WriteString("\r\n")
+
+	lines := []string{n.Message, ""}
+	lines = append(lines, T("notify.mail.event", n.Event))
+	if n.Device != "" {
+		lines = append(lines, T("notify.mail.device", n.Device))
+	}
+	if n.StatusBefore != "" || n.StatusAfter != "" {
+		lines = append(lines, T("notify.mail.status", orNil(n.StatusBefore), orNil(n.StatusAfter)))
+	}
+	if n.Attempts > 0 {
+		lines = append(lines, T("notify.mail.attempts", n.Attempts))
+	}
+	if n.Episode != "" {
+		lines = append(lines, T("notify.mail.episode", n.Episode))
+	}
+	if n.Hostname != "" {
+		lines = append(lines, T("notify.mail.host", n.Hostname))
+	}
+	lines = append(lines, T("notify.mail.time", n.Time.Format("2006-01-02 15:04:05")))
+	for _, line := range lines {
+		sb.WriteString(strings.ReplaceAll(line, "\n", "\r\n"))
+		sb.WriteString("\r\n")
+	}
+	return []byte(sb.String())
+}
+
+// mimeEncodeWord 非 ASCII 标题使用 RFC 2047 编码
+func mimeEncodeWord(s string) string {
+	for _, r := range s {
+		if r > 127 {
+			return mime.BEncoding.Encode("UTF-8", s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+// Package main provides user-defined notification title and body templates.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NotificationTemplateConfig 自定义某个事件的通知标题和正文（Go text/template 语法），留空的部分使用默认文本
+//
+// 可用字段：.Event .Device .Status .StatusBefore .StatusAfter .Error .Attempts .Time .Episode .Hostname .Version
+// 以及默认文本 .Title .Message，例如 "{{.Device}} 第 {{.Attempts}} 次修复失败: {{.Error}}"
+type NotificationTemplateConfig struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// notificationTemplateData 模板可用的数据
+type notificationTemplateData struct {
+	Event        string
+	Level        string
+	Device       string
+	Status       string // 修复后状态，没有时为修复前状态
+	StatusBefore string
+	StatusAfter  string
+	Error        string
+	Attempts     int
+	Time         time.Time
+	Episode      string
+	Hostname     string
+	Version      string
+	Title        string // 默认标题
+	Message      string // 默认正文
+}
+
+// notificationTemplate 已解析的通知模板
+type notificationTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// parseNotificationTemplates 解析 notification_templates 配置，键为事件名称
+func parseNotificationTemplates(cfg map[string]NotificationTemplateConfig) (map[NotificationEvent]*notificationTemplate, error) {
+	templates := make(map[NotificationEvent]*notificationTemplate, len(cfg))
+	for name, tc := range cfg {
+		events, err := parseNotificationEvents([]string{name})
+		if err != nil {
+			return nil, err
+		}
+		t := &notificationTemplate{}
+		if t.title, err = parseNotificationTemplate(name+".title", tc.Title); err != nil {
+			return nil, err
+		}
+		if t.body, err = parseNotificationTemplate(name+".body", tc.Body); err != nil {
+			return nil, err
+		}
+		templates[events[0]] = t
+	}
+	return templates, nil
+}
+
+// parseNotificationTemplate 解析单个模板，空字符串返回 nil
+func parseNotificationTemplate(name, text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("模板 %s 无效: %w", name, err)
+	}
+	return t, nil
+}
+
+// apply 用模板生成通知标题和正文
+func (t *notificationTemplate) apply(n Notification) (Notification, error) {
+	data := notificationTemplateData{
+		Event:        string(n.Event),
+		Level:        n.Level,
+		Device:       n.Device,
+		Status:       n.StatusAfter,
+		StatusBefore: n.StatusBefore,
+		StatusAfter:  n.StatusAfter,
+		Error:        n.Error,
+		Attempts:     n.Attempts,
+		Time:         n.Time,
+		Episode:      n.Episode,
+		Hostname:     n.Hostname,
+		Version:      n.Version,
+		Title:        n.Title,
+		Message:      n.Message,
+	}
+	if data.Status == "" {
+		data.Status = n.StatusBefore
+	}
+
+	var err error
+	if t.title != nil {
+		if n.Title, err = executeNotificationTemplate(t.title, data); err != nil {
+			return n, err
+		}
+	}
+	if t.body != nil {
+		if n.Message, err = executeNotificationTemplate(t.body, data); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// executeNotificationTemplate 执行模板
+func executeNotificationTemplate(t *template.Template, data notificationTemplateData) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("执行模板 %s 失败: %w", t.Name(), err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
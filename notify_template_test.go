@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNotificationTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    NotificationTemplateConfig
+		wantTitle   string
+		wantMessage string
+	}{
+		{
+			name:        "标题和正文",
+			template:    NotificationTemplateConfig{Title: "[{{.Hostname}}] {{.Device}}", Body: "第 {{.Attempts}} 次修复失败（{{.Status}}）: {{.Error}} @ {{.Time.Format \"15:04\"}}"},
+			wantTitle:   "[gpd] 触摸屏",
+			wantMessage: "第 3 次修复失败（Error）: 设备未响应 @ 08:30",
+		},
+		{
+			name:        "只覆盖标题",
+			template:    NotificationTemplateConfig{Title: "{{.Event}}: {{.Title}}"},
+			wantTitle:   "failure: 修复失败",
+			wantMessage: "默认正文",
+		},
+		{
+			name:        "未知字段回退默认文本",
+			template:    NotificationTemplateConfig{Title: "{{.NoSuchField}}"},
+			wantTitle:   "修复失败",
+			wantMessage: "默认正文",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newTestNotifier()
+			sink := &fakeSink{name: "fake"}
+			n.AddSink(sink)
+			templates, err := parseNotificationTemplates(map[string]NotificationTemplateConfig{"failure": tt.template})
+			if err != nil {
+				t.Fatalf("parseNotificationTemplates() error = %v", err)
+			}
+			n.templates = templates
+
+			err = n.Dispatch(context.Background(), Notification{
+				Event:        NotifyEventFailure,
+				Type:         NotifyError,
+				Title:        "修复失败",
+				Message:      "默认正文",
+				Device:       "触摸屏",
+				Hostname:     "gpd",
+				Error:        "设备未响应",
+				StatusBefore: "Error",
+				Attempts:     3,
+			})
+			if err != nil {
+				t.Fatalf("Dispatch() error = %v", err)
+			}
+			if len(sink.got) != 1 {
+				t.Fatalf("收到 %d 条通知, want 1", len(sink.got))
+			}
+			if got := sink.got[0]; got.Title != tt.wantTitle || got.Message != tt.wantMessage {
+				t.Errorf("got (%q, %q), want (%q, %q)", got.Title, got.Message, tt.wantTitle, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestNotificationTemplate_OtherEventsUnchanged(t *testing.T) {
+	n := newTestNotifier()
+	sink := &fakeSink{name: "fake"}
+	n.AddSink(sink)
+	n.templates, _ = parseNotificationTemplates(map[string]NotificationTemplateConfig{"failure": {Title: "自定义"}})
+
+	_ = n.Dispatch(context.Background(), Notification{Event: NotifyEventSuccess, Title: "修复成功"})
+	if got := sink.got[0].Title; got != "修复成功" {
+		t.Errorf("success 事件标题 = %q, 不应套用 failure 模板", got)
+	}
+}
+
+func TestNotificationTemplate_DedupUsesDefaultText(t *testing.T) {
+	n := newTestNotifier()
+	sink := &fakeSink{name: "fake"}
+	n.AddSink(sink)
+	n.policy = mustPolicy(t, NotificationPolicyConfig{DedupWindowMinutes: 10})
+	n.templates, _ = parseNotificationTemplates(map[string]NotificationTemplateConfig{
+		"skip": {Title: "{{.Title}} @ {{.Time.Format \"15:04:05\"}}", Body: "{{.Message}}（{{.Episode}}）"},
+	})
+
+	// 模板含时间和事件 ID，渲染结果每次不同；去重仍按默认文本判断
+	for i, at := range []time.Time{policyAt(9, 0), policyAt(9, 5)} {
+		_ = n.Dispatch(context.Background(), Notification{
+			Event:   NotifyEventSkip,
+			Title:   "触屏状态正常",
+			Message: "设备 触摸屏 状态正常，无需修复",
+			Episode: fmt.Sprintf("ep-%d", i),
+			Time:    at,
+		})
+	}
+	if len(sink.got) != 1 {
+		t.Fatalf("收到 %d 条通知, want 1（第二条应被去重）", len(sink.got))
+	}
+	if got := sink.got[0].Title; got != "触屏状态正常 @ 09:00:00" {
+		t.Errorf("标题 = %q, 应套用模板", got)
+	}
+}
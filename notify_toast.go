@@ -0,0 +1,105 @@
+// Package main provides the Windows toast notification sink.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ToastSink 使用 PowerShell 显示 Windows Toast 通知
+type ToastSink struct {
+	appName string
+}
+
+// NewToastSink 创建 Toast 通知渠道
+func NewToastSink(appName string) *ToastSink {
+	return &ToastSink{appName: appName}
+}
+
+// Name 渠道名称
+func (t *ToastSink) Name() string {
+	return "toast"
+}
+
+// Send 显示 Toast 通知（有事件 ID 时附在消息末尾）
+func (t *ToastSink) Send(ctx context.Context, n Notification) error {
+	message := n.Message
+	if n.Episode != "" {
+		message += "\n" + T("notify.episode", n.Episode)
+	}
+	return t.sendToast(ctx, n.Type, n.Title, message, n.Priority == PriorityHigh)
+}
+
+// sendToast 使用 PowerShell 发送 Windows Toast 通知（高优先级通知在屏幕上停留更久）
+func (t *ToastSink) sendToast(ctx context.Context, notifyType NotificationType, title, message string, highPriority bool) error {
+	// 根据类型选择图标
+	iconHint := ""
+	switch notifyType {
+	case NotifySuccess:
+		iconHint = "ms-winsoundevent:Notification.Default"
+	case NotifyError:
+		iconHint = "ms-winsoundevent:Notification.Looping.Alarm"
+	case NotifyWarning:
+		iconHint = "ms-winsoundevent:Notification.Looping.Alarm2"
+	default:
+		iconHint = "ms-winsoundevent:Notification.Default"
+	}
+
+	duration := "short"
+	if highPriority {
+		duration = "long"
+	}
+
+	// 使用 BurntToast 模块（如果可用）或者回退到基础通知
+	script := fmt.Sprintf(`
+$ErrorActionPreference = 'SilentlyContinue'
+
+# 尝试使用 BurntToast 模块
+if (Get-Module -ListAvailable -Name BurntToast) {
+    Import-Module BurntToast
+    New-BurntToastNotification -Text '%s', '%s' -AppLogo $null
+} else {
+    # 回退到基础 Windows 通知
+    [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+    [Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+    
+    $template = @"
+<toast duration="%s">
+    <visual>
+        <binding template="ToastText02">
+            <text id="1">%s</text>
+            <text id="2">%s</text>
+        </binding>
+    </visual>
+    <audio src="%s"/>
+</toast>
+"@
+    
+    $xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+    $xml.LoadXml($template)
+    
+    $toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+    $notifier = [Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s')
+    $notifier.Show($toast)
+}
+`,
+		escapeForPowerShell(title),
+		escapeForPowerShell(message),
+		duration,
+		escapeForPowerShell(title),
+		escapeForPowerShell(message),
+		iconHint,
+		escapeForPowerShell(t.appName),
+	)
+
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// 通知失败不应影响主程序，只记录警告
+		return fmt.Errorf("发送通知失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+// Package main provides the HTTP webhook notification sink with signing, retries and an offline queue.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	webhookSignatureHeader = "X-GPD-Signature" // sha256=<HMAC-SHA256(secret, body) 的十六进制>
+	webhookDeliveryHeader  = "X-GPD-Delivery"  // 投递 ID，重试时不变
+	webhookRetryMin        = 10 * time.Second
+	webhookRetryMax        = 10 * time.Minute
+)
+
+// WebhookOptions Webhook 通知渠道参数
+type WebhookOptions struct {
+	URL      string
+	Headers  map[string]string
+	Secret   string        // 非空时对请求体做 HMAC-SHA256 签名
+	Timeout  time.Duration // 单次请求超时
+	QueueDir string        // 离线队列目录（为空时不排队，失败即丢弃）
+	MaxQueue int           // 离线队列最多保留的条数（0 表示不限制）
+	RetryMax time.Duration // 退避重试间隔上限
+}
+
+// WebhookSink 以 JSON 格式 POST 通知到 HTTP 地址
+// 网络不可用或服务端临时错误时通知写入磁盘队列，后台按指数退避重试，服务重启后继续补发
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	secret  []byte
+	client  *http.Client
+	queue   *NotificationQueue
+	logger  TagLogger
+	now     func() time.Time
+
+	sendMu     sync.Mutex // 保证队列按顺序补发，且同一时间只有一个补发过程
+	retryMin   time.Duration
+	retryMax   time.Duration
+	retryDelay time.Duration
+	nextTry    time.Time
+
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	running bool // 后台补发是否已启动
+}
+
+// NewWebhookSink 创建 Webhook 通知渠道；配置了离线队列时启动后台补发（补发上次运行遗留的通知）
+func NewWebhookSink(opts WebhookOptions) (*WebhookSink, error) {
+	w, err := newWebhookSink(opts)
+	if err != nil {
+		return nil, err
+	}
+	if w.queue != nil {
+		w.running = true
+		go w.run()
+	}
+	return w, nil
+}
+
+// newWebhookSink 创建 Webhook 通知渠道（不启动后台补发，测试中手动调用 Drain）
+func newWebhookSink(opts WebhookOptions) (*WebhookSink, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	retryMax := opts.RetryMax
+	if retryMax <= 0 {
+		retryMax = webhookRetryMax
+	}
+
+	w := &WebhookSink{
+		url:      opts.URL,
+		headers:  opts.Headers,
+		client:   &http.Client{Timeout: timeout},
+		logger:   ConsoleLogger{},
+		now:      time.Now,
+		retryMin: min(webhookRetryMin, retryMax),
+		retryMax: retryMax,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if opts.Secret != "" {
+		w.secret = []byte(opts.Secret)
+	}
+	if opts.QueueDir != "" {
+		queue, err := NewNotificationQueue(opts.QueueDir, opts.MaxQueue)
+		if err != nil {
+			return nil, err
+		}
+		w.queue = queue
+	}
+	return w, nil
+}
+
+// Name 渠道名称
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// SetLogger 设置日志输出（记录离线队列补发情况）
+func (w *WebhookSink) SetLogger(logger TagLogger) {
+	w.logger = logger
+}
+
+// Pending 返回离线队列中尚未送达的通知数
+func (w *WebhookSink) Pending() int {
+	if w.queue == nil {
+		return 0
+	}
+	return w.queue.Len()
+}
+
+// Send 发送通知；配置了离线队列时只写入队列并唤醒后台补发，不等待请求完成（避免阻塞修复流程），
+// 接收方按入队顺序收到；未配置队列时直接发送，失败即丢弃
+func (w *WebhookSink) Send(ctx context.Context, n Notification) error {
+	item := queuedNotification{ID: newDeliveryID(), QueuedAt: w.now(), Notification: n}
+	if w.queue == nil {
+		return w.deliver(ctx, item)
+	}
+	if err := w.enqueue(ctx, item); err != nil {
+		return err
+	}
+	w.kick()
+	return nil
+}
+
+// enqueue 加入离线队列
+func (w *WebhookSink) enqueue(ctx context.Context, item queuedNotification) error {
+	dropped, err := w.queue.Push(item)
+	if err != nil {
+		return err
+	}
+	if dropped > 0 {
+		w.logger.LogTag(ctx, WARNING, TagService, "Webhook 离线队列已满，丢弃最早的 %d 条通知", dropped)
+	}
+	return nil
+}
+
+// kick 有新通知入队时唤醒后台补发；不获取 sendMu，补发过程正在等待请求时也不阻塞
+func (w *WebhookSink) kick() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 后台补发循环
+func (w *WebhookSink) run() {
+	defer close(w.done)
+
+	timer := time.NewTimer(0) // 启动时立即补发上次运行遗留的通知
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.wake:
+			// 有新通知时立即尝试（网络可能已经恢复），不等待退避间隔
+			w.sendMu.Lock()
+			w.nextTry = time.Time{}
+			w.sendMu.Unlock()
+		case <-timer.C:
+		}
+
+		ctx := context.Background()
+		if err := w.Drain(ctx); err != nil {
+			w.logger.LogTag(ctx, WARNING, TagService, "Webhook 通知暂未送达: %v", err)
+		}
+
+		// 仍有积压时按退避间隔重试
+		timer.Reset(w.retryMax)
+		if w.Pending() > 0 {
+			w.sendMu.Lock()
+			delay := w.nextTry.Sub(w.now())
+			w.sendMu.Unlock()
+			if delay < w.retryMin {
+				delay = w.retryMin
+			}
+			timer.Reset(delay)
+		}
+	}
+}
+
+// Drain 按入队顺序补发离线队列中的通知，遇到临时失败时停止并推迟下次重试
+// 服务端拒绝（4xx）的通知重试也不会成功，记录后移出队列
+func (w *WebhookSink) Drain(ctx context.Context) error {
+	if w.queue == nil {
+		return nil
+	}
+
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	if w.now().Before(w.nextTry) {
+		return fmt.Errorf("Webhook 暂不可用，%s 后重试", w.nextTry.Sub(w.now()).Round(time.Second))
+	}
+
+	names, err := w.queue.List()
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, name := range names {
+		item, err := w.queue.Load(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // 列出后因队列已满被丢弃
+		}
+		if err != nil {
+			w.logger.LogTag(ctx, WARNING, TagService, "跳过离线队列中的通知: %v", err)
+			continue
+		}
+
+		if err := w.deliver(ctx, item); err != nil {
+			if !isRetryableWebhookError(err) {
+				w.logger.LogTag(ctx, WARNING, TagService, "Webhook 拒绝通知 %s，不再重试: %v", item.ID, err)
+				_ = w.queue.Remove(name)
+				continue
+			}
+
+			if w.retryDelay == 0 {
+				w.retryDelay = w.retryMin
+			} else if w.retryDelay *= 2; w.retryDelay > w.retryMax {
+				w.retryDelay = w.retryMax
+			}
+			w.nextTry = w.now().Add(w.retryDelay)
+			if sent > 0 {
+				w.logger.LogTag(ctx, INFO, TagService, "Webhook 离线队列已补发 %d 条通知", sent)
+			}
+			return fmt.Errorf("补发 Webhook 通知失败，%s 后重试: %w", w.retryDelay, err)
+		}
+
+		_ = w.queue.Remove(name)
+		sent++
+	}
+
+	w.retryDelay = 0
+	w.nextTry = time.Time{}
+	if sent > 0 {
+		w.logger.LogTag(ctx, INFO, TagService, "Webhook 离线队列已补发 %d 条通知", sent)
+	}
+	return nil
+}
+
+// deliver 发送一次请求，非 2xx 响应视为失败
+func (w *WebhookSink) deliver(ctx context.Context, item queuedNotification) error {
+	body, err := json.Marshal(item.Notification)
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gpd-touch-fix/"+Version)
+	req.Header.Set(webhookDeliveryHeader, item.ID)
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	if w.secret != nil {
+		req.Header.Set(webhookSignatureHeader, SignWebhookPayload(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &webhookStatusError{code: resp.StatusCode, status: resp.Status}
+	}
+	return nil
+}
+
+// Close 停止后台补发（未送达的通知保留在磁盘队列中）
+func (w *WebhookSink) Close() error {
+	select {
+	case <-w.stop:
+		return nil
+	default:
+	}
+	close(w.stop)
+	if w.running {
+		<-w.done
+	}
+	return nil
+}
+
+// SignWebhookPayload 计算请求体签名：sha256=<HMAC-SHA256(secret, body) 的十六进制>
+func SignWebhookPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookStatusError 服务端返回非 2xx 响应
+type webhookStatusError struct {
+	code   int
+	status string
+}
+
+func (e *webhookStatusError) Error() string {
+	return "服务器返回 " + e.status
+}
+
+// isRetryableWebhookError 网络错误、超时、限流和服务端错误可以重试，其他 4xx 重试也不会成功
+func isRetryableWebhookError(err error) bool {
+	var se *webhookStatusError
+	if !errors.As(err, &se) {
+		return true
+	}
+	switch {
+	case se.code == http.StatusRequestTimeout, se.code == http.StatusTooEarly, se.code == http.StatusTooManyRequests:
+		return true
+	default:
+		return se.code >= 500
+	}
+}
+
+// newDeliveryID 生成投递 ID
+func newDeliveryID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
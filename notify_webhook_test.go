@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver 可切换在线/离线的 Webhook 接收端
+type webhookReceiver struct {
+	mu         sync.Mutex
+	status     int // 返回的状态码（0 表示 200）
+	titles     []string
+	deliveries []string
+	signatures []string
+	bodies     [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	var n Notification
+	_ = json.Unmarshal(body, &n)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+		return
+	}
+	r.titles = append(r.titles, n.Title)
+	r.deliveries = append(r.deliveries, req.Header.Get(webhookDeliveryHeader))
+	r.signatures = append(r.signatures, req.Header.Get(webhookSignatureHeader))
+	r.bodies = append(r.bodies, body)
+}
+
+func (r *webhookReceiver) setStatus(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = code
+}
+
+func (r *webhookReceiver) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.titles...)
+}
+
+// newTestWebhook 创建不启动后台补发、使用假时钟的 Webhook 渠道
+func newTestWebhook(t *testing.T, opts WebhookOptions, now *time.Time) *WebhookSink {
+	t.Helper()
+	w, err := newWebhookSink(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.logger = ConsoleLogger{MinLevel: ERROR}
+	w.now = func() time.Time { return *now }
+	return w
+}
+
+func TestWebhookSink_Signature(t *testing.T) {
+	recv := &webhookReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	w := newTestWebhook(t, WebhookOptions{URL: srv.URL, Secret: "s3cret"}, &now)
+	n := Notification{
+		Event: NotifyEventFailure, Title: "触屏修复失败", Hostname: "gpd-win4", Device: "触摸屏",
+		StatusBefore: "Error", StatusAfter: "Error", Attempts: 3, Error: "设备状态: Error", Version: "1.0.1",
+	}
+	if err := w.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(recv.bodies) != 1 {
+		t.Fatalf("收到 %d 个请求, want 1", len(recv.bodies))
+	}
+	want := SignWebhookPayload([]byte("s3cret"), recv.bodies[0])
+	if !hmac.Equal([]byte(recv.signatures[0]), []byte(want)) || !strings.HasPrefix(want, "sha256=") {
+		t.Errorf("签名 = %q, want %q", recv.signatures[0], want)
+	}
+	if SignWebhookPayload([]byte("other"), recv.bodies[0]) == want {
+		t.Error("不同密钥的签名不应相同")
+	}
+	for _, field := range []string{`"hostname":"gpd-win4"`, `"status_before":"Error"`, `"status_after":"Error"`, `"attempts":3`, `"version":"1.0.1"`, `"error":"设备状态: Error"`} {
+		if !strings.Contains(string(recv.bodies[0]), field) {
+			t.Errorf("请求体缺少 %s: %s", field, recv.bodies[0])
+		}
+	}
+}
+
+func TestWebhookSink_OfflineQueue(t *testing.T) {
+	recv := &webhookReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	queueDir := filepath.Join(t.TempDir(), "queue")
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	opts := WebhookOptions{URL: srv.URL, QueueDir: queueDir, RetryMax: time.Minute}
+	w := newTestWebhook(t, opts, &now)
+	ctx := context.Background()
+
+	// 配置了队列时 Send 只排队，由补发按顺序发送
+	recv.setStatus(http.StatusServiceUnavailable)
+	for _, title := range []string{"第一条", "第二条"} {
+		if err := w.Send(ctx, Notification{Title: title}); err != nil {
+			t.Errorf("Send(%s) error = %v", title, err)
+		}
+	}
+	if got := w.Pending(); got != 2 {
+		t.Fatalf("Pending() = %d, want 2", got)
+	}
+
+	// 补发失败后按退避推迟：10 秒内不再请求
+	if err := w.Drain(ctx); err == nil {
+		t.Fatal("服务端仍不可用时 Drain() 应返回错误")
+	}
+	recv.setStatus(0)
+	if err := w.Drain(ctx); err == nil || len(recv.received()) != 0 {
+		t.Errorf("退避期间不应请求, err = %v, received = %v", err, recv.received())
+	}
+
+	// 模拟服务重启：新实例从磁盘队列恢复并按顺序补发
+	now = now.Add(30 * time.Second)
+	restarted := newTestWebhook(t, opts, &now)
+	if err := restarted.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if got := strings.Join(recv.received(), ","); got != "第一条,第二条" {
+		t.Errorf("补发顺序 = %s, want 第一条,第二条", got)
+	}
+	if restarted.Pending() != 0 {
+		t.Errorf("补发后队列应为空, Pending() = %d", restarted.Pending())
+	}
+	if recv.deliveries[0] == "" || recv.deliveries[0] == recv.deliveries[1] {
+		t.Errorf("投递 ID 应非空且各不相同: %v", recv.deliveries)
+	}
+
+	// 新通知同样经队列发送
+	if err := restarted.Send(ctx, Notification{Title: "第三条"}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+	if got := len(recv.received()); got != 2 {
+		t.Errorf("Send() 不应等待请求, 收到 %d 条, want 2", got)
+	}
+	if err := restarted.Drain(ctx); err != nil || len(recv.received()) != 3 {
+		t.Errorf("Drain() error = %v, 收到 %d 条, want 3", err, len(recv.received()))
+	}
+}
+
+func TestWebhookSink_NetworkDown(t *testing.T) {
+	recv := &webhookReceiver{}
+	srv := httptest.NewServer(recv)
+	url := srv.URL
+	srv.Close() // 地址不可达
+
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	w := newTestWebhook(t, WebhookOptions{URL: url, QueueDir: t.TempDir(), Timeout: time.Second}, &now)
+	if err := w.Send(context.Background(), Notification{Title: "断网"}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+	if err := w.Drain(context.Background()); err == nil {
+		t.Error("网络不可达时 Drain() 应返回错误")
+	}
+	if w.Pending() != 1 {
+		t.Errorf("网络错误应留在离线队列, Pending() = %d", w.Pending())
+	}
+}
+
+func TestWebhookSink_PermanentFailure(t *testing.T) {
+	recv := &webhookReceiver{status: http.StatusBadRequest}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	w := newTestWebhook(t, WebhookOptions{URL: srv.URL}, &now)
+	if err := w.Send(context.Background(), Notification{Title: "被拒绝"}); err == nil {
+		t.Error("未配置队列时 4xx 响应应返回错误")
+	}
+
+	queued := newTestWebhook(t, WebhookOptions{URL: srv.URL, QueueDir: t.TempDir()}, &now)
+	if err := queued.Send(context.Background(), Notification{Title: "被拒绝"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queued.Drain(context.Background()); err != nil {
+		t.Errorf("Drain() error = %v", err)
+	}
+	if queued.Pending() != 0 {
+		t.Errorf("4xx 响应重试也不会成功，应移出队列, Pending() = %d", queued.Pending())
+	}
+}
+
+func TestNotificationQueue_MaxItems(t *testing.T) {
+	q, err := NewNotificationQueue(t.TempDir(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)
+	dropped := 0
+	var first []string
+	for i, title := range []string{"a", "b", "c"} {
+		if i == 2 {
+			first, _ = q.List()
+		}
+		n, err := q.Push(queuedNotification{ID: title, QueuedAt: base.Add(time.Duration(i) * time.Second), Notification: Notification{Title: title}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dropped += n
+	}
+	if dropped != 1 {
+		t.Errorf("丢弃 %d 条, want 1", dropped)
+	}
+	// 列出后被丢弃的通知读取时报告不存在，补发时直接跳过
+	if _, err := q.Load(first[0]); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load(已丢弃) error = %v, want fs.ErrNotExist", err)
+	}
+
+	names, _ := q.List()
+	var titles []string
+	for _, name := range names {
+		item, err := q.Load(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, item.Notification.Title)
+	}
+	if strings.Join(titles, ",") != "b,c" {
+		t.Errorf("队列内容 = %v, want 保留最新的 b,c", titles)
+	}
+}
+
+func TestIsRetryableWebhookError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"网络错误", io.ErrUnexpectedEOF, true},
+		{"服务端错误", &webhookStatusError{code: 502}, true},
+		{"限流", &webhookStatusError{code: 429}, true},
+		{"请求超时", &webhookStatusError{code: 408}, true},
+		{"未授权", &webhookStatusError{code: 401}, false},
+		{"地址不存在", &webhookStatusError{code: 404}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableWebhookError(tt.err); got != tt.want {
+				t.Errorf("isRetryableWebhookError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSink_BackgroundDrainOnStart(t *testing.T) {
+	recv := &webhookReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	// 上次运行遗留在队列中的通知
+	dir := t.TempDir()
+	q, err := NewNotificationQueue(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Push(queuedNotification{ID: "left", QueuedAt: time.Now(), Notification: Notification{Title: "遗留"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWebhookSink(WebhookOptions{URL: srv.URL, QueueDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := recv.received(); len(got) != 1 || got[0] != "遗留" {
+		t.Errorf("启动后应补发遗留通知, received = %v", got)
+	}
+}
+
+func TestWebhookSink_SendDoesNotBlock(t *testing.T) {
+	// 服务端一直不响应，直到测试放行
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var titles []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		var n Notification
+		_ = json.NewDecoder(req.Body).Decode(&n)
+		mu.Lock()
+		titles = append(titles, n.Title)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	w, err := NewWebhookSink(WebhookOptions{URL: srv.URL, QueueDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetLogger(ConsoleLogger{MinLevel: ERROR})
+	defer w.Close()
+
+	// 第一条唤醒后台补发并卡在请求中，第二条也不应等待
+	start := time.Now()
+	for _, title := range []string{"第一条", "第二条"} {
+		if err := w.Send(context.Background(), Notification{Title: title}); err != nil {
+			t.Fatalf("Send(%s) error = %v", title, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send() 耗时 %s，不应等待请求完成", elapsed)
+	}
+
+	release <- struct{}{}
+	release <- struct{}{}
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(titles, ",") != "第一条,第二条" {
+		t.Errorf("收到 %v, want 按顺序收到两条", titles)
+	}
+}
@@ -0,0 +1,258 @@
+// Package main provides the adaptive poll schedule and the polling cost meter used by the device poller.
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval 默认轮询间隔
+const DefaultPollInterval = 10 * time.Second
+
+// AdaptivePollConfig 自适应轮询配置：唤醒或失败后短时间内加快轮询，设备长期稳定后放慢，显示器关闭时停止
+type AdaptivePollConfig struct {
+	FastIntervalSecs   int `json:"fast_interval_secs,omitempty"`   // 唤醒或失败后的轮询间隔（秒，默认 2）
+	FastWindowSecs     int `json:"fast_window_secs,omitempty"`     // 唤醒或失败后快速轮询的时长（秒，默认 180）
+	StableIntervalSecs int `json:"stable_interval_secs,omitempty"` // 设备稳定后的轮询间隔（秒，默认 300）
+	StableAfterSecs    int `json:"stable_after_secs,omitempty"`    // 设备持续正常多久后视为稳定（秒，默认 600）
+}
+
+// Validate 验证自适应轮询配置
+func (c *AdaptivePollConfig) Validate() error {
+	if c.FastIntervalSecs < 0 || c.FastWindowSecs < 0 || c.StableIntervalSecs < 0 || c.StableAfterSecs < 0 {
+		return fmt.Errorf("fast_interval_secs、fast_window_secs、stable_interval_secs、stable_after_secs 必须为非负数")
+	}
+	return nil
+}
+
+// PollMode 轮询模式
+type PollMode string
+
+const (
+	PollFast    PollMode = "fast"    // 唤醒或失败后快速轮询
+	PollNormal  PollMode = "normal"  // 按 poll_interval 轮询
+	PollStable  PollMode = "stable"  // 设备长期稳定，放慢轮询
+	PollStopped PollMode = "stopped" // 睡眠或显示器关闭，停止读取设备状态，只慢速读取空闲时间
+)
+
+// AsleepIdleInterval 睡眠或显示器关闭期间读取空闲时间的间隔（没有收到唤醒事件时靠用户输入恢复）
+const AsleepIdleInterval = time.Minute
+
+// allPollModes 所有轮询模式（按报告顺序）
+var allPollModes = []PollMode{PollFast, PollNormal, PollStable, PollStopped}
+
+// PollSchedule 轮询间隔设置
+type PollSchedule struct {
+	Interval       time.Duration // 正常轮询间隔
+	Adaptive       bool          // 是否启用自适应轮询
+	FastInterval   time.Duration
+	FastWindow     time.Duration
+	StableInterval time.Duration
+	StableAfter    time.Duration
+}
+
+// NewPollSchedule 根据配置创建轮询间隔设置（未配置的项使用默认值）
+func NewPollSchedule(interval time.Duration, adaptive *AdaptivePollConfig) PollSchedule {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	s := PollSchedule{
+		Interval:       interval,
+		FastInterval:   2 * time.Second,
+		FastWindow:     3 * time.Minute,
+		StableInterval: 5 * time.Minute,
+		StableAfter:    10 * time.Minute,
+	}
+	if adaptive == nil {
+		return s
+	}
+	s.Adaptive = true
+	if adaptive.FastIntervalSecs > 0 {
+		s.FastInterval = time.Duration(adaptive.FastIntervalSecs) * time.Second
+	}
+	if adaptive.FastWindowSecs > 0 {
+		s.FastWindow = time.Duration(adaptive.FastWindowSecs) * time.Second
+	}
+	if adaptive.StableIntervalSecs > 0 {
+		s.StableInterval = time.Duration(adaptive.StableIntervalSecs) * time.Second
+	}
+	if adaptive.StableAfterSecs > 0 {
+		s.StableAfter = time.Duration(adaptive.StableAfterSecs) * time.Second
+	}
+	return s
+}
+
+// String 用于日志
+func (s PollSchedule) String() string {
+	if !s.Adaptive {
+		return T("poll.every", s.Interval)
+	}
+	return T("poll.adaptive", s.FastWindow, s.FastInterval, s.Interval, s.StableAfter, s.StableInterval)
+}
+
+// PollScheduler 根据睡眠/唤醒状态和最近的唤醒、失败时间决定下次轮询间隔
+type PollScheduler struct {
+	schedule    PollSchedule
+	mu          sync.Mutex
+	lastWake    time.Time // 最近一次进入 resuming 的时间
+	lastFailure time.Time // 最近一次发现异常或修复失败的时间
+}
+
+// NewPollScheduler 创建轮询调度器
+func NewPollScheduler(schedule PollSchedule) *PollScheduler {
+	return &PollScheduler{schedule: schedule}
+}
+
+// Schedule 轮询间隔设置
+func (s *PollScheduler) Schedule() PollSchedule {
+	return s.schedule
+}
+
+// Observe 记录状态转换（唤醒、发现异常、修复失败）
+func (s *PollScheduler) Observe(t WakeTransition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.To == StateResuming {
+		s.lastWake = t.At
+	}
+	switch t.Input {
+	case InputDeviceError, InputRepairFailed, InputGiveUp:
+		s.lastFailure = t.At
+	}
+}
+
+// Next 返回当前状态下的轮询模式和间隔；PollStopped 时间隔为 AsleepIdleInterval，只检查是否有用户输入
+// since 为进入当前状态的时间
+func (s *PollScheduler) Next(state WakeState, since, now time.Time) (PollMode, time.Duration) {
+	if state == StateAsleep {
+		return PollStopped, AsleepIdleInterval
+	}
+	if !s.schedule.Adaptive {
+		return PollNormal, s.schedule.Interval
+	}
+
+	s.mu.Lock()
+	lastWake, lastFailure := s.lastWake, s.lastFailure
+	s.mu.Unlock()
+
+	within := func(at time.Time, d time.Duration) bool {
+		return !at.IsZero() && now.Sub(at) < d
+	}
+	switch {
+	case state == StateResuming || state == StateRepairing || state == StateCooldown,
+		within(lastWake, s.schedule.FastWindow), within(lastFailure, s.schedule.FastWindow):
+		return PollFast, s.schedule.FastInterval
+	case state == StateAwake && now.Sub(since) >= s.schedule.StableAfter && !within(lastFailure, s.schedule.StableAfter):
+		return PollStable, s.schedule.StableInterval
+	}
+	return PollNormal, s.schedule.Interval
+}
+
+// PollCostReport 一段时间内的轮询开销
+type PollCostReport struct {
+	Start         time.Time                  `json:"start"`
+	End           time.Time                  `json:"end"`
+	Checks        int                        `json:"checks"`          // 检查设备状态次数
+	Spawns        int64                      `json:"spawns"`          // 启动的 PowerShell 进程数（包括修复）
+	SpawnsPerHour float64                    `json:"spawns_per_hour"` // 折算为每小时
+	ModeTime      map[PollMode]time.Duration `json:"mode_time"`       // 各轮询模式的累计时长
+}
+
+// Period 统计时长
+func (r PollCostReport) Period() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// String 用于日志和 -status
+func (r PollCostReport) String() string {
+	s := T("poll.cost", r.Period().Round(time.Second), r.Checks, r.Spawns, r.SpawnsPerHour)
+	for _, mode := range allPollModes {
+		if d := r.ModeTime[mode]; d > 0 {
+			s += T("poll.cost.mode", mode, d.Round(time.Second))
+		}
+	}
+	return s
+}
+
+// PollCostMeter 统计轮询开销：检查次数、PowerShell 进程数和各轮询模式的时长
+type PollCostMeter struct {
+	mu         sync.Mutex
+	spawns     func() int64 // 返回累计的 PowerShell 进程数
+	start      time.Time
+	spawnStart int64
+	checks     int
+	mode       PollMode
+	modeSince  time.Time
+	modeTime   map[PollMode]time.Duration
+}
+
+// NewPollCostMeter 创建开销统计，从 now 开始计时；spawns 为空时使用 PowerShellSpawnCount
+func NewPollCostMeter(now time.Time, spawns func() int64) *PollCostMeter {
+	if spawns == nil {
+		spawns = PowerShellSpawnCount
+	}
+	m := &PollCostMeter{spawns: spawns}
+	m.reset(now)
+	return m
+}
+
+func (m *PollCostMeter) reset(now time.Time) {
+	m.start = now
+	m.spawnStart = m.spawns()
+	m.checks = 0
+	m.modeSince = now
+	m.modeTime = make(map[PollMode]time.Duration)
+}
+
+// RecordCheck 记录一次设备状态检查
+func (m *PollCostMeter) RecordCheck() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks++
+}
+
+// SetMode 记录轮询模式切换
+func (m *PollCostMeter) SetMode(mode PollMode, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accumulate(now)
+	m.mode = mode
+}
+
+func (m *PollCostMeter) accumulate(now time.Time) {
+	if m.mode != "" && now.After(m.modeSince) {
+		m.modeTime[m.mode] += now.Sub(m.modeSince)
+	}
+	m.modeSince = now
+}
+
+// Due 距离上次报告是否已超过 period
+func (m *PollCostMeter) Due(now time.Time, period time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return now.Sub(m.start) >= period
+}
+
+// Report 返回从上次报告到 now 的开销，并开始新的统计周期
+func (m *PollCostMeter) Report(now time.Time) PollCostReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accumulate(now)
+
+	r := PollCostReport{
+		Start:    m.start,
+		End:      now,
+		Checks:   m.checks,
+		Spawns:   m.spawns() - m.spawnStart,
+		ModeTime: m.modeTime,
+	}
+	if hours := r.Period().Hours(); hours > 0 {
+		r.SpawnsPerHour = float64(r.Spawns) / hours
+	}
+
+	mode := m.mode
+	m.reset(now)
+	m.mode = mode
+	return r
+}
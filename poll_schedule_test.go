@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPollSchedule(t *testing.T) {
+	s := NewPollSchedule(0, nil)
+	if s.Interval != DefaultPollInterval || s.Adaptive {
+		t.Errorf("默认设置 = %+v, want 每 10 秒、不自适应", s)
+	}
+
+	s = NewPollSchedule(30*time.Second, &AdaptivePollConfig{StableIntervalSecs: 900})
+	if !s.Adaptive || s.Interval != 30*time.Second || s.FastInterval != 2*time.Second ||
+		s.FastWindow != 3*time.Minute || s.StableInterval != 15*time.Minute || s.StableAfter != 10*time.Minute {
+		t.Errorf("自适应设置 = %+v", s)
+	}
+}
+
+func TestPollScheduler_Next(t *testing.T) {
+	base := time.Date(2025, 12, 25, 7, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return base.Add(d) }
+	adaptive := NewPollSchedule(10*time.Second, &AdaptivePollConfig{})
+
+	tests := []struct {
+		name         string
+		schedule     PollSchedule
+		transitions  []WakeTransition
+		state        WakeState
+		since        time.Duration
+		now          time.Duration
+		wantMode     PollMode
+		wantInterval time.Duration
+	}{
+		{"固定间隔", NewPollSchedule(20*time.Second, nil), nil, StateAwake, 0, time.Hour, PollNormal, 20 * time.Second},
+		{"固定间隔下睡眠时也停止", NewPollSchedule(20*time.Second, nil), nil, StateAsleep, 0, time.Minute, PollStopped, AsleepIdleInterval},
+		{"刚启动", adaptive, nil, StateAwake, 0, time.Minute, PollNormal, 10 * time.Second},
+		{"显示器关闭时停止", adaptive, nil, StateAsleep, 0, time.Hour, PollStopped, AsleepIdleInterval},
+		{
+			name:        "唤醒后几分钟内快速轮询",
+			schedule:    adaptive,
+			transitions: []WakeTransition{{From: StateAsleep, To: StateResuming, Input: InputResume, At: at(time.Hour)}, {From: StateResuming, To: StateAwake, Input: InputDeviceOK, At: at(time.Hour + 3*time.Second)}},
+			state:       StateAwake, since: time.Hour + 3*time.Second, now: time.Hour + 2*time.Minute,
+			wantMode: PollFast, wantInterval: 2 * time.Second,
+		},
+		{
+			name:        "唤醒窗口结束后恢复正常",
+			schedule:    adaptive,
+			transitions: []WakeTransition{{From: StateAsleep, To: StateResuming, Input: InputResume, At: at(time.Hour)}},
+			state:       StateAwake, since: time.Hour, now: time.Hour + 5*time.Minute,
+			wantMode: PollNormal, wantInterval: 10 * time.Second,
+		},
+		{"退避期间快速轮询", adaptive, nil, StateCooldown, 0, time.Hour, PollFast, 2 * time.Second},
+		{"长期稳定后放慢", adaptive, nil, StateAwake, 0, 10 * time.Minute, PollStable, 5 * time.Minute},
+		{
+			name:        "最近失败过不算稳定",
+			schedule:    adaptive,
+			transitions: []WakeTransition{{From: StateRepairing, To: StateCooldown, Input: InputRepairFailed, At: at(30 * time.Minute)}},
+			state:       StateAwake, since: 0, now: 35 * time.Minute,
+			wantMode: PollNormal, wantInterval: 10 * time.Second,
+		},
+		{"空闲时不放慢", adaptive, nil, StateDozing, 0, time.Hour, PollNormal, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPollScheduler(tt.schedule)
+			for _, tr := range tt.transitions {
+				s.Observe(tr)
+			}
+			mode, interval := s.Next(tt.state, at(tt.since), at(tt.now))
+			if mode != tt.wantMode || interval != tt.wantInterval {
+				t.Errorf("Next = (%s, %s), want (%s, %s)", mode, interval, tt.wantMode, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestPollCostMeter(t *testing.T) {
+	start := time.Date(2025, 12, 25, 7, 0, 0, 0, time.UTC)
+	var spawns int64 = 100
+	m := NewPollCostMeter(start, func() int64 { return spawns })
+
+	m.SetMode(PollFast, start)
+	for i := 0; i < 90; i++ {
+		m.RecordCheck()
+		spawns++
+	}
+	m.SetMode(PollNormal, start.Add(3*time.Minute))
+	spawns += 3 // 一次修复：禁用、启用、验证
+	m.SetMode(PollStopped, start.Add(10*time.Minute))
+
+	if m.Due(start.Add(59*time.Minute), time.Hour) || !m.Due(start.Add(2*time.Hour), time.Hour) {
+		t.Error("应在满 1 小时后报告")
+	}
+	r := m.Report(start.Add(2 * time.Hour))
+	if r.Checks != 90 || r.Spawns != 93 || r.SpawnsPerHour != 46.5 {
+		t.Errorf("报告 = %+v, want 90 次检查、93 个进程、46.5 个/小时", r)
+	}
+	if r.ModeTime[PollFast] != 3*time.Minute || r.ModeTime[PollNormal] != 7*time.Minute || r.ModeTime[PollStopped] != 110*time.Minute {
+		t.Errorf("各模式时长 = %v", r.ModeTime)
+	}
+	if s := r.String(); !strings.Contains(s, "PowerShell 进程 93 个（46.5 个/小时）") || !strings.Contains(s, "stopped 1h50m0s") {
+		t.Errorf("String = %q", s)
+	}
+
+	// 新周期从上次报告开始，沿用当前模式
+	r = m.Report(start.Add(3 * time.Hour))
+	if r.Checks != 0 || r.Spawns != 0 || r.ModeTime[PollStopped] != time.Hour {
+		t.Errorf("第二个周期 = %+v", r)
+	}
+}
@@ -0,0 +1,800 @@
+// Package main provides the device status poller that feeds idle time, device status and repair results into the wake state machine.
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeviceStatusReader 读取设备状态（由 DeviceManager 实现，测试中注入假设备）
+type DeviceStatusReader interface {
+	GetStatus(ctx context.Context) (string, error)
+}
+
+// WakeEventPoller 设备状态轮询器（备用方案）
+// 用于 Modern Standby 系统中，当电源事件不可靠时作为补充检测
+// 是否检查、是否修复由睡眠/唤醒状态机决定，轮询器只负责输入空闲时间、设备状态和修复结果
+type WakeEventPoller struct {
+	callback         func(ctx context.Context, trigger RepairTrigger) RepairResult // 修复回调（ctx 携带事件 ID），返回修复结果
+	stopChan         chan struct{}
+	wakeChan         chan RepairTrigger // 唤醒或触发条件满足后立即检查一次（发现异常时使用该触发来源）
+	scheduler        *PollScheduler     // 决定下次轮询间隔（固定或自适应）
+	cost             *PollCostMeter     // 轮询开销统计
+	reschedule       chan struct{}      // 状态变化后重新计算轮询间隔
+	mode             PollMode           // 当前轮询模式
+	policy           RetryPolicy        // 修复失败后的重试间隔策略
+	budget           RetryBudget        // 每次唤醒、每天的自动修复次数上限
+	maxRetryInterval time.Duration      // 最大重试间隔（退避上限）
+	maxRetryCount    int                // 最大连续失败次数（0=无限制）
+	lastRepairTime   time.Time          // 上次修复时间
+	consecutiveFails int                // 连续失败次数
+	currentInterval  time.Duration      // 当前重试间隔（退避用）
+	wakeAttempts     int                // 本次唤醒以来的自动修复次数
+	dayAttempts      int                // 当天的自动修复次数
+	day              string             // dayAttempts 对应的日期（本地时间 2006-01-02）
+	blocked          RetryBlock         // 次数上限已用完时等待的条件
+	pendingSince     time.Time          // 空闲时发现异常、等待用户回来修复的时间（待唤醒修复）
+	store            *PollerStateStore  // 状态文件（为空时不保存）
+	stateMaxAge      time.Duration      // 恢复状态时待修复标记和重试状态的有效期
+	lastSaved        PollerState        // 上次保存的内容（未变化时不重复写入）
+	deviceID         string
+	device           DeviceStatusReader // 读取设备状态
+	clock            Clock              // 时间来源（测试中注入假时钟）
+	idle             IdleSource         // 用户空闲时间来源
+	idleThresholds   IdleThresholds     // 空闲/活跃判定阈值
+	logger           *Logger
+	state            *WakeStateMachine                            // 睡眠/唤醒状态（与电源监控器共用）
+	onGiveUp         func(ctx context.Context, attempts int)      // 达到最大重试次数时调用（可选）
+	giveUpCooldown   time.Duration                                // 停止后经过该时长自动恢复
+	onRearm          func(ctx context.Context, reason string)     // 恢复自动修复时调用（可选）
+	rearmRequested   func() bool                                  // 返回 true 表示已在外部（-rearm）要求恢复（可选）
+	onCostReport     func(report PollCostReport)                  // 每小时报告一次轮询开销（可选）
+	flap             *FlapDetector                                // 设备状态抖动检测与隔离
+	onQuarantine     func(ctx context.Context, snap FlapSnapshot) // 进入隔离时调用（可选）
+	onRelease        func(ctx context.Context, since time.Time)   // 解除隔离时调用（可选）
+	mu               sync.Mutex
+}
+
+// PollerConfig 轮询器配置
+type PollerConfig struct {
+	BaseRetryInterval time.Duration
+	MaxRetryInterval  time.Duration
+	MaxRetryCount     int
+	StateMachine      *WakeStateMachine                            // 与电源监控器共用的状态机（为空时轮询器自己创建）
+	OnGiveUp          func(ctx context.Context, attempts int)      // 达到最大重试次数、停止自动修复时调用
+	GiveUpCooldown    time.Duration                                // 停止自动修复后经过该时长自动恢复（默认 2 小时）
+	OnRearm           func(ctx context.Context, reason string)     // 恢复自动修复时调用
+	RearmRequested    func() bool                                  // 停止期间每次轮询调用，返回 true 时恢复自动修复
+	Schedule          PollSchedule                                 // 轮询间隔（为空时每 10 秒）
+	OnCostReport      func(report PollCostReport)                  // 每小时报告一次轮询开销
+	StatePath         string                                       // 状态文件路径：保存待唤醒修复标记和重试状态，服务重启后恢复（为空时不保存）
+	StateMaxAge       time.Duration                                // 恢复状态时待修复标记和重试状态的有效期（默认 12 小时）
+	Device            DeviceStatusReader                           // 读取设备状态（为空时通过 PowerShell 查询 deviceID）
+	Clock             Clock                                        // 时间来源（为空时使用系统时钟）
+	IdleSource        IdleSource                                   // 用户空闲时间来源（为空时读取系统最后输入时间）
+	IdleThresholds    IdleThresholds                               // 空闲/活跃判定阈值（为空时 300 秒/60 秒）
+	RetryPolicy       RetryPolicy                                  // 重试间隔策略（为空时按基础间隔指数退避）
+	RetryBudget       RetryBudget                                  // 每次唤醒、每天的自动修复次数上限
+	Flap              FlapThresholds                               // 抖动检测阈值（为空时 5 分钟内切换 6 次进入隔离，稳定 15 分钟后解除）
+	OnQuarantine      func(ctx context.Context, snap FlapSnapshot) // 设备状态频繁变化、进入隔离时调用（snap 为诊断快照）
+	OnRelease         func(ctx context.Context, since time.Time)   // 隔离期间状态保持稳定、解除隔离时调用
+}
+
+// pollCostReportPeriod 轮询开销报告周期
+const pollCostReportPeriod = time.Hour
+
+// NewWakeEventPoller 创建唤醒事件轮询器
+func NewWakeEventPoller(deviceID string, callback func(ctx context.Context, trigger RepairTrigger) RepairResult, logger *Logger, cfg *PollerConfig) *WakeEventPoller {
+	baseInterval := 60 * time.Second
+	maxInterval := 10 * time.Minute
+	maxRetry := 10
+	cooldown := DefaultGiveUpCooldown
+	var state *WakeStateMachine
+	var onGiveUp func(ctx context.Context, attempts int)
+	var onRearm func(ctx context.Context, reason string)
+	var rearmRequested func() bool
+	var onCostReport func(report PollCostReport)
+	var store *PollerStateStore
+	var device DeviceStatusReader
+	var clock Clock = systemClock{}
+	var idle IdleSource = systemIdleSource{}
+	idleThresholds := DefaultIdleThresholds
+	var policy RetryPolicy
+	var budget RetryBudget
+	var onQuarantine func(ctx context.Context, snap FlapSnapshot)
+	var onRelease func(ctx context.Context, since time.Time)
+	flapThresholds := DefaultFlapThresholds
+	stateMaxAge := DefaultPollerStateMaxAge
+	schedule := NewPollSchedule(DefaultPollInterval, nil)
+
+	if cfg != nil {
+		state = cfg.StateMachine
+		onGiveUp = cfg.OnGiveUp
+		onRearm = cfg.OnRearm
+		rearmRequested = cfg.RearmRequested
+		onCostReport = cfg.OnCostReport
+		device = cfg.Device
+		if cfg.Clock != nil {
+			clock = cfg.Clock
+		}
+		if cfg.IdleSource != nil {
+			idle = cfg.IdleSource
+		}
+		policy = cfg.RetryPolicy
+		budget = cfg.RetryBudget
+		onQuarantine = cfg.OnQuarantine
+		onRelease = cfg.OnRelease
+		if cfg.Flap.Transitions > 0 {
+			flapThresholds = cfg.Flap
+		}
+		if cfg.IdleThresholds.Doze > 0 {
+			idleThresholds = cfg.IdleThresholds
+		}
+		if cfg.StatePath != "" {
+			store = NewPollerStateStore(cfg.StatePath)
+		}
+		if cfg.StateMaxAge > 0 {
+			stateMaxAge = cfg.StateMaxAge
+		}
+		if cfg.Schedule.Interval > 0 {
+			schedule = cfg.Schedule
+		}
+		if cfg.GiveUpCooldown > 0 {
+			cooldown = cfg.GiveUpCooldown
+		}
+		if cfg.BaseRetryInterval > 0 {
+			baseInterval = cfg.BaseRetryInterval
+		}
+		if cfg.MaxRetryInterval > 0 {
+			maxInterval = cfg.MaxRetryInterval
+		}
+		if cfg.MaxRetryCount >= 0 {
+			maxRetry = cfg.MaxRetryCount
+		}
+	}
+	if state == nil {
+		state = NewWakeStateMachine(clock)
+		state.SetLogger(logger)
+	}
+	if policy == nil {
+		policy = ExponentialPolicy{Base: baseInterval, Max: maxInterval, Jitter: JitterNone}
+	}
+	if device == nil {
+		dm := NewDeviceManager(deviceID)
+		dm.SetLogger(logger)
+		device = dm
+	}
+
+	p := &WakeEventPoller{
+		callback:         callback,
+		stopChan:         make(chan struct{}),
+		wakeChan:         make(chan RepairTrigger, 1),
+		scheduler:        NewPollScheduler(schedule),
+		cost:             NewPollCostMeter(clock.Now(), nil),
+		reschedule:       make(chan struct{}, 1),
+		policy:           policy,
+		budget:           budget,
+		maxRetryInterval: maxInterval,
+		maxRetryCount:    maxRetry,
+		currentInterval:  policy.Initial(),
+		deviceID:         deviceID,
+		device:           device,
+		clock:            clock,
+		idle:             idle,
+		idleThresholds:   idleThresholds,
+		logger:           logger,
+		state:            state,
+		onGiveUp:         onGiveUp,
+		giveUpCooldown:   cooldown,
+		onRearm:          onRearm,
+		rearmRequested:   rearmRequested,
+		onCostReport:     onCostReport,
+		flap:             NewFlapDetector(flapThresholds),
+		onQuarantine:     onQuarantine,
+		onRelease:        onRelease,
+		store:            store,
+		stateMaxAge:      stateMaxAge,
+	}
+	state.OnTransition(p.onTransition)
+	return p
+}
+
+// Start 恢复上次保存的状态并开始轮询
+func (p *WakeEventPoller) Start() {
+	p.restoreState()
+	go p.poll()
+}
+
+// restoreState 从状态文件恢复待唤醒修复标记和重试状态（过期或不合理的部分丢弃）
+func (p *WakeEventPoller) restoreState() {
+	if p.store == nil {
+		return
+	}
+	saved, err := p.store.Load()
+	if err != nil {
+		p.logger.WarningTag(TagService, "忽略轮询器状态文件: %v", err)
+		return
+	}
+	if saved == nil {
+		return
+	}
+
+	now := p.clock.Now()
+	state, dropped := saved.Sanitize(now, PollerStateLimits{
+		MaxInterval:   max(p.maxRetryInterval, policyMaxStep(p.policy)),
+		MaxRetryCount: p.maxRetryCount,
+		MaxAge:        p.stateMaxAge,
+	})
+	for _, reason := range dropped {
+		p.logger.InfoTag(TagService, "丢弃保存的轮询器状态: %s", reason)
+	}
+
+	p.mu.Lock()
+	p.pendingSince = state.PendingSince
+	p.wakeAttempts = state.WakeAttempts
+	p.dayAttempts, p.day = state.DayAttempts, state.Day
+	p.consecutiveFails = state.ConsecutiveFails
+	p.lastRepairTime = state.LastRepairTime
+	if state.IntervalSecs > 0 {
+		p.currentInterval = time.Duration(state.IntervalSecs) * time.Second
+	}
+	p.mu.Unlock()
+	p.state.Restore(state.WakeState, state.StateSince)
+	if !state.QuarantinedSince.IsZero() {
+		// 重启前处于隔离：从现在起重新计算稳定时长
+		p.flap.Restore(state.QuarantinedSince, now)
+		p.logger.InfoTag(TagFlap, "已恢复隔离状态 (自 %s)，%s 前不自动修复",
+			state.QuarantinedSince.Format("2006-01-02 15:04:05"), p.flap.ReleaseAt().Format("2006-01-02 15:04:05"))
+	}
+
+	if state.Pending() || state.ConsecutiveFails > 0 {
+		p.logger.InfoTag(TagService, "已恢复轮询器状态 (保存于 %s): 状态=%s, 待唤醒修复=%v, 连续失败 %d 次, 重试间隔 %s",
+			saved.SavedAt.Format("2006-01-02 15:04:05"), state.WakeState, state.Pending(), state.ConsecutiveFails, p.currentInterval)
+	}
+	p.saveState()
+}
+
+// snapshot 当前需要保存的状态
+func (p *WakeEventPoller) snapshot() PollerState {
+	wakeState, since := p.state.State(), p.state.Since()
+	next := p.NextAttempt()
+	quarantinedSince, releaseAt := p.flap.QuarantinedSince(), p.flap.ReleaseAt()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PollerState{
+		SavedAt:           p.clock.Now(),
+		QuarantinedSince:  quarantinedSince,
+		QuarantineRelease: releaseAt,
+		NextAttempt:       next,
+		RetryPolicy:       p.policy.String(),
+		WakeAttempts:      p.wakeAttempts,
+		DayAttempts:       p.dayAttempts,
+		Day:               p.day,
+		Blocked:           p.blocked,
+		WakeState:         wakeState,
+		StateSince:        since,
+		PendingSince:      p.pendingSince,
+		ConsecutiveFails:  p.consecutiveFails,
+		IntervalSecs:      int(p.currentInterval / time.Second),
+		LastRepairTime:    p.lastRepairTime,
+	}
+}
+
+// saveState 保存状态到状态文件（内容未变化时跳过）
+func (p *WakeEventPoller) saveState() {
+	if p.store == nil {
+		return
+	}
+	state := p.snapshot()
+	p.mu.Lock()
+	unchanged := state.sameAs(p.lastSaved)
+	p.mu.Unlock()
+	if unchanged {
+		return
+	}
+	if err := p.store.Save(state); err != nil {
+		p.logger.WarningTag(TagService, "%v", err)
+		return
+	}
+	p.mu.Lock()
+	p.lastSaved = state
+	p.mu.Unlock()
+}
+
+// Pending 是否有待唤醒修复标记
+func (p *WakeEventPoller) Pending() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.pendingSince.IsZero()
+}
+
+// setPending 设置或清除待唤醒修复标记
+func (p *WakeEventPoller) setPending(pending bool) {
+	p.mu.Lock()
+	changed := pending == p.pendingSince.IsZero()
+	if changed {
+		if pending {
+			p.pendingSince = p.clock.Now()
+		} else {
+			p.pendingSince = time.Time{}
+		}
+	}
+	p.mu.Unlock()
+	if changed {
+		p.saveState()
+	}
+}
+
+// Stop 停止轮询
+func (p *WakeEventPoller) Stop() {
+	close(p.stopChan)
+}
+
+// Pause 暂停轮询（系统睡眠时调用）
+func (p *WakeEventPoller) Pause() {
+	p.state.Fire(InputSuspend, "暂停轮询")
+}
+
+// Resume 系统唤醒时调用：进入 resuming 状态，并在系统稳定后立即检查一次设备状态
+func (p *WakeEventPoller) Resume() {
+	p.state.Fire(InputResume, "恢复轮询")
+	p.CheckNow(RepairTrigger{Source: TriggerPending, Reason: "唤醒后设备仍异常"})
+}
+
+// CheckNow 在系统稳定后立即检查一次设备状态，发现异常时以 trigger 作为触发来源修复
+// 尚未处理的检查请求会被新的请求替换
+func (p *WakeEventPoller) CheckNow(trigger RepairTrigger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.wakeChan:
+	default:
+	}
+	p.wakeChan <- trigger
+}
+
+// IsPaused 检查是否已暂停（处于睡眠状态）
+func (p *WakeEventPoller) IsPaused() bool {
+	return p.state.State() == StateAsleep
+}
+
+// ResetRetryState 重置重试状态（修复成功后调用）
+func (p *WakeEventPoller) ResetRetryState() {
+	p.mu.Lock()
+	p.consecutiveFails = 0
+	p.currentInterval = p.policy.Initial()
+	p.mu.Unlock()
+	p.saveState()
+}
+
+// GetConsecutiveFails 获取连续失败次数
+func (p *WakeEventPoller) GetConsecutiveFails() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.consecutiveFails
+}
+
+// incrementFails 增加失败计数并更新退避间隔
+func (p *WakeEventPoller) incrementFails() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.consecutiveFails++
+
+	// 检查是否超过最大重试次数
+	if p.maxRetryCount > 0 && p.consecutiveFails >= p.maxRetryCount {
+		p.logger.WithFields(LogFields{Device: p.deviceID, Attempt: p.consecutiveFails, Trigger: "poll"}).
+			WarningTag(TagFail, "连续失败 %d 次，已达到最大重试次数，停止自动修复", p.consecutiveFails)
+		return false // 返回 false 表示应该停止重试
+	}
+
+	// 按重试策略计算下次间隔
+	p.currentInterval = p.policy.Next(p.consecutiveFails, p.currentInterval)
+
+	p.logger.WithFields(LogFields{Device: p.deviceID, Attempt: p.consecutiveFails, Trigger: "poll"}).
+		InfoTag(TagService, "连续失败 %d 次，下次重试间隔: %v (%s)", p.consecutiveFails, p.currentInterval.Round(time.Second), p.policy)
+	return true
+}
+
+// recordFailure 记录一次修复失败；达到最大重试次数时进入停止状态、通知并返回 false
+func (p *WakeEventPoller) recordFailure(ctx context.Context) bool {
+	if p.incrementFails() {
+		p.state.Fire(InputRepairFailed, fmt.Sprintf("连续失败 %d 次", p.GetConsecutiveFails()))
+		return true
+	}
+
+	attempts := p.GetConsecutiveFails()
+	p.state.Fire(InputGiveUp, fmt.Sprintf("连续失败 %d 次", attempts))
+	if p.onGiveUp != nil {
+		p.onGiveUp(ctx, attempts)
+	}
+	return false
+}
+
+// GaveUp 是否已停止自动修复
+func (p *WakeEventPoller) GaveUp() bool {
+	return p.state.State() == StateGaveUp
+}
+
+// GiveUpUntil 返回停止状态自动恢复的时间（未停止时返回零值）
+func (p *WakeEventPoller) GiveUpUntil() time.Time {
+	if !p.GaveUp() {
+		return time.Time{}
+	}
+	return p.state.Since().Add(p.giveUpCooldown)
+}
+
+// Rearm 退出停止状态，恢复自动修复；reason 说明恢复原因（cooldown、resume、manual）
+// 当前不处于停止状态时返回 false
+func (p *WakeEventPoller) Rearm(ctx context.Context, reason string) bool {
+	if !p.GaveUp() {
+		return false
+	}
+	return p.state.Fire(InputRearm, reason).Changed()
+}
+
+// onTransition 状态变化后重新计算轮询间隔；离开停止状态时清零重试计数并通知（无论是冷却结束、-rearm、唤醒还是设备自行恢复）
+func (p *WakeEventPoller) onTransition(t WakeTransition) {
+	p.scheduler.Observe(t)
+	select {
+	case p.reschedule <- struct{}{}:
+	default:
+	}
+
+	defer p.saveState()
+
+	// 开始修复或设备已正常：待唤醒修复标记完成
+	if t.To == StateAwake || t.To == StateRepairing {
+		p.mu.Lock()
+		p.pendingSince = time.Time{}
+		p.mu.Unlock()
+	}
+	// 真正的唤醒开始新的唤醒周期，每次唤醒的修复次数重新计算
+	if t.Input == InputResume {
+		p.mu.Lock()
+		p.wakeAttempts = 0
+		if p.blocked == RetryBlockedWake {
+			p.blocked = RetryNotBlocked
+		}
+		p.mu.Unlock()
+	}
+
+	if t.From != StateGaveUp {
+		return
+	}
+	reason := t.Reason
+	switch t.Input {
+	case InputResume:
+		reason = "resume"
+	case InputDeviceOK:
+		reason = "recovered"
+	}
+
+	p.mu.Lock()
+	p.consecutiveFails = 0
+	p.currentInterval = p.policy.Initial()
+	p.lastRepairTime = time.Time{}
+	p.mu.Unlock()
+
+	p.logger.InfoTag(TagService, "恢复自动修复 (原因: %s)", reason)
+	if p.onRearm != nil {
+		p.onRearm(context.Background(), reason)
+	}
+}
+
+// checkRearm 停止期间检查是否应恢复自动修复：冷却时间已过，或外部要求恢复
+func (p *WakeEventPoller) checkRearm() {
+	if until := p.GiveUpUntil(); !until.IsZero() && !p.clock.Now().Before(until) {
+		p.Rearm(context.Background(), "cooldown")
+		return
+	}
+	if p.rearmRequested != nil && p.rearmRequested() {
+		p.Rearm(context.Background(), "manual")
+	}
+}
+
+// retryDue 退避间隔是否已到
+func (p *WakeEventPoller) retryDue() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.clock.Now().Sub(p.lastRepairTime) > p.currentInterval
+}
+
+// NextAttempt 返回下次自动重试的时间（不在退避中或次数上限已用完时返回零值）
+func (p *WakeEventPoller) NextAttempt() time.Time {
+	if p.state.State() != StateCooldown {
+		return time.Time{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.consecutiveFails == 0 || p.blocked != RetryNotBlocked {
+		return time.Time{}
+	}
+	return p.lastRepairTime.Add(p.currentInterval)
+}
+
+// today 当前日期（本地时间）
+func (p *WakeEventPoller) today() string {
+	return p.clock.Now().Format("2006-01-02")
+}
+
+// budgetAllows 检查每次唤醒和每天的修复次数上限；用完时记录一次日志并返回 false
+func (p *WakeEventPoller) budgetAllows() bool {
+	p.mu.Lock()
+	if p.day != p.today() {
+		p.day, p.dayAttempts = p.today(), 0
+		if p.blocked == RetryBlockedDay {
+			p.blocked = RetryNotBlocked
+		}
+	}
+	blocked := RetryNotBlocked
+	switch {
+	case p.budget.MaxPerDay > 0 && p.dayAttempts >= p.budget.MaxPerDay:
+		blocked = RetryBlockedDay
+	case p.budget.MaxPerWake > 0 && p.wakeAttempts >= p.budget.MaxPerWake:
+		blocked = RetryBlockedWake
+	}
+	changed := blocked != p.blocked
+	p.blocked = blocked
+	wakeAttempts, dayAttempts := p.wakeAttempts, p.dayAttempts
+	p.mu.Unlock()
+
+	if !changed {
+		return blocked == RetryNotBlocked
+	}
+	switch blocked {
+	case RetryBlockedWake:
+		p.logger.WarningTag(TagSkip, "本次唤醒已自动修复 %d 次，达到 max_per_wake，等待下次唤醒", wakeAttempts)
+	case RetryBlockedDay:
+		p.logger.WarningTag(TagSkip, "今天已自动修复 %d 次，达到 max_per_day，明天再重试", dayAttempts)
+	}
+	p.saveState()
+	return blocked == RetryNotBlocked
+}
+
+// recordAttempt 记录一次自动修复（计入每次唤醒和每天的次数）
+func (p *WakeEventPoller) recordAttempt() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.day != p.today() {
+		p.day, p.dayAttempts = p.today(), 0
+	}
+	p.wakeAttempts++
+	p.dayAttempts++
+}
+
+// policyMaxStep 固定间隔表中的最大间隔（其他策略返回 0，由 max_retry_interval 限制）
+func policyMaxStep(policy RetryPolicy) time.Duration {
+	var longest time.Duration
+	if fixed, ok := policy.(FixedSchedulePolicy); ok {
+		for _, d := range fixed.Steps {
+			longest = max(longest, d)
+		}
+	}
+	return longest
+}
+
+// Quarantined 设备是否因状态频繁变化处于隔离中
+func (p *WakeEventPoller) Quarantined() bool {
+	return p.flap.Quarantined()
+}
+
+// observeFlap 把设备状态交给抖动检测：频繁切换时进入隔离并保存诊断快照，隔离期间状态稳定后解除
+func (p *WakeEventPoller) observeFlap(status string) {
+	now := p.clock.Now()
+	since := p.flap.QuarantinedSince()
+	switch p.flap.Observe(status, now) {
+	case FlapQuarantined:
+		ctx, episode := NewEpisodeContext(context.Background())
+		snap := p.flapSnapshot(now)
+		p.logger.WithFields(LogFields{Device: p.deviceID, Status: status, Trigger: "poll", Episode: episode}).
+			WarningTag(TagFlap, "设备状态频繁变化（%s），进入隔离：暂停自动修复，状态稳定 %s 后恢复", snap.Summary(), p.flap.Thresholds().Stable)
+		p.saveState()
+		if p.onQuarantine != nil {
+			p.onQuarantine(ctx, snap)
+		}
+	case FlapReleased:
+		p.logger.WithFields(LogFields{Device: p.deviceID, Status: status, Trigger: "poll"}).
+			InfoTag(TagFlap, "设备状态已稳定 %s，解除隔离（隔离了 %s）", p.flap.Thresholds().Stable, now.Sub(since).Round(time.Second))
+		p.saveState()
+		if p.onRelease != nil {
+			p.onRelease(context.Background(), since)
+		}
+	}
+}
+
+// flapSnapshot 进入隔离时的诊断快照（服务保存到诊断目录前可补充环境信息）
+func (p *WakeEventPoller) flapSnapshot(now time.Time) FlapSnapshot {
+	idleSecs := -1
+	if idle, err := p.idle.IdleTime(); err == nil {
+		idleSecs = int(idle / time.Second)
+	}
+	thresholds := p.flap.Thresholds()
+	return FlapSnapshot{
+		Time:        now,
+		DeviceID:    p.deviceID,
+		Transitions: p.flap.Transitions(now),
+		WindowSecs:  int(thresholds.Window / time.Second),
+		ReleaseAt:   p.flap.ReleaseAt(),
+		IdleSecs:    idleSecs,
+		Samples:     p.flap.Samples(),
+		Poller:      p.snapshot(),
+	}
+}
+
+// poll 轮询设备状态
+func (p *WakeEventPoller) poll() {
+	// 启动时立即检查一次
+	p.check(&RepairTrigger{Source: TriggerPoll, Reason: "服务启动时设备异常"})
+
+	for {
+		// 按当前状态决定下次轮询：睡眠或显示器关闭时只慢速检查是否有用户输入
+		tick := p.clock.After(p.nextPoll())
+
+		select {
+		case <-p.stopChan:
+			p.reportCost()
+			return
+
+		case trigger := <-p.wakeChan:
+			// 唤醒后短暂等待系统稳定，再立即检查
+			select {
+			case <-p.stopChan:
+				return
+			case <-p.clock.After(2 * time.Second):
+			}
+			p.check(&trigger)
+
+		case <-p.reschedule:
+			// 状态变化，重新计算间隔
+
+		case <-tick:
+			p.check(nil)
+		}
+
+		if p.cost.Due(p.clock.Now(), pollCostReportPeriod) {
+			p.reportCost()
+		}
+	}
+}
+
+// nextPoll 返回到下次轮询的间隔，轮询模式变化时记录
+func (p *WakeEventPoller) nextPoll() time.Duration {
+	now := p.clock.Now()
+	mode, interval := p.scheduler.Next(p.state.State(), p.state.Since(), now)
+	p.mu.Lock()
+	changed := mode != p.mode
+	from := p.mode
+	p.mode = mode
+	p.mu.Unlock()
+	if changed {
+		p.logger.DebugTag(TagCheck, "轮询模式: %s → %s (间隔: %s)", from, mode, interval)
+		p.cost.SetMode(mode, now)
+	}
+	return interval
+}
+
+// reportCost 记录并报告上一周期的轮询开销
+func (p *WakeEventPoller) reportCost() {
+	report := p.cost.Report(p.clock.Now())
+	p.logger.InfoTag(TagService, "轮询开销: %s", report)
+	if p.onCostReport != nil {
+		p.onCostReport(report)
+	}
+}
+
+// Schedule 轮询间隔设置
+func (p *WakeEventPoller) Schedule() PollSchedule {
+	return p.scheduler.Schedule()
+}
+
+// activeWhileAsleep 睡眠（显示器关闭）后是否有用户输入，有则进入 resuming；只读取空闲时间，不读取设备状态
+// 只有晚于进入睡眠的输入才算（关闭显示器前的最后一次按键不算）
+func (p *WakeEventPoller) activeWhileAsleep() bool {
+	idle, err := p.idle.IdleTime()
+	if err != nil || idle >= p.clock.Now().Sub(p.state.Since()) || !p.idleThresholds.IsActive(idle) {
+		return false
+	}
+	p.logger.InfoTag(TagResume, "未收到唤醒事件，但检测到用户输入（空闲 %d 秒），恢复轮询", int(idle/time.Second))
+	p.state.Fire(InputActive, fmt.Sprintf("睡眠后有用户输入，空闲 %d 秒", int(idle/time.Second)))
+	return true
+}
+
+// check 检查一次：把空闲时间和设备状态输入状态机，进入 repairing 时执行修复
+// override 非空时作为 awake/resuming 状态下发现异常的触发来源（CheckNow 和服务启动时）
+func (p *WakeEventPoller) check(override *RepairTrigger) {
+	switch p.state.State() {
+	case StateAsleep:
+		// 睡眠期间不检查设备状态；没有收到唤醒事件但用户已在使用时恢复
+		if !p.activeWhileAsleep() {
+			return
+		}
+	case StateGaveUp:
+		// 冷却结束或 -rearm 时恢复，否则只检查状态不再触发修复
+		p.checkRearm()
+	}
+
+	// 无法获取空闲时间时视为活跃
+	idle, err := p.idle.IdleTime()
+	active := err != nil || p.idleThresholds.IsActive(idle)
+	idleSec := int(idle / time.Second)
+	switch {
+	case err != nil:
+	case p.idleThresholds.IsIdle(idle):
+		p.state.Fire(InputIdle, fmt.Sprintf("空闲 %d 秒", idleSec))
+	case active:
+		p.state.Fire(InputActive, fmt.Sprintf("空闲 %d 秒", idleSec))
+	}
+
+	p.cost.RecordCheck()
+	status, err := p.device.GetStatus(context.Background())
+	if err != nil {
+		return
+	}
+	p.observeFlap(status)
+	if isDeviceOK(status) {
+		p.setPending(false)
+		p.state.Fire(InputDeviceOK, "设备状态正常")
+		return
+	}
+	if p.flap.Quarantined() {
+		p.logger.DebugTag(TagFlap, "设备状态异常 (%s)，但设备处于隔离中，%s 前不自动修复",
+			status, p.flap.ReleaseAt().Format("15:04:05"))
+		return
+	}
+
+	from := p.state.State()
+	trigger := RepairTrigger{Source: TriggerPoll, Reason: "状态变化"}
+	switch from {
+	case StateAwake, StateResuming:
+		if override != nil {
+			trigger = *override
+		} else if from == StateResuming {
+			trigger = RepairTrigger{Source: TriggerPending, Reason: "唤醒后设备仍异常"}
+		}
+		if !p.budgetAllows() {
+			return
+		}
+	case StateCooldown:
+		// 持续异常：仅在系统活跃且退避间隔已到时重试，避免睡眠中不断重试
+		if !active || !p.retryDue() || !p.budgetAllows() {
+			return
+		}
+		p.state.Fire(InputRetryDue, "持续异常")
+		trigger.Reason = "持续异常"
+	case StateDozing:
+		p.logger.DebugTag(TagCheck, "设备状态异常 (%s)，但系统长时间空闲（%d秒），等待用户回来后修复", status, idleSec)
+		p.setPending(true)
+		return
+	default:
+		return
+	}
+	p.state.Fire(InputDeviceError, "设备状态: "+status)
+	if p.state.State() != StateRepairing {
+		return
+	}
+
+	ctx, _ := NewEpisodeContext(context.Background())
+	p.logger.WithContext(ctx).InfoTag(TagResume, "检测到设备状态异常 (轮询-%s): %s", trigger.Reason, status)
+	if p.callback == nil {
+		return
+	}
+	result := p.callback(ctx, trigger)
+	p.mu.Lock()
+	p.lastRepairTime = p.clock.Now()
+	p.mu.Unlock()
+	switch result {
+	case RepairFixed, RepairSkipped:
+		p.recordAttempt()
+		p.ResetRetryState()
+		p.state.Fire(InputRepairOK, "修复成功")
+	case RepairVetoed:
+		// pre_reset 钩子阻止了重置：没有尝试修复，不计入失败次数和重试次数上限，按当前间隔稍后再试
+		p.state.Fire(InputRepairFailed, "pre_reset 钩子推迟了修复")
+	default:
+		p.recordAttempt()
+		p.recordFailure(ctx)
+	}
+}
@@ -0,0 +1,230 @@
+// Package main provides persistence of the poller's pending-repair mark and retry state across service restarts.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pollerStateVersion 状态文件格式版本，不一致时丢弃
+const pollerStateVersion = 1
+
+// PollerStateFileName 轮询器状态文件名（位于统计目录）
+const PollerStateFileName = "poller_state.json"
+
+// DefaultPollerStateMaxAge 待修复标记和重试状态的默认有效期，超过后服务启动时丢弃
+const DefaultPollerStateMaxAge = 12 * time.Hour
+
+// PollerState 轮询器需要跨服务重启保留的状态
+type PollerState struct {
+	Version          int       `json:"version"`
+	SavedAt          time.Time `json:"saved_at"`
+	WakeState        WakeState `json:"wake_state"`                 // 保存时的睡眠/唤醒状态
+	StateSince       time.Time `json:"state_since"`                // 进入该状态的时间
+	PendingSince     time.Time `json:"pending_since,omitempty"`    // 发现异常但等待用户回来修复的时间（待唤醒修复）
+	ConsecutiveFails int       `json:"consecutive_fails"`          // 连续失败次数
+	IntervalSecs     int       `json:"current_interval_secs"`      // 当前退避间隔（秒）
+	LastRepairTime   time.Time `json:"last_repair_time,omitempty"` // 上次修复时间
+
+	// 重试计划（供 -status 显示）
+	NextAttempt  time.Time  `json:"next_attempt,omitempty"`  // 下次自动重试时间
+	RetryPolicy  string     `json:"retry_policy,omitempty"`  // 重试策略说明
+	WakeAttempts int        `json:"wake_attempts,omitempty"` // 本次唤醒以来的自动修复次数
+	DayAttempts  int        `json:"day_attempts,omitempty"`  // 当天的自动修复次数
+	Day          string     `json:"day,omitempty"`           // DayAttempts 对应的日期
+	Blocked      RetryBlock `json:"blocked,omitempty"`       // 次数上限已用完时等待的条件
+
+	// 设备状态频繁变化时的隔离
+	QuarantinedSince  time.Time `json:"quarantined_since,omitempty"`  // 进入隔离的时间
+	QuarantineRelease time.Time `json:"quarantine_release,omitempty"` // 状态保持不变时预计解除隔离的时间
+}
+
+// RetryBlock 自动修复次数上限用完后等待的条件
+type RetryBlock string
+
+const (
+	RetryNotBlocked  RetryBlock = ""     // 未达到上限
+	RetryBlockedWake RetryBlock = "wake" // 本次唤醒的次数已用完，等待下次唤醒
+	RetryBlockedDay  RetryBlock = "day"  // 当天的次数已用完，等待第二天
+)
+
+// Pending 是否有待唤醒修复标记
+func (s PollerState) Pending() bool {
+	return !s.PendingSince.IsZero()
+}
+
+// sameAs 除保存时间外内容是否相同（内容未变化时不重复写入）
+func (s PollerState) sameAs(other PollerState) bool {
+	s.SavedAt, other.SavedAt = time.Time{}, time.Time{}
+	return s.WakeState == other.WakeState && s.StateSince.Equal(other.StateSince) &&
+		s.PendingSince.Equal(other.PendingSince) && s.ConsecutiveFails == other.ConsecutiveFails &&
+		s.IntervalSecs == other.IntervalSecs && s.LastRepairTime.Equal(other.LastRepairTime) &&
+		s.NextAttempt.Equal(other.NextAttempt) && s.RetryPolicy == other.RetryPolicy &&
+		s.WakeAttempts == other.WakeAttempts && s.DayAttempts == other.DayAttempts && s.Day == other.Day && s.Blocked == other.Blocked &&
+		s.QuarantinedSince.Equal(other.QuarantinedSince) && s.QuarantineRelease.Equal(other.QuarantineRelease)
+}
+
+// StatusLines -status 显示的隔离状态、重试计划和已修复次数；policy 为空时显示服务保存的策略说明
+func (s PollerState) StatusLines(policy RetryPolicy) []string {
+	var lines []string
+	if !s.QuarantinedSince.IsZero() {
+		lines = append(lines, T("cli.status.quarantined", s.QuarantinedSince.Format("2006-01-02 15:04:05"),
+			s.QuarantineRelease.Format("2006-01-02 15:04:05")))
+	}
+	switch {
+	case s.Blocked == RetryBlockedWake:
+		lines = append(lines, T("cli.status.retry_blocked_wake", s.WakeAttempts))
+	case s.Blocked == RetryBlockedDay:
+		lines = append(lines, T("cli.status.retry_blocked_day", s.DayAttempts))
+	case !s.NextAttempt.IsZero():
+		lines = append(lines, T("cli.status.next_retry", s.NextAttempt.Format("2006-01-02 15:04:05"), s.ConsecutiveFails))
+	}
+	// 保存的策略说明使用服务的界面语言，能从配置重新生成时按当前语言显示
+	if s.RetryPolicy != "" {
+		desc := s.RetryPolicy
+		if policy != nil {
+			desc = policy.String()
+		}
+		lines = append(lines, T("cli.status.retry_budget", desc, s.WakeAttempts, s.DayAttempts))
+	}
+	return lines
+}
+
+// PollerStateLimits 恢复状态时的合理性检查参数
+type PollerStateLimits struct {
+	BaseInterval  time.Duration // 退避间隔下限
+	MaxInterval   time.Duration // 退避间隔上限
+	MaxRetryCount int           // 最大连续失败次数（0=无限制）
+	MaxAge        time.Duration // 待修复标记和重试状态的有效期
+}
+
+// Sanitize 检查恢复的状态是否合理，返回可用的部分和被丢弃部分的说明
+// 停止自动修复状态不恢复：服务启动时清除并重新计数
+func (s PollerState) Sanitize(now time.Time, limits PollerStateLimits) (PollerState, []string) {
+	var dropped []string
+	clean := PollerState{Version: pollerStateVersion, SavedAt: s.SavedAt, WakeState: StateAwake, StateSince: now}
+
+	if s.Version != pollerStateVersion {
+		return clean, []string{fmt.Sprintf("状态文件版本 %d 不受支持", s.Version)}
+	}
+	if s.SavedAt.After(now.Add(time.Minute)) {
+		return clean, []string{fmt.Sprintf("保存时间 %s 晚于当前时间（系统时间可能已调整）", s.SavedAt.Format(time.RFC3339))}
+	}
+	fresh := func(at time.Time) bool {
+		return limits.MaxAge <= 0 || now.Sub(at) <= limits.MaxAge
+	}
+
+	if s.Pending() {
+		if fresh(s.PendingSince) && !s.PendingSince.After(now) {
+			clean.PendingSince = s.PendingSince
+		} else {
+			dropped = append(dropped, fmt.Sprintf("待唤醒修复标记已过期（%s）", s.PendingSince.Format(time.RFC3339)))
+		}
+	}
+
+	// 隔离在有效期内保留（恢复后重新计算稳定时长）
+	if !s.QuarantinedSince.IsZero() {
+		if fresh(s.QuarantinedSince) && !s.QuarantinedSince.After(now) {
+			clean.QuarantinedSince = s.QuarantinedSince
+		} else {
+			dropped = append(dropped, fmt.Sprintf("隔离状态已过期（%s）", s.QuarantinedSince.Format(time.RFC3339)))
+		}
+	}
+
+	// 当天的修复次数只在同一天内有效；本次唤醒的次数随重试状态一起恢复
+	if s.Day == now.Format("2006-01-02") {
+		clean.Day, clean.DayAttempts = s.Day, s.DayAttempts
+	}
+	if !s.LastRepairTime.IsZero() && fresh(s.LastRepairTime) {
+		clean.WakeAttempts = s.WakeAttempts
+	}
+
+	switch {
+	case s.ConsecutiveFails <= 0:
+	case s.WakeState == StateGaveUp || (limits.MaxRetryCount > 0 && s.ConsecutiveFails >= limits.MaxRetryCount):
+		dropped = append(dropped, fmt.Sprintf("已停止自动修复（连续失败 %d 次），重新计数", s.ConsecutiveFails))
+	case s.LastRepairTime.IsZero() || !fresh(s.LastRepairTime):
+		dropped = append(dropped, fmt.Sprintf("重试状态已过期（连续失败 %d 次）", s.ConsecutiveFails))
+	default:
+		clean.ConsecutiveFails = s.ConsecutiveFails
+		clean.LastRepairTime = s.LastRepairTime
+		if clean.LastRepairTime.After(now) {
+			clean.LastRepairTime = now
+		}
+		interval := time.Duration(s.IntervalSecs) * time.Second
+		if interval < limits.BaseInterval {
+			interval = limits.BaseInterval
+		}
+		if limits.MaxInterval > 0 && interval > limits.MaxInterval {
+			interval = limits.MaxInterval
+		}
+		clean.IntervalSecs = int(interval / time.Second)
+	}
+
+	// 修复失败后的退避和待唤醒修复可以恢复；挂起、唤醒中、修复中等瞬时状态从 awake 重新开始
+	switch {
+	case clean.ConsecutiveFails > 0 && s.WakeState == StateCooldown:
+		clean.WakeState, clean.StateSince = StateCooldown, s.StateSince
+	case clean.Pending():
+		clean.WakeState, clean.StateSince = StateDozing, clean.PendingSince
+	}
+	if clean.StateSince.After(now) {
+		clean.StateSince = now
+	}
+	return clean, dropped
+}
+
+// PollerStateStore 轮询器状态文件
+type PollerStateStore struct {
+	path string
+}
+
+// NewPollerStateStore 创建状态文件存储
+func NewPollerStateStore(path string) *PollerStateStore {
+	return &PollerStateStore{path: path}
+}
+
+// Path 状态文件路径
+func (s *PollerStateStore) Path() string {
+	return s.path
+}
+
+// Load 读取状态文件；文件不存在时返回 nil
+func (s *PollerStateStore) Load() (*PollerState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取轮询器状态失败: %w", err)
+	}
+	var state PollerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析轮询器状态失败: %w", err)
+	}
+	return &state, nil
+}
+
+// Save 保存状态（先写临时文件再重命名，避免读取到写了一半的文件）
+func (s *PollerStateStore) Save(state PollerState) error {
+	state.Version = pollerStateVersion
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建状态目录失败: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("保存轮询器状态失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("保存轮询器状态失败: %w", err)
+	}
+	return nil
+}
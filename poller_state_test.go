@@ -0,0 +1,238 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollerState_Sanitize(t *testing.T) {
+	now := time.Date(2025, 12, 25, 9, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	limits := PollerStateLimits{BaseInterval: time.Minute, MaxInterval: 10 * time.Minute, MaxRetryCount: 10, MaxAge: 12 * time.Hour}
+
+	tests := []struct {
+		name        string
+		saved       PollerState
+		want        PollerState // 只比较状态、待修复、失败次数、间隔和隔离时间
+		wantDropped string
+	}{
+		{
+			name:  "恢复退避状态",
+			saved: PollerState{Version: 1, SavedAt: ago(time.Minute), WakeState: StateCooldown, StateSince: ago(3 * time.Minute), ConsecutiveFails: 3, IntervalSecs: 480, LastRepairTime: ago(3 * time.Minute)},
+			want:  PollerState{WakeState: StateCooldown, StateSince: ago(3 * time.Minute), ConsecutiveFails: 3, IntervalSecs: 480},
+		},
+		{
+			name:  "恢复待唤醒修复",
+			saved: PollerState{Version: 1, SavedAt: ago(time.Hour), WakeState: StateDozing, StateSince: ago(2 * time.Hour), PendingSince: ago(90 * time.Minute)},
+			want:  PollerState{WakeState: StateDozing, StateSince: ago(90 * time.Minute), PendingSince: ago(90 * time.Minute)},
+		},
+		{
+			name:        "待修复标记过期",
+			saved:       PollerState{Version: 1, SavedAt: ago(13 * time.Hour), WakeState: StateDozing, PendingSince: ago(13 * time.Hour)},
+			want:        PollerState{WakeState: StateAwake, StateSince: now},
+			wantDropped: "待唤醒修复标记已过期",
+		},
+		{
+			name:        "重试状态过期",
+			saved:       PollerState{Version: 1, SavedAt: ago(20 * time.Hour), WakeState: StateCooldown, ConsecutiveFails: 4, IntervalSecs: 600, LastRepairTime: ago(20 * time.Hour)},
+			want:        PollerState{WakeState: StateAwake, StateSince: now},
+			wantDropped: "重试状态已过期",
+		},
+		{
+			name:        "已停止自动修复时重新计数",
+			saved:       PollerState{Version: 1, SavedAt: ago(time.Minute), WakeState: StateGaveUp, ConsecutiveFails: 10, IntervalSecs: 600, LastRepairTime: ago(time.Minute)},
+			want:        PollerState{WakeState: StateAwake, StateSince: now},
+			wantDropped: "重新计数",
+		},
+		{
+			name:  "退避间隔超出范围时截断",
+			saved: PollerState{Version: 1, SavedAt: ago(time.Minute), WakeState: StateCooldown, StateSince: ago(time.Minute), ConsecutiveFails: 2, IntervalSecs: 86400, LastRepairTime: ago(time.Minute)},
+			want:  PollerState{WakeState: StateCooldown, StateSince: ago(time.Minute), ConsecutiveFails: 2, IntervalSecs: 600},
+		},
+		{
+			name:  "修复中重启从 awake 开始，保留失败次数",
+			saved: PollerState{Version: 1, SavedAt: ago(time.Minute), WakeState: StateRepairing, StateSince: ago(time.Minute), ConsecutiveFails: 1, IntervalSecs: 120, LastRepairTime: ago(5 * time.Minute)},
+			want:  PollerState{WakeState: StateAwake, StateSince: now, ConsecutiveFails: 1, IntervalSecs: 120},
+		},
+		{
+			name:        "保存时间晚于当前时间",
+			saved:       PollerState{Version: 1, SavedAt: now.Add(time.Hour), WakeState: StateCooldown, ConsecutiveFails: 2, PendingSince: now.Add(time.Hour)},
+			want:        PollerState{WakeState: StateAwake, StateSince: now},
+			wantDropped: "晚于当前时间",
+		},
+		{
+			name:  "恢复隔离状态",
+			saved: PollerState{Version: 1, SavedAt: ago(time.Minute), WakeState: StateAwake, StateSince: ago(time.Hour), QuarantinedSince: ago(10 * time.Minute)},
+			want:  PollerState{WakeState: StateAwake, StateSince: now, QuarantinedSince: ago(10 * time.Minute)},
+		},
+		{
+			name:        "隔离状态过期",
+			saved:       PollerState{Version: 1, SavedAt: ago(time.Minute), WakeState: StateAwake, QuarantinedSince: ago(13 * time.Hour)},
+			want:        PollerState{WakeState: StateAwake, StateSince: now},
+			wantDropped: "隔离状态已过期",
+		},
+		{
+			name:        "版本不受支持",
+			saved:       PollerState{Version: 99, SavedAt: ago(time.Minute), WakeState: StateDozing, PendingSince: ago(time.Minute)},
+			want:        PollerState{WakeState: StateAwake, StateSince: now},
+			wantDropped: "版本 99",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dropped := tt.saved.Sanitize(now, limits)
+			if got.WakeState != tt.want.WakeState || !got.StateSince.Equal(tt.want.StateSince) || !got.PendingSince.Equal(tt.want.PendingSince) ||
+				got.ConsecutiveFails != tt.want.ConsecutiveFails || got.IntervalSecs != tt.want.IntervalSecs ||
+				!got.QuarantinedSince.Equal(tt.want.QuarantinedSince) {
+				t.Errorf("Sanitize = %+v, want %+v", got, tt.want)
+			}
+			joined := strings.Join(dropped, "; ")
+			if (tt.wantDropped == "") != (len(dropped) == 0) || !strings.Contains(joined, tt.wantDropped) {
+				t.Errorf("dropped = %q, want %q", joined, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestPollerState_SanitizeAttempts(t *testing.T) {
+	now := time.Date(2025, 12, 25, 9, 0, 0, 0, time.UTC)
+	limits := PollerStateLimits{MaxInterval: 10 * time.Minute, MaxAge: 12 * time.Hour}
+
+	tests := []struct {
+		name             string
+		saved            PollerState
+		wantWakeAttempts int
+		wantDayAttempts  int
+	}{
+		{
+			name:             "同一天保留修复次数",
+			saved:            PollerState{Version: 1, SavedAt: now.Add(-time.Minute), ConsecutiveFails: 2, IntervalSecs: 120, LastRepairTime: now.Add(-time.Minute), WakeAttempts: 2, DayAttempts: 5, Day: "2025-12-25"},
+			wantWakeAttempts: 2,
+			wantDayAttempts:  5,
+		},
+		{
+			name:            "前一天的次数清零",
+			saved:           PollerState{Version: 1, SavedAt: now.Add(-10 * time.Hour), WakeAttempts: 3, DayAttempts: 8, Day: "2025-12-24"},
+			wantDayAttempts: 0,
+		},
+		{
+			name:            "上次修复已过期时不保留每次唤醒的次数",
+			saved:           PollerState{Version: 1, SavedAt: now.Add(-time.Minute), LastRepairTime: now.Add(-13 * time.Hour), WakeAttempts: 3, DayAttempts: 1, Day: "2025-12-25"},
+			wantDayAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := tt.saved.Sanitize(now, limits)
+			if got.WakeAttempts != tt.wantWakeAttempts || got.DayAttempts != tt.wantDayAttempts {
+				t.Errorf("Sanitize = wake %d, day %d, want wake %d, day %d",
+					got.WakeAttempts, got.DayAttempts, tt.wantWakeAttempts, tt.wantDayAttempts)
+			}
+		})
+	}
+}
+
+func TestPollerState_StatusLines(t *testing.T) {
+	at := time.Date(2025, 12, 25, 9, 30, 0, 0, time.Local)
+	policy := LinearPolicy{Base: time.Minute, Step: 30 * time.Second, Max: 10 * time.Minute}
+	tests := []struct {
+		name   string
+		locale Locale
+		state  PollerState
+		policy RetryPolicy
+		want   []string
+	}{
+		{
+			name:   "英文下重试计划和策略",
+			locale: LocaleEnUS,
+			state:  PollerState{NextAttempt: at, ConsecutiveFails: 2, RetryPolicy: "线性退避", WakeAttempts: 2, DayAttempts: 5},
+			policy: policy,
+			want: []string{
+				"🔁 Next automatic retry: 2025-12-25 09:30:00 (2 consecutive failures)",
+				"Retry policy: linear backoff from 1m0s, +30s per failure, up to 10m0s; 2 repairs since this wake, 5 today",
+			},
+		},
+		{
+			name:   "英文下隔离和次数上限",
+			locale: LocaleEnUS,
+			state:  PollerState{QuarantinedSince: at, QuarantineRelease: at.Add(15 * time.Minute), Blocked: RetryBlockedDay, DayAttempts: 8},
+			want: []string{
+				"🚧 Device status keeps flapping, automatic repair paused since 2025-12-25 09:30:00; resumes at 2025-12-25 09:45:00 if the status stays stable",
+				"⏸️ 8 automatic repairs today reached the daily limit, resuming tomorrow",
+			},
+		},
+		{
+			name:   "中文",
+			locale: LocaleZhCN,
+			state:  PollerState{Blocked: RetryBlockedWake, WakeAttempts: 3, RetryPolicy: "固定间隔 [10s]"},
+			policy: FixedSchedulePolicy{Steps: []time.Duration{10 * time.Second}},
+			want: []string{
+				"⏸️ 本次唤醒已自动修复 3 次，达到上限，等待下次唤醒",
+				"重试策略: 固定间隔 [10s]；本次唤醒已修复 3 次，今天 0 次",
+			},
+		},
+		{
+			name:   "没有配置时显示服务保存的策略",
+			locale: LocaleZhCN,
+			state:  PollerState{RetryPolicy: "指数退避 1m0s～10m0s"},
+			want:   []string{"重试策略: 指数退避 1m0s～10m0s；本次唤醒已修复 0 次，今天 0 次"},
+		},
+		{
+			name:   "没有重试计划",
+			locale: LocaleEnUS,
+			state:  PollerState{},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withLocale(t, tt.locale)
+			got := tt.state.StatusLines(tt.policy)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("StatusLines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if tt.locale == LocaleEnUS && hasHan(strings.Join(got, "\n")) {
+				t.Errorf("en-US 输出中不应有中文: %q", got)
+			}
+		})
+	}
+}
+
+func TestPollerStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", PollerStateFileName)
+	store := NewPollerStateStore(path)
+
+	if state, err := store.Load(); err != nil || state != nil {
+		t.Fatalf("文件不存在时 Load = (%v, %v), want (nil, nil)", state, err)
+	}
+
+	saved := PollerState{
+		SavedAt:          time.Date(2025, 12, 25, 9, 0, 0, 0, time.UTC),
+		WakeState:        StateCooldown,
+		StateSince:       time.Date(2025, 12, 25, 8, 58, 0, 0, time.UTC),
+		ConsecutiveFails: 3,
+		IntervalSecs:     480,
+		LastRepairTime:   time.Date(2025, 12, 25, 8, 58, 0, 0, time.UTC),
+	}
+	if err := store.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != pollerStateVersion || !got.sameAs(saved) {
+		t.Errorf("Load = %+v, want %+v", got, saved)
+	}
+
+	if err := os.WriteFile(path, []byte("{oops"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil || !strings.Contains(err.Error(), "解析轮询器状态失败") {
+		t.Errorf("损坏的状态文件 error = %v", err)
+	}
+}
@@ -0,0 +1,573 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIdleSource 用户是否在操作由测试控制；离开后空闲时间随假时钟增长
+type fakeIdleSource struct {
+	clock     *fakeClock
+	mu        sync.Mutex
+	active    bool
+	lastInput time.Time
+}
+
+func (s *fakeIdleSource) SetActive(active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = active
+	s.lastInput = s.clock.Now()
+}
+
+func (s *fakeIdleSource) IdleTime() (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active {
+		return 0, nil
+	}
+	return s.clock.Now().Sub(s.lastInput), nil
+}
+
+// fakeStatusDevice 状态由测试设置，并记录读取次数
+type fakeStatusDevice struct {
+	mu     sync.Mutex
+	status string
+	reads  int
+}
+
+func (d *fakeStatusDevice) Set(status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status = status
+}
+
+func (d *fakeStatusDevice) Reads() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reads
+}
+
+func (d *fakeStatusDevice) GetStatus(_ context.Context) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reads++
+	return d.status, nil
+}
+
+// simRepair 一次修复调用
+type simRepair struct {
+	at      time.Time
+	trigger RepairTrigger
+}
+
+// pollerSim 在假时钟上运行轮询器，模拟数小时的使用、离开、睡眠和唤醒
+type pollerSim struct {
+	t            *testing.T
+	clock        *fakeClock
+	start        time.Time
+	idle         *fakeIdleSource
+	device       *fakeStatusDevice
+	poller       *WakeEventPoller
+	logDir       string
+	repairOK     bool // 修复是否成功（成功时设备恢复正常）
+	repairVetoed bool // pre_reset 钩子是否阻止修复
+	repairs      []simRepair
+	giveUps      []int
+	rearms       []string
+}
+
+func newPollerSim(t *testing.T, cfg PollerConfig) *pollerSim {
+	t.Helper()
+	clock := newFakeClock()
+	s := &pollerSim{
+		t:      t,
+		clock:  clock,
+		start:  clock.Now(),
+		idle:   &fakeIdleSource{clock: clock, active: true},
+		device: &fakeStatusDevice{status: "OK"},
+		logDir: t.TempDir(),
+	}
+	logNow := clock.Now()
+	logger := newTestLogger(t, s.logDir, &logNow, LogRotation{})
+
+	cfg.Clock = clock
+	cfg.IdleSource = s.idle
+	cfg.Device = s.device
+	cfg.OnGiveUp = func(_ context.Context, attempts int) { s.giveUps = append(s.giveUps, attempts) }
+	cfg.OnRearm = func(_ context.Context, reason string) { s.rearms = append(s.rearms, reason) }
+	s.poller = NewWakeEventPoller("ACPI\\GXTP7386", func(_ context.Context, trigger RepairTrigger) RepairResult {
+		s.repairs = append(s.repairs, simRepair{at: clock.Now(), trigger: trigger})
+		switch {
+		case s.repairVetoed:
+			return RepairVetoed
+		case s.repairOK:
+			s.device.Set("OK")
+			return RepairFixed
+		}
+		return RepairFailed
+	}, logger, &cfg)
+	return s
+}
+
+// run 按 poll 循环的顺序同步执行：先处理唤醒检查请求，再按轮询器给出的间隔推进时钟并检查
+// 用于模拟数小时的场景；真实的 poll 循环由 TestWakeEventPoller_PollLoop 覆盖
+func (s *pollerSim) run(d time.Duration) {
+	end := s.clock.Now().Add(d)
+	for {
+		select {
+		case trigger := <-s.poller.wakeChan:
+			s.clock.Sleep(2 * time.Second)
+			s.poller.check(&trigger)
+			continue
+		default:
+		}
+		interval := s.poller.nextPoll()
+		if s.clock.Now().Add(interval).After(end) {
+			if remaining := end.Sub(s.clock.Now()); remaining > 0 {
+				s.clock.Sleep(remaining)
+			}
+			return
+		}
+		s.clock.Sleep(interval)
+		s.poller.check(nil)
+	}
+}
+
+// elapsed 距模拟开始的时长
+func (s *pollerSim) elapsed(at time.Time) time.Duration {
+	return at.Sub(s.start)
+}
+
+func (s *pollerSim) log() string {
+	entries, _ := os.ReadDir(s.logDir)
+	var b strings.Builder
+	for _, e := range entries {
+		data, _ := os.ReadFile(filepath.Join(s.logDir, e.Name()))
+		b.Write(data)
+	}
+	return b.String()
+}
+
+func TestWakeEventPoller_UserAwayThenBack(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{})
+	s.repairOK = true
+
+	// 正常使用 1 小时，然后离开（合盖前无操作），10 分钟后设备掉线
+	s.run(time.Hour)
+	s.idle.SetActive(false)
+	s.run(10 * time.Minute)
+	if got := s.poller.state.State(); got != StateDozing {
+		t.Fatalf("离开 10 分钟后状态 = %s, want dozing", got)
+	}
+	s.device.Set("Error")
+	s.run(2 * time.Hour)
+	if len(s.repairs) != 0 {
+		t.Fatalf("用户离开期间不应修复, got %d 次", len(s.repairs))
+	}
+	if !s.poller.Pending() {
+		t.Error("离开期间发现异常应标记待唤醒修复")
+	}
+
+	// 用户回来：下一次轮询补修复
+	back := s.clock.Now()
+	s.idle.SetActive(true)
+	s.run(time.Minute)
+	if len(s.repairs) != 1 {
+		t.Fatalf("回来后应修复 1 次, got %d", len(s.repairs))
+	}
+	r := s.repairs[0]
+	if r.trigger.Source != TriggerPending || r.at.Sub(back) > DefaultPollInterval {
+		t.Errorf("修复 = %+v（回来后 %s）, want pending 且在一个轮询间隔内", r.trigger, r.at.Sub(back))
+	}
+	if s.poller.Pending() || s.poller.state.State() != StateAwake {
+		t.Errorf("修复后 pending = %v, state = %s", s.poller.Pending(), s.poller.state.State())
+	}
+}
+
+func TestWakeEventPoller_BackoffAndGiveUp(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{
+		BaseRetryInterval: time.Minute,
+		MaxRetryInterval:  4 * time.Minute,
+		MaxRetryCount:     4,
+		GiveUpCooldown:    time.Hour,
+	})
+	s.device.Set("Error")
+	s.run(3 * time.Hour)
+
+	// 首次轮询立即修复；之后间隔 2 分钟、4 分钟、4 分钟（上限）后重试，加上轮询粒度
+	if len(s.repairs) < 5 {
+		t.Fatalf("修复 %d 次, want 至少 5 次（4 次后停止，冷却后恢复）", len(s.repairs))
+	}
+	wantGaps := []time.Duration{130 * time.Second, 250 * time.Second, 250 * time.Second, time.Hour}
+	for i, want := range wantGaps {
+		if gap := s.repairs[i+1].at.Sub(s.repairs[i].at); gap != want {
+			t.Errorf("第 %d 次与第 %d 次修复间隔 = %s, want %s", i+1, i+2, gap, want)
+		}
+	}
+	if len(s.giveUps) == 0 || s.giveUps[0] != 4 {
+		t.Errorf("giveUps = %v, want 第一次在 4 次失败后", s.giveUps)
+	}
+	if len(s.rearms) == 0 || s.rearms[0] != "cooldown" {
+		t.Errorf("rearms = %v, want 冷却结束后恢复", s.rearms)
+	}
+	if s.repairs[0].trigger.Source != TriggerPoll || s.repairs[1].trigger.Reason != "持续异常" {
+		t.Errorf("触发来源 = %+v, %+v", s.repairs[0].trigger, s.repairs[1].trigger)
+	}
+	if !strings.Contains(s.log(), "连续失败 4 次，已达到最大重试次数") {
+		t.Error("日志缺少停止自动修复记录")
+	}
+}
+
+func TestWakeEventPoller_PreResetVeto(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{
+		BaseRetryInterval: time.Minute,
+		MaxRetryInterval:  10 * time.Minute,
+		MaxRetryCount:     3,
+	})
+	s.device.Set("Error")
+	s.repairVetoed = true
+	s.run(30 * time.Minute)
+
+	// 被阻止的修复不计入失败：不退避、不停止自动修复，按基础间隔稍后再试
+	if len(s.repairs) < 10 {
+		t.Fatalf("修复 %d 次, want 按基础间隔持续重试", len(s.repairs))
+	}
+	for i := 1; i < len(s.repairs); i++ {
+		if gap := s.repairs[i].at.Sub(s.repairs[i-1].at); gap != 70*time.Second {
+			t.Errorf("第 %d 次与第 %d 次修复间隔 = %s, want 70s", i, i+1, gap)
+		}
+	}
+	if len(s.giveUps) != 0 || s.poller.GetConsecutiveFails() != 0 {
+		t.Errorf("giveUps = %v, 连续失败 = %d, want 被阻止不计入失败", s.giveUps, s.poller.GetConsecutiveFails())
+	}
+	if got := s.poller.snapshot().WakeAttempts; got != 0 {
+		t.Errorf("WakeAttempts = %d, want 被阻止不计入重试次数", got)
+	}
+
+	// 钩子不再阻止后正常修复
+	s.repairVetoed = false
+	s.repairOK = true
+	s.run(5 * time.Minute)
+	if s.device.status != "OK" || s.poller.state.State() != StateAwake {
+		t.Errorf("设备 = %s, 状态 = %s, want 修复成功", s.device.status, s.poller.state.State())
+	}
+}
+
+func TestWakeEventPoller_RetryPolicyAndBudget(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{
+		BaseRetryInterval: time.Minute,
+		MaxRetryInterval:  10 * time.Minute,
+		RetryPolicy:       FixedSchedulePolicy{Steps: []time.Duration{30 * time.Second, time.Minute}},
+		RetryBudget:       RetryBudget{MaxPerWake: 3, MaxPerDay: 4},
+	})
+	s.device.Set("Error")
+	s.run(time.Hour)
+
+	// 固定间隔表：30 秒、1 分钟后重试（加上轮询粒度），本次唤醒 3 次后停止
+	if len(s.repairs) != 3 {
+		t.Fatalf("第一次唤醒修复 %d 次, want 3", len(s.repairs))
+	}
+	wantGaps := []time.Duration{40 * time.Second, 70 * time.Second}
+	for i, want := range wantGaps {
+		if gap := s.repairs[i+1].at.Sub(s.repairs[i].at); gap != want {
+			t.Errorf("第 %d 次与第 %d 次修复间隔 = %s, want %s", i+1, i+2, gap, want)
+		}
+	}
+	state := s.poller.snapshot()
+	if state.Blocked != RetryBlockedWake || !state.NextAttempt.IsZero() || state.WakeAttempts != 3 {
+		t.Errorf("snapshot = %+v, want 等待下次唤醒且无下次重试时间", state)
+	}
+	if !strings.Contains(s.log(), "达到 max_per_wake") {
+		t.Error("日志缺少达到每次唤醒上限的记录")
+	}
+
+	// 下次唤醒：每次唤醒的次数重新计算，但当天只剩 1 次
+	s.poller.Pause()
+	s.poller.Resume()
+	s.run(time.Hour)
+	if len(s.repairs) != 4 {
+		t.Fatalf("第二次唤醒后共修复 %d 次, want 4", len(s.repairs))
+	}
+	s.poller.Pause()
+	s.poller.Resume()
+	s.run(time.Hour)
+	if len(s.repairs) != 4 {
+		t.Fatalf("当天次数用完后仍修复, 共 %d 次", len(s.repairs))
+	}
+	if got := s.poller.snapshot().Blocked; got != RetryBlockedDay {
+		t.Errorf("Blocked = %q, want day", got)
+	}
+
+	// 第二天恢复
+	s.run(24 * time.Hour)
+	if len(s.repairs) != 7 {
+		t.Fatalf("第二天共修复 %d 次, want 7（每次唤醒 3 次）", len(s.repairs))
+	}
+	if day := s.repairs[4].at.Format("2006-01-02"); day != "2025-12-25" {
+		t.Errorf("第二天首次修复日期 = %s", day)
+	}
+}
+
+func TestWakeEventPoller_NextAttempt(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{BaseRetryInterval: time.Minute, MaxRetryInterval: 10 * time.Minute})
+	if got := s.poller.NextAttempt(); !got.IsZero() {
+		t.Fatalf("未失败时 NextAttempt = %s, want 零值", got)
+	}
+	s.device.Set("Error")
+	s.run(15 * time.Second)
+	if len(s.repairs) != 1 {
+		t.Fatalf("修复 %d 次, want 1", len(s.repairs))
+	}
+	if got, want := s.poller.NextAttempt(), s.repairs[0].at.Add(2*time.Minute); !got.Equal(want) {
+		t.Errorf("NextAttempt = %s, want %s", got, want)
+	}
+	if got := s.poller.snapshot().NextAttempt; got.IsZero() {
+		t.Error("保存的状态缺少下次重试时间")
+	}
+}
+
+func TestWakeEventPoller_FlapQuarantine(t *testing.T) {
+	var snaps []FlapSnapshot
+	var releases []time.Time
+	s := newPollerSim(t, PollerConfig{
+		OnQuarantine: func(_ context.Context, snap FlapSnapshot) { snaps = append(snaps, snap) },
+		OnRelease:    func(_ context.Context, since time.Time) { releases = append(releases, since) },
+	})
+	s.repairOK = true
+	s.run(time.Minute)
+
+	// 设备修复后几秒又掉线：每个周期“异常 → 修复 → 正常”
+	for i := 0; i < 10; i++ {
+		s.device.Set("Error")
+		s.run(20 * time.Second)
+	}
+	if len(snaps) != 1 {
+		t.Fatalf("进入隔离 %d 次, want 1", len(snaps))
+	}
+	if len(s.repairs) != 3 {
+		t.Errorf("修复 %d 次, want 3（第 3 次修复后切换达到 6 次，之后不再修复）", len(s.repairs))
+	}
+	snap := snaps[0]
+	if snap.Transitions != 6 || len(snap.Samples) == 0 || snap.Poller.QuarantinedSince.IsZero() || snap.DeviceID == "" {
+		t.Errorf("诊断快照 = %+v", snap)
+	}
+	if !s.poller.Quarantined() || s.poller.snapshot().QuarantineRelease.IsZero() {
+		t.Error("应处于隔离中并记录预计解除时间")
+	}
+	if !strings.Contains(s.log(), "进入隔离") {
+		t.Error("日志缺少进入隔离记录")
+	}
+
+	// 设备一直异常、不再切换：稳定 15 分钟后解除隔离并恢复修复
+	s.device.Set("Error")
+	s.run(20 * time.Minute)
+	if len(releases) != 1 || s.poller.Quarantined() {
+		t.Fatalf("releases = %v, Quarantined = %v, want 解除隔离", releases, s.poller.Quarantined())
+	}
+	if len(s.repairs) != 4 {
+		t.Errorf("解除隔离后修复共 %d 次, want 4", len(s.repairs))
+	}
+}
+
+func TestWakeEventPoller_SleepAndWake(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{})
+	s.repairOK = true
+	s.run(30 * time.Minute)
+
+	// 用户离开，显示器关闭：停止轮询，整晚不读取设备状态
+	s.idle.SetActive(false)
+	s.poller.Pause()
+	reads := s.device.Reads()
+	s.device.Set("Error")
+	s.run(8 * time.Hour)
+	if got := s.device.Reads(); got != reads {
+		t.Errorf("睡眠期间读取设备 %d 次, want 0", got-reads)
+	}
+	if got := s.elapsed(s.clock.Now()); got != 8*time.Hour+30*time.Minute {
+		t.Errorf("模拟时长 = %s", got)
+	}
+
+	// 唤醒：等待 2 秒后立即检查并修复
+	woke := s.clock.Now()
+	s.idle.SetActive(true)
+	s.poller.Resume()
+	s.run(time.Minute)
+	if len(s.repairs) != 1 || s.repairs[0].at.Sub(woke) != 2*time.Second || s.repairs[0].trigger.Source != TriggerPending {
+		t.Fatalf("唤醒后修复 = %+v, want 2 秒后 1 次 pending 修复", s.repairs)
+	}
+}
+
+func TestWakeEventPoller_WakeWithoutEvent(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{})
+	s.repairOK = true
+	s.run(30 * time.Minute)
+
+	// 按电源键关闭显示器：关闭前刚有过输入，不应视为唤醒
+	s.poller.Pause()
+	s.idle.SetActive(false)
+	reads := s.device.Reads()
+	s.device.Set("Error")
+	s.run(2 * time.Hour)
+	if got := s.poller.state.State(); got != StateAsleep {
+		t.Fatalf("睡眠期间状态 = %s, want asleep", got)
+	}
+	if got := s.device.Reads(); got != reads {
+		t.Errorf("睡眠期间读取设备 %d 次, want 0", got-reads)
+	}
+
+	// 没有收到 display_on 事件，但用户已经在使用：下一次慢速检查时恢复并修复
+	back := s.clock.Now()
+	s.idle.SetActive(true)
+	s.run(5 * time.Minute)
+	if len(s.repairs) != 1 || s.repairs[0].at.Sub(back) > AsleepIdleInterval {
+		t.Fatalf("repairs = %+v, want 用户回来后 %s 内修复", s.repairs, AsleepIdleInterval)
+	}
+	if got := s.poller.state.State(); got != StateAwake {
+		t.Errorf("状态 = %s, want awake", got)
+	}
+	if !strings.Contains(s.log(), "未收到唤醒事件，但检测到用户输入") {
+		t.Error("日志缺少恢复轮询记录")
+	}
+}
+
+func TestWakeEventPoller_IdleThresholds(t *testing.T) {
+	tests := []struct {
+		name        string
+		thresholds  IdleThresholds
+		away        time.Duration
+		wantState   WakeState
+		wantRepairs int
+	}{
+		{"默认阈值：离开 10 分钟视为空闲，暂不修复", IdleThresholds{}, 10 * time.Minute, StateDozing, 0},
+		{"放宽到 30 分钟：离开 10 分钟仍立即修复", IdleThresholds{Doze: 30 * time.Minute, Active: 2 * time.Minute}, 10 * time.Minute, StateAwake, 1},
+		{"收紧到 2 分钟：离开 3 分钟即视为空闲", IdleThresholds{Doze: 2 * time.Minute, Active: 30 * time.Second}, 3 * time.Minute, StateDozing, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newPollerSim(t, PollerConfig{IdleThresholds: tt.thresholds})
+			s.repairOK = true
+			s.idle.SetActive(false)
+			s.run(tt.away)
+			s.device.Set("Error")
+			s.run(30 * time.Second)
+			if got := s.poller.state.State(); got != tt.wantState || len(s.repairs) != tt.wantRepairs {
+				t.Errorf("状态 = %s, 修复 %d 次, want %s, %d 次", got, len(s.repairs), tt.wantState, tt.wantRepairs)
+			}
+		})
+	}
+}
+
+func TestWakeEventPoller_AdaptivePolling(t *testing.T) {
+	s := newPollerSim(t, PollerConfig{Schedule: NewPollSchedule(10*time.Second, &AdaptivePollConfig{})})
+	s.idle.SetActive(false)
+	s.poller.Pause()
+	s.run(8 * time.Hour)
+	if s.device.Reads() != 0 {
+		t.Fatalf("显示器关闭时应停止轮询, 读取 %d 次", s.device.Reads())
+	}
+
+	// 唤醒后 3 分钟内每 2 秒
+	s.idle.SetActive(true)
+	s.poller.Resume()
+	s.run(3 * time.Minute)
+	fast := s.device.Reads()
+	if fast < 85 || fast > 91 {
+		t.Errorf("唤醒后 3 分钟读取 %d 次, want 约 90 次", fast)
+	}
+
+	// 之后每 10 秒，稳定 10 分钟后每 5 分钟
+	s.run(time.Hour)
+	slow := s.device.Reads() - fast
+	if slow < 50 || slow > 60 {
+		t.Errorf("之后 1 小时读取 %d 次, want 约 42 次（正常）+ 10 次（稳定）", slow)
+	}
+}
+
+func TestWakeEventPoller_PollLoop(t *testing.T) {
+	clock := newFakeClock()
+	idle := &fakeIdleSource{clock: clock, active: true}
+	device := &fakeStatusDevice{status: "OK"}
+	logNow := clock.Now()
+	repairs := make(chan RepairTrigger, 1)
+	reports := make(chan PollCostReport, 2)
+	p := NewWakeEventPoller("ACPI\\GXTP7386", func(_ context.Context, trigger RepairTrigger) RepairResult {
+		device.Set("OK")
+		repairs <- trigger
+		return RepairFixed
+	}, newTestLogger(t, t.TempDir(), &logNow, LogRotation{}), &PollerConfig{
+		Clock:        clock,
+		IdleSource:   idle,
+		Device:       device,
+		Schedule:     NewPollSchedule(10*time.Second, nil),
+		OnCostReport: func(r PollCostReport) { reports <- r },
+	})
+	repaired := func() RepairTrigger {
+		t.Helper()
+		select {
+		case trigger := <-repairs:
+			return trigger
+		case <-time.After(5 * time.Second):
+			t.Fatal("等待修复超时")
+		}
+		return RepairTrigger{}
+	}
+	report := func() PollCostReport {
+		t.Helper()
+		select {
+		case r := <-reports:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("等待轮询开销报告超时")
+		}
+		return PollCostReport{}
+	}
+
+	// 启动时检查一次，之后每次时钟推进到间隔时检查
+	p.Start()
+	clock.waitForTimer(t, 10*time.Second)
+	clock.Advance(10 * time.Second)
+	clock.waitForTimer(t, 10*time.Second)
+	if got := device.Reads(); got != 2 {
+		t.Fatalf("启动和一次轮询后读取 %d 次, want 2", got)
+	}
+
+	// 显示器关闭：重新计算间隔，之后只慢速读取空闲时间，不读取设备状态
+	idle.SetActive(false)
+	p.Pause()
+	clock.waitForTimer(t, AsleepIdleInterval)
+	clock.Advance(time.Hour)
+	r := report()
+	if r.Checks != 2 || r.ModeTime[PollNormal] != 10*time.Second || r.ModeTime[PollStopped] != time.Hour {
+		t.Errorf("开销报告 = %+v, want 2 次检查、normal 10s、stopped 1h", r)
+	}
+	if got := device.Reads(); got != 2 {
+		t.Errorf("显示器关闭期间读取设备 %d 次, want 0", got-2)
+	}
+
+	// 唤醒：等待 2 秒后立即检查，发现异常以 pending 修复
+	clock.waitForTimer(t, AsleepIdleInterval)
+	device.Set("Error")
+	idle.SetActive(true)
+	p.Resume()
+	clock.waitForTimer(t, 2*time.Second)
+	clock.Advance(2 * time.Second)
+	if trigger := repaired(); trigger.Source != TriggerPending {
+		t.Errorf("唤醒后修复来源 = %s, want pending", trigger.Source)
+	}
+	if got := p.state.State(); got != StateAwake {
+		t.Errorf("修复后状态 = %s, want awake", got)
+	}
+
+	// 停止时报告最后一个周期的开销
+	clock.waitForTimer(t, 10*time.Second)
+	p.Stop()
+	if r := report(); r.Checks != 1 || r.Period() != 2*time.Second {
+		t.Errorf("停止时的开销报告 = %+v, want 2 秒内 1 次检查", r)
+	}
+}
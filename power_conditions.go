@@ -0,0 +1,116 @@
+// Package main provides the lid, power-source and session-lock conditions tracked from power events.
+package main
+
+import "sync"
+
+// WakeTriggerConfig 盖子、电源和会话事件作为修复触发条件的配置
+type WakeTriggerConfig struct {
+	LidOpen        bool `json:"lid_open,omitempty"`         // 开盖时立即检查设备，异常则修复
+	SessionUnlock  bool `json:"session_unlock,omitempty"`   // 解锁会话时立即检查设备，异常则修复
+	PowerSource    bool `json:"power_source,omitempty"`     // 切换交流电源/电池时立即检查设备，异常则修复
+	RequireLidOpen bool `json:"require_lid_open,omitempty"` // 合盖期间不修复（忽略合盖时的自动唤醒），开盖后再修复
+}
+
+// TriggerSource 返回该事件作为修复触发时的来源；未启用对应触发条件时返回 false
+func (c *WakeTriggerConfig) TriggerSource(kind WakeEventKind) (RepairSource, bool) {
+	if c == nil {
+		return "", false
+	}
+	switch kind {
+	case WakeLidOpen:
+		return TriggerLid, c.LidOpen
+	case WakeSessionUnlock:
+		return TriggerUnlock, c.SessionUnlock
+	case WakePowerAC, WakePowerDC:
+		return TriggerPowerSource, c.PowerSource
+	}
+	return "", false
+}
+
+// LidState 盖子状态
+type LidState string
+
+const (
+	LidUnknown LidState = ""       // 尚未收到盖子事件
+	LidOpen    LidState = "open"   // 打开
+	LidClosed  LidState = "closed" // 合上
+)
+
+// PowerSourceState 电源来源
+type PowerSourceState string
+
+const (
+	PowerSourceUnknown PowerSourceState = ""   // 尚未收到电源事件
+	PowerSourceAC      PowerSourceState = "ac" // 交流电源
+	PowerSourceDC      PowerSourceState = "dc" // 电池
+)
+
+// PowerConditions 从电源和会话事件得到的当前条件（盖子、电源、会话锁定）
+// 注册通知后 Windows 会立即发送一次盖子和电源的当前值
+type PowerConditions struct {
+	mu     sync.Mutex
+	lid    LidState
+	source PowerSourceState
+	locked bool
+}
+
+// Update 根据事件更新条件，返回条件是否发生变化
+func (c *PowerConditions) Update(e WakeEvent) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Kind {
+	case WakeLidOpen:
+		return c.setLid(LidOpen)
+	case WakeLidClose:
+		return c.setLid(LidClosed)
+	case WakePowerAC:
+		return c.setSource(PowerSourceAC)
+	case WakePowerDC:
+		return c.setSource(PowerSourceDC)
+	case WakeSessionLock, WakeSessionUnlock:
+		locked := e.Kind == WakeSessionLock
+		changed := c.locked != locked
+		c.locked = locked
+		return changed
+	}
+	return false
+}
+
+func (c *PowerConditions) setLid(lid LidState) bool {
+	changed := c.lid != lid
+	c.lid = lid
+	return changed
+}
+
+func (c *PowerConditions) setSource(source PowerSourceState) bool {
+	changed := c.source != source
+	c.source = source
+	return changed
+}
+
+// Lid 当前盖子状态
+func (c *PowerConditions) Lid() LidState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lid
+}
+
+// LidClosed 盖子是否确定已合上（未收到盖子事件时为 false）
+func (c *PowerConditions) LidClosed() bool {
+	return c.Lid() == LidClosed
+}
+
+// PowerSource 当前电源来源
+func (c *PowerConditions) PowerSource() PowerSourceState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.source
+}
+
+// SessionLocked 会话是否已锁定
+func (c *PowerConditions) SessionLocked() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.locked
+}
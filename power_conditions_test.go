@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestWakeTriggerConfig_TriggerSource(t *testing.T) {
+	cfg := &WakeTriggerConfig{LidOpen: true, PowerSource: true}
+	tests := []struct {
+		name   string
+		cfg    *WakeTriggerConfig
+		kind   WakeEventKind
+		want   RepairSource
+		wantOK bool
+	}{
+		{"未配置", nil, WakeLidOpen, "", false},
+		{"开盖", cfg, WakeLidOpen, TriggerLid, true},
+		{"合盖不触发", cfg, WakeLidClose, "", false},
+		{"切换到电池", cfg, WakePowerDC, TriggerPowerSource, true},
+		{"切换到交流电源", cfg, WakePowerAC, TriggerPowerSource, true},
+		{"未启用解锁触发", cfg, WakeSessionUnlock, TriggerUnlock, false},
+		{"其他事件", cfg, WakeDisplayOn, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.cfg.TriggerSource(tt.kind)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("TriggerSource(%s) = (%s, %v), want (%s, %v)", tt.kind, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPowerConditions_Update(t *testing.T) {
+	var c PowerConditions
+	if c.LidClosed() || c.Lid() != LidUnknown || c.PowerSource() != PowerSourceUnknown {
+		t.Fatal("初始状态应为未知")
+	}
+
+	steps := []struct {
+		kind        WakeEventKind
+		wantChanged bool
+	}{
+		{WakeLidClose, true},
+		{WakeLidClose, false},
+		{WakePowerDC, true},
+		{WakeSessionLock, true},
+		{WakeDisplayOn, false},
+		{WakeSessionUnlock, true},
+		{WakeLidOpen, true},
+		{WakePowerAC, true},
+	}
+	for i, step := range steps {
+		if got := c.Update(WakeEvent{Kind: step.kind}); got != step.wantChanged {
+			t.Errorf("第 %d 步 %s: changed = %v, want %v", i+1, step.kind, got, step.wantChanged)
+		}
+		if i == 3 && (!c.LidClosed() || c.PowerSource() != PowerSourceDC || !c.SessionLocked()) {
+			t.Errorf("合盖、电池、锁定后条件 = %s/%s/%v", c.Lid(), c.PowerSource(), c.SessionLocked())
+		}
+	}
+	if c.Lid() != LidOpen || c.PowerSource() != PowerSourceAC || c.SessionLocked() {
+		t.Errorf("最终条件 = %s/%s/%v, want open/ac/false", c.Lid(), c.PowerSource(), c.SessionLocked())
+	}
+}
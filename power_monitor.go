@@ -39,16 +39,27 @@ var GUID_SYSTEM_AWAYMODE = windows.GUID{
 	Data4: [8]byte{0x9c, 0x0f, 0x44, 0x35, 0x2c, 0x29, 0xe5, 0xc0},
 }
 
-const (
-	// 显示器状态值
-	DisplayStateOff    = 0 // 显示器关闭
-	DisplayStateDimmed = 1 // 显示器调暗
-	DisplayStateOn     = 2 // 显示器开启
-)
+// GUID_LIDSWITCH_STATE_CHANGE - 盖子开合状态
+// {ba3e0f4d-b817-4094-a2d1-d56379e6a0f3}
+var GUID_LIDSWITCH_STATE_CHANGE = windows.GUID{
+	Data1: 0xba3e0f4d,
+	Data2: 0xb817,
+	Data3: 0x4094,
+	Data4: [8]byte{0xa2, 0xd1, 0xd5, 0x63, 0x79, 0xe6, 0xa0, 0xf3},
+}
+
+// GUID_ACDC_POWER_SOURCE - 交流电源/电池切换
+// {5d3e9a59-e9d5-4b00-a6bd-ff34ff516548}
+var GUID_ACDC_POWER_SOURCE = windows.GUID{
+	Data1: 0x5d3e9a59,
+	Data2: 0xe9d5,
+	Data3: 0x4b00,
+	Data4: [8]byte{0xa6, 0xbd, 0xff, 0x34, 0xff, 0x51, 0x65, 0x48},
+}
 
 const (
 	// Power setting notification registration type
-	DEVICE_NOTIFY_SERVICE_HANDLE = 1
+	DEVICE_NOTIFY_CALLBACK = 2
 )
 
 var (
@@ -73,85 +84,48 @@ type PowerBroadcastSetting struct {
 	Data         [1]byte // 实际数据长度由 DataLength 决定
 }
 
-// PowerMonitor 监控电源状态变化
-type PowerMonitor struct {
-	callback         func()        // 唤醒时的回调函数
-	lastDisplayState int           // 上次显示器状态
-	lastResumeTime   time.Time     // 上次唤醒时间
-	mu               sync.Mutex    // 保护并发访问
-	minInterval      time.Duration // 最小触发间隔，防止重复触发
-}
-
-// NewPowerMonitor 创建电源监控器
-func NewPowerMonitor(callback func()) *PowerMonitor {
-	return &PowerMonitor{
-		callback:         callback,
-		lastDisplayState: DisplayStateOn, // 初始假设显示器开启
-		minInterval:      5 * time.Second,
+// DecodeServicePowerEvent 解码服务收到的 SERVICE_CONTROL_POWEREVENT；PBT_POWERSETTINGCHANGE 返回 false
+// 电源设置变化由 RegisterPowerSettingCallback 在系统回调中解码：SCM 转发到 Execute 时控制处理函数已经返回，
+// eventData 指向的缓冲区可能已被释放或重用，不能再读取
+func DecodeServicePowerEvent(t time.Time, eventType uint32) (WakeEvent, bool) {
+	if eventType == PBTPowerSettingChange {
+		return WakeEvent{}, false
 	}
+	return DecodePowerEvent(t, eventType, "", 0), true
 }
 
-// HandlePowerBroadcast 处理电源广播消息
-// 返回 true 表示这是一个唤醒事件
-func (pm *PowerMonitor) HandlePowerBroadcast(eventType uint32, eventData uintptr) bool {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	// PBT_POWERSETTINGCHANGE = 0x8013
-	if eventType != 0x8013 {
-		// 处理传统电源事件
-		switch eventType {
-		case 7, 18: // PBT_APMRESUMESUSPEND = 7, PBT_APMRESUMEAUTOMATIC = 18
-			return pm.triggerResume("传统电源事件")
-		}
-		return false
-	}
-
-	// 解析 PowerBroadcastSetting
-	if eventData == 0 {
-		return false
-	}
-
-	pbs := (*PowerBroadcastSetting)(unsafe.Pointer(eventData))
-
-	// 检查是否是显示器状态变化
-	if pbs.PowerSetting == GUID_CONSOLE_DISPLAY_STATE || pbs.PowerSetting == GUID_MONITOR_POWER_ON {
-		newState := int(pbs.Data[0])
-		oldState := pm.lastDisplayState
-		pm.lastDisplayState = newState
-
-		// 显示器从关闭变为开启 = 系统唤醒
-		if oldState == DisplayStateOff && newState == DisplayStateOn {
-			return pm.triggerResume("显示器开启")
-		}
-	}
-
-	return false
+// deviceNotifySubscribeParameters DEVICE_NOTIFY_SUBSCRIBE_PARAMETERS
+type deviceNotifySubscribeParameters struct {
+	Callback uintptr
+	Context  uintptr
 }
 
-// triggerResume 触发唤醒事件
-func (pm *PowerMonitor) triggerResume(reason string) bool {
-	now := time.Now()
-
-	// 防止短时间内重复触发
-	if now.Sub(pm.lastResumeTime) < pm.minInterval {
-		return false
-	}
+var (
+	powerSettingMu      sync.Mutex
+	powerSettingHandler func(WakeEvent) // 电源设置变化的接收者
 
-	pm.lastResumeTime = now
+	// powerSettingParams 注册时传给系统的回调参数（全局保存，注册期间始终可达）
+	powerSettingParams = sync.OnceValue(func() *deviceNotifySubscribeParameters {
+		return &deviceNotifySubscribeParameters{Callback: windows.NewCallback(powerSettingCallback)}
+	})
+)
 
-	if pm.callback != nil {
-		go pm.callback()
+// powerSettingCallback 系统在电源设置变化时调用（DEVICE_NOTIFY_CALLBACK）
+// setting 只在回调返回前有效，在这里解码，交给接收者的事件不再引用该缓冲区
+func powerSettingCallback(_ uintptr, eventType uintptr, setting *PowerBroadcastSetting) uintptr {
+	if uint32(eventType) != PBTPowerSettingChange || setting == nil {
+		return 0
 	}
-
-	return true
-}
-
-// SimulateDisplayOff 模拟显示器关闭（用于测试）
-func (pm *PowerMonitor) SimulateDisplayOff() {
-	pm.mu.Lock()
-	pm.lastDisplayState = DisplayStateOff
-	pm.mu.Unlock()
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(setting)), powerBroadcastSettingHeaderSize+int(setting.DataLength))
+	e := DecodePowerSettingBuffer(time.Now(), buf)
+
+	powerSettingMu.Lock()
+	handler := powerSettingHandler
+	powerSettingMu.Unlock()
+	if handler != nil {
+		handler(e)
+	}
+	return 0
 }
 
 // PowerSettingGUID 返回需要监控的电源设置 GUID
@@ -159,23 +133,28 @@ func GetPowerSettingGUIDs() []windows.GUID {
 	return []windows.GUID{
 		GUID_CONSOLE_DISPLAY_STATE,
 		GUID_MONITOR_POWER_ON,
+		GUID_LIDSWITCH_STATE_CHANGE,
+		GUID_ACDC_POWER_SOURCE,
 	}
 }
 
-// RegisterPowerNotification 注册电源通知（服务模式）
-// serviceHandle 应该是服务状态句柄
-func RegisterPowerNotification(serviceHandle windows.Handle, guid *windows.GUID) (windows.Handle, error) {
-	var regHandle windows.Handle
+// RegisterPowerSettingCallback 注册电源设置通知，变化时在系统回调中解码并交给 handler（所有注册共用同一个 handler）
+func RegisterPowerSettingCallback(guid *windows.GUID, handler func(WakeEvent)) (windows.Handle, error) {
+	powerSettingMu.Lock()
+	powerSettingHandler = handler
+	powerSettingMu.Unlock()
 
-	ret, _, err := procPowerSettingRegisterNotification.Call(
+	var regHandle windows.Handle
+	ret, _, _ := procPowerSettingRegisterNotification.Call(
 		uintptr(unsafe.Pointer(guid)),
-		uintptr(DEVICE_NOTIFY_SERVICE_HANDLE),
-		uintptr(serviceHandle),
+		uintptr(DEVICE_NOTIFY_CALLBACK),
+		uintptr(unsafe.Pointer(powerSettingParams())),
 		uintptr(unsafe.Pointer(&regHandle)),
 	)
 
+	// 返回值是 Win32 错误码
 	if ret != 0 {
-		return 0, err
+		return 0, windows.Errno(ret)
 	}
 
 	return regHandle, nil
@@ -183,9 +162,9 @@ func RegisterPowerNotification(serviceHandle windows.Handle, guid *windows.GUID)
 
 // UnregisterPowerNotification 取消电源通知注册
 func UnregisterPowerNotification(handle windows.Handle) error {
-	ret, _, err := procPowerSettingUnregisterNotification.Call(uintptr(handle))
+	ret, _, _ := procPowerSettingUnregisterNotification.Call(uintptr(handle))
 	if ret != 0 {
-		return err
+		return windows.Errno(ret)
 	}
 	return nil
 }
@@ -199,337 +178,15 @@ func GetPowerSettingName(guid windows.GUID) string {
 		return "MONITOR_POWER_ON"
 	case GUID_SYSTEM_AWAYMODE:
 		return "SYSTEM_AWAYMODE"
+	case GUID_LIDSWITCH_STATE_CHANGE:
+		return "LIDSWITCH_STATE_CHANGE"
+	case GUID_ACDC_POWER_SOURCE:
+		return "ACDC_POWER_SOURCE"
 	default:
 		return "UNKNOWN"
 	}
 }
 
-// WakeEventPoller 设备状态轮询器（备用方案）
-// 用于 Modern Standby 系统中，当电源事件不可靠时作为补充检测
-type WakeEventPoller struct {
-	callback          func() bool // 修复回调，返回是否成功
-	stopChan          chan struct{}
-	pauseChan         chan bool // 暂停/恢复控制
-	pollInterval      time.Duration
-	baseRetryInterval time.Duration // 基础重试间隔
-	maxRetryInterval  time.Duration // 最大重试间隔（退避上限）
-	maxRetryCount     int           // 最大连续失败次数（0=无限制）
-	lastStatus        string
-	pendingRepair     bool          // 设备在睡眠/长空闲期间变异常，等待唤醒后再修复
-	lastRepairTime    time.Time     // 上次修复时间
-	consecutiveFails  int           // 连续失败次数
-	currentInterval   time.Duration // 当前重试间隔（退避用）
-	deviceID          string
-	logger            *Logger
-	paused            bool // 是否暂停
-	mu                sync.Mutex
-}
-
-// PollerConfig 轮询器配置
-type PollerConfig struct {
-	BaseRetryInterval time.Duration
-	MaxRetryInterval  time.Duration
-	MaxRetryCount     int
-}
-
-// NewWakeEventPoller 创建唤醒事件轮询器
-func NewWakeEventPoller(deviceID string, callback func() bool, logger *Logger, cfg *PollerConfig) *WakeEventPoller {
-	baseInterval := 60 * time.Second
-	maxInterval := 10 * time.Minute
-	maxRetry := 10
-
-	if cfg != nil {
-		if cfg.BaseRetryInterval > 0 {
-			baseInterval = cfg.BaseRetryInterval
-		}
-		if cfg.MaxRetryInterval > 0 {
-			maxInterval = cfg.MaxRetryInterval
-		}
-		if cfg.MaxRetryCount >= 0 {
-			maxRetry = cfg.MaxRetryCount
-		}
-	}
-
-	return &WakeEventPoller{
-		callback:          callback,
-		stopChan:          make(chan struct{}),
-		pauseChan:         make(chan bool, 1),
-		pollInterval:      10 * time.Second,
-		baseRetryInterval: baseInterval,
-		maxRetryInterval:  maxInterval,
-		maxRetryCount:     maxRetry,
-		currentInterval:   baseInterval,
-		deviceID:          deviceID,
-		logger:            logger,
-	}
-}
-
-// Start 开始轮询
-func (p *WakeEventPoller) Start() {
-	go p.poll()
-}
-
-// Stop 停止轮询
-func (p *WakeEventPoller) Stop() {
-	close(p.stopChan)
-}
-
-// Pause 暂停轮询（系统睡眠时调用）
-func (p *WakeEventPoller) Pause() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if !p.paused {
-		p.paused = true
-		select {
-		case p.pauseChan <- true:
-		default:
-		}
-		p.logger.InfoTag(TagService, "设备状态轮询已暂停")
-	}
-}
-
-// Resume 恢复轮询（系统唤醒时调用）
-func (p *WakeEventPoller) Resume() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.paused {
-		p.paused = false
-		select {
-		case p.pauseChan <- false:
-		default:
-		}
-		p.logger.InfoTag(TagService, "设备状态轮询已恢复")
-
-		// 如果有待处理的修复（在睡眠期间设备变异常），立即检查并处理
-		if p.pendingRepair {
-			p.logger.InfoTag(TagResume, "检测到待唤醒修复标记，立即检查设备状态")
-			// 异步处理，避免阻塞Resume调用
-			go func() {
-				// 短暂等待系统稳定
-				time.Sleep(2 * time.Second)
-
-				dm := NewDeviceManager(p.deviceID)
-				status, err := dm.GetStatus()
-				if err == nil && status != "OK" {
-					p.logger.InfoTag(TagResume, "唤醒后设备仍异常 (状态: %s)，立即执行修复", status)
-					if p.callback != nil {
-						success := p.callback()
-						if success {
-							p.pendingRepair = false
-							p.ResetRetryState()
-						}
-					}
-				} else if err == nil && status == "OK" {
-					p.logger.InfoTag(TagResume, "唤醒后设备状态正常，清除待修复标记")
-					p.pendingRepair = false
-				}
-			}()
-		}
-	}
-}
-
-// IsPaused 检查是否已暂停
-func (p *WakeEventPoller) IsPaused() bool {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	return p.paused
-}
-
-// ResetRetryState 重置重试状态（修复成功后调用）
-func (p *WakeEventPoller) ResetRetryState() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.consecutiveFails = 0
-	p.currentInterval = p.baseRetryInterval
-}
-
-// GetConsecutiveFails 获取连续失败次数
-func (p *WakeEventPoller) GetConsecutiveFails() int {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	return p.consecutiveFails
-}
-
-// incrementFails 增加失败计数并更新退避间隔
-func (p *WakeEventPoller) incrementFails() bool {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	p.consecutiveFails++
-
-	// 检查是否超过最大重试次数
-	if p.maxRetryCount > 0 && p.consecutiveFails >= p.maxRetryCount {
-		p.logger.WarningTag(TagFail, "连续失败 %d 次，已达到最大重试次数，停止自动修复", p.consecutiveFails)
-		return false // 返回 false 表示应该停止重试
-	}
-
-	// 指数退避：每次失败后间隔翻倍，但不超过最大值
-	p.currentInterval *= 2
-	if p.currentInterval > p.maxRetryInterval {
-		p.currentInterval = p.maxRetryInterval
-	}
-
-	p.logger.InfoTag(TagService, "连续失败 %d 次，下次重试间隔: %v", p.consecutiveFails, p.currentInterval)
-	return true
-}
-
-// getCurrentInterval 获取当前重试间隔
-func (p *WakeEventPoller) getCurrentInterval() time.Duration {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	return p.currentInterval
-}
-
-// poll 轮询设备状态
-func (p *WakeEventPoller) poll() {
-	dm := NewDeviceManager(p.deviceID)
-
-	// 获取初始状态
-	status, err := dm.GetStatus()
-	if err == nil {
-		p.lastStatus = status
-		if status != "OK" {
-			p.logger.InfoTag(TagResume, "服务启动时检测到设备异常状态: %s，将尝试修复", status)
-			if p.callback != nil {
-				success := p.callback()
-				p.lastRepairTime = time.Now()
-				if success {
-					p.ResetRetryState()
-				} else {
-					p.incrementFails()
-				}
-			}
-		} else {
-			p.logger.InfoTag(TagCheck, "设备初始状态正常: %s", status)
-		}
-	}
-
-	ticker := time.NewTicker(p.pollInterval)
-	defer ticker.Stop()
-
-	exceededMaxRetry := false // 是否已超过最大重试次数
-
-	for {
-		select {
-		case <-p.stopChan:
-			return
-
-		case paused := <-p.pauseChan:
-			// 处理暂停/恢复
-			if paused {
-				// 等待恢复信号或停止信号
-			waitLoop:
-				for {
-					select {
-					case <-p.stopChan:
-						return
-					case <-p.pauseChan:
-						// 收到任何信号都跳出暂停状态
-						break waitLoop
-					}
-				}
-			}
-			// 恢复后继续下一次循环
-			continue
-
-		case <-ticker.C:
-			// 如果已暂停，跳过本次轮询
-			if p.IsPaused() {
-				continue
-			}
-
-			// 如果已超过最大重试次数，只检查状态不再触发修复
-			if exceededMaxRetry {
-				status, err := dm.GetStatus()
-				if err == nil && status == "OK" {
-					p.logger.InfoTag(TagCheck, "设备状态已恢复正常: %s", status)
-					p.ResetRetryState()
-					exceededMaxRetry = false
-					p.lastStatus = status
-				}
-				continue
-			}
-
-			status, err := dm.GetStatus()
-			if err != nil {
-				continue
-			}
-
-			// 状态恢复正常，清理“待唤醒修复”标记
-			if status == "OK" {
-				p.pendingRepair = false
-			}
-
-			shouldRepair := false
-			reason := ""
-
-			idleSec := -1
-			if idleMs, err := GetIdleTime(); err == nil {
-				idleSec = int(idleMs / 1000)
-			}
-
-			// 情况1：状态从 OK 变为 Error
-			// 关键：如果系统已经长时间无输入（合盖/睡眠很常见），不要在睡眠期间修复；标记为待唤醒修复。
-			if status != "OK" && p.lastStatus == "OK" {
-				if idleSec < 0 || idleSec >= 300 {
-					p.logger.InfoTag(TagCheck, "检测到设备状态变化: %s -> %s，但系统长时间空闲（%d秒），标记待唤醒修复", p.lastStatus, status, idleSec)
-					p.pendingRepair = true
-					p.lastStatus = status
-					continue
-				}
-				p.logger.InfoTag(TagCheck, "检测到设备状态变化: %s -> %s，且系统活跃（空闲%d秒），立即修复", p.lastStatus, status, idleSec)
-				shouldRepair = true
-				reason = "状态变化"
-				p.pendingRepair = false
-				p.ResetRetryState()
-			}
-
-			// 情况2：设备一直是 Error 状态，且距离上次修复已经足够久
-			if status != "OK" && p.lastStatus != "OK" {
-				// 如果之前在长空闲/睡眠期间变异常，这里优先等待“唤醒后活跃”再立刻补修复
-				if p.pendingRepair {
-					if idleSec >= 0 && idleSec <= 60 {
-						shouldRepair = true
-						reason = "唤醒后补修复"
-						p.pendingRepair = false
-						p.ResetRetryState()
-					} else {
-						continue
-					}
-				} else {
-					// 常规持续异常处理：仅在系统活跃时重试，避免睡眠中不断重试
-					if idleSec >= 0 && idleSec > 60 {
-						continue
-					}
-				}
-
-				interval := p.getCurrentInterval()
-				if time.Since(p.lastRepairTime) > interval {
-					shouldRepair = true
-					reason = "持续异常"
-				}
-			}
-
-			if shouldRepair {
-				p.logger.InfoTag(TagResume, "检测到设备状态异常 (轮询-%s): %s -> %s", reason, p.lastStatus, status)
-				if p.callback != nil {
-					success := p.callback()
-					p.lastRepairTime = time.Now()
-					if success {
-						p.ResetRetryState()
-					} else {
-						if !p.incrementFails() {
-							exceededMaxRetry = true
-						}
-					}
-				}
-			}
-
-			p.lastStatus = status
-		}
-	}
-}
-
 // IsModernStandbySupported 检查系统是否使用 Modern Standby
 func IsModernStandbySupported() bool {
 	// 首先尝试注册表方法
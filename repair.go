@@ -0,0 +1,275 @@
+// Package main provides the repair orchestrator shared by every repair entry point.
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RepairSource 修复触发来源
+type RepairSource string
+
+const (
+	TriggerPower   RepairSource = "power"   // 系统唤醒电源事件
+	TriggerOEM     RepairSource = "oem"     // OEM 电源事件（Modern Standby 唤醒信号）
+	TriggerPoll    RepairSource = "poll"    // 轮询检测到设备异常
+	TriggerPending RepairSource = "pending" // 睡眠期间变异常，唤醒后补修复
+	TriggerManual  RepairSource = "manual"  // 命令行手动修复
+
+	TriggerLid         RepairSource = "lid"          // 开盖（wake_triggers.lid_open）
+	TriggerUnlock      RepairSource = "unlock"       // 会话解锁（wake_triggers.session_unlock）
+	TriggerPowerSource RepairSource = "power_source" // 切换交流电源/电池（wake_triggers.power_source）
+)
+
+// IsResume 是否为系统唤醒事件；只有唤醒事件计入唤醒次数，轮询、补修复和 wake_triggers 的检查不计入
+func (s RepairSource) IsResume() bool {
+	return s == TriggerPower || s == TriggerOEM
+}
+
+// RepairTrigger 一次修复请求
+type RepairTrigger struct {
+	Source     RepairSource
+	Reason     string // 触发原因，写入日志（如电源事件名称、"状态变化"）
+	Episode    string // 事件 ID；为空时使用 context 中的 ID，仍为空时生成新的
+	Attempt    int    // 连续第几次尝试（0 视为 1）
+	CheckFirst bool   // 不论 check_before_reset 设置都先检查状态，正常时跳过（OEM 事件、wake_triggers）
+}
+
+// RepairResult 修复结果
+type RepairResult string
+
+const (
+	RepairFixed   RepairResult = "fixed"   // 重置后设备状态正常
+	RepairSkipped RepairResult = "skipped" // 设备状态正常，无需修复
+	RepairFailed  RepairResult = "failed"  // 重置失败、无法验证或重置后仍异常
+	RepairVetoed  RepairResult = "vetoed"  // pre_reset 钩子阻止了重置
+)
+
+// RepairOutcome 修复结果详情
+type RepairOutcome struct {
+	Trigger      RepairTrigger
+	Result       RepairResult
+	StatusBefore string        // 修复前状态（未检查或获取失败时为空）
+	StatusAfter  string        // 修复后状态
+	Err          error         // 失败或被阻止的原因
+	Started      time.Time     // 开始处理的时间
+	Duration     time.Duration // 重置和验证耗时
+}
+
+// OK 设备是否处于正常状态（已修复或无需修复）
+func (o RepairOutcome) OK() bool {
+	return o.Result == RepairFixed || o.Result == RepairSkipped
+}
+
+// DeviceController 修复所需的设备操作（由 DeviceManager 实现，测试中使用假设备）
+type DeviceController interface {
+	GetStatus(ctx context.Context) (string, error)
+	Reset(ctx context.Context, waitDuration time.Duration) error
+}
+
+// repairStats 修复过程写入的统计（由 StatsManager 实现）
+type repairStats interface {
+	RecordResume(ctx context.Context)
+	RecordReset(ctx context.Context, success bool, result string)
+	RecordSkip(ctx context.Context)
+}
+
+// repairNotifier 修复结果通知（由 Notifier 实现）
+type repairNotifier interface {
+	NotifyRepair(ctx context.Context, r RepairReport)
+}
+
+// RepairOptions 修复流程参数
+type RepairOptions struct {
+	DeviceName       string        // 用于日志和通知的设备名称
+	DeviceID         string        // 设备实例 ID（传给钩子）
+	ResumeDelay      time.Duration // 修复前等待系统稳定的时间（手动修复不等待）
+	ResetWait        time.Duration // 禁用和启用设备之间的等待时间
+	CheckBeforeReset bool          // 修复前先检查状态，正常时跳过
+	NotifySkip       bool          // 跳过修复时也发送通知（log_all_events）
+}
+
+// RepairOrchestrator 统一的修复流程：等待 → 检查 → pre_reset 钩子 → 重置 → 验证 → 统计 → 通知 → 钩子
+// 电源事件、OEM 事件、轮询、唤醒后补修复和命令行手动修复都通过它执行
+type RepairOrchestrator struct {
+	device   DeviceController
+	opts     RepairOptions
+	clock    Clock
+	logger   FieldTagLogger
+	stats    repairStats
+	notifier repairNotifier
+	hooks    *HookRunner
+}
+
+// NewRepairOrchestrator 创建修复流程（默认输出到控制台，不记录统计、不发送通知）
+func NewRepairOrchestrator(device DeviceController, opts RepairOptions) *RepairOrchestrator {
+	return &RepairOrchestrator{
+		device: device,
+		opts:   opts,
+		clock:  systemClock{},
+		logger: ConsoleLogger{},
+	}
+}
+
+// SetLogger 设置日志输出
+func (r *RepairOrchestrator) SetLogger(logger FieldTagLogger) {
+	r.logger = logger
+}
+
+// SetClock 设置时间来源（测试用）
+func (r *RepairOrchestrator) SetClock(clock Clock) {
+	r.clock = clock
+}
+
+// SetStats 设置统计记录
+func (r *RepairOrchestrator) SetStats(stats repairStats) {
+	r.stats = stats
+}
+
+// SetNotifier 设置结果通知
+func (r *RepairOrchestrator) SetNotifier(notifier repairNotifier) {
+	r.notifier = notifier
+}
+
+// SetHooks 设置修复前后的钩子
+func (r *RepairOrchestrator) SetHooks(hooks *HookRunner) {
+	r.hooks = hooks
+}
+
+// Run 执行一次修复并返回结果
+func (r *RepairOrchestrator) Run(ctx context.Context, trigger RepairTrigger) RepairOutcome {
+	if trigger.Episode == "" {
+		trigger.Episode = EpisodeFromContext(ctx)
+	}
+	if trigger.Episode == "" {
+		trigger.Episode = NewEpisodeID(r.clock.Now())
+	}
+	if trigger.Attempt <= 0 {
+		trigger.Attempt = 1
+	}
+	ctx = WithEpisode(ctx, trigger.Episode)
+
+	outcome := RepairOutcome{Trigger: trigger, Started: r.clock.Now()}
+	fields := LogFields{Device: r.opts.DeviceName, Trigger: string(trigger.Source), Episode: trigger.Episode}
+	if trigger.Attempt > 1 {
+		fields.Attempt = trigger.Attempt
+	}
+
+	if trigger.Reason != "" {
+		r.logger.LogTagFields(INFO, TagResume, fields, "开始处理修复请求 (%s: %s)", trigger.Source, trigger.Reason)
+	} else {
+		r.logger.LogTagFields(INFO, TagResume, fields, "开始处理修复请求 (%s)", trigger.Source)
+	}
+
+	if trigger.Source != TriggerManual {
+		if r.stats != nil && trigger.Source.IsResume() {
+			r.stats.RecordResume(ctx)
+		}
+		if r.opts.ResumeDelay > 0 {
+			r.logger.LogTagFields(INFO, TagResume, fields, "等待系统稳定 (%s)...", r.opts.ResumeDelay)
+			r.clock.Sleep(r.opts.ResumeDelay)
+		}
+	}
+
+	// 修复前检查状态，正常时跳过
+	if r.opts.CheckBeforeReset || trigger.CheckFirst {
+		status, err := r.device.GetStatus(ctx)
+		if err != nil {
+			r.logger.LogTagFields(ERROR, TagCheck, fields, "获取设备状态失败，继续尝试修复: %v", err)
+		} else {
+			outcome.StatusBefore = status
+			fields.Status = status
+			if isDeviceOK(status) {
+				r.logger.LogTagFields(INFO, TagSkip, fields, "设备状态正常，无需修复")
+				outcome.Result = RepairSkipped
+				outcome.StatusAfter = status
+				// CheckFirst 的检查只是顺带确认（如切换电源、解锁），正常时不计入跳过次数
+				if r.stats != nil && !trigger.CheckFirst {
+					r.stats.RecordSkip(ctx)
+				}
+				if r.opts.NotifySkip {
+					r.notify(ctx, outcome)
+				}
+				return outcome
+			}
+			r.logger.LogTagFields(WARNING, TagCheck, fields, "设备状态异常 (%s)，需要修复", status)
+		}
+	}
+
+	hc := HookContext{
+		Device: r.opts.DeviceName, DeviceID: r.opts.DeviceID, Status: outcome.StatusBefore,
+		Attempt: trigger.Attempt, Trigger: string(trigger.Source),
+	}
+	if err := r.hooks.Run(ctx, HookPreReset, hc); err != nil {
+		r.logger.LogTagFields(WARNING, TagSkip, fields, "pre_reset 钩子阻止了本次修复: %v", err)
+		outcome.Result = RepairVetoed
+		outcome.Err = err
+		return outcome
+	}
+
+	r.logger.LogTagFields(INFO, TagReset, fields, "开始修复设备: %s", r.opts.DeviceName)
+	resetStart := r.clock.Now()
+	err := r.device.Reset(ctx, r.opts.ResetWait)
+	if err == nil {
+		var status string
+		if status, err = r.device.GetStatus(ctx); err != nil {
+			err = fmt.Errorf("无法验证修复结果: %w", err)
+		} else {
+			outcome.StatusAfter = status
+			fields.Status = status
+			if !isDeviceOK(status) {
+				err = fmt.Errorf("设备状态: %s", status)
+			}
+		}
+	} else {
+		err = fmt.Errorf("设备重置失败: %w", err)
+	}
+	outcome.Duration = r.clock.Now().Sub(resetStart)
+	fields.Duration = outcome.Duration
+
+	hc.Status = outcome.StatusAfter
+	if err != nil {
+		r.logger.LogTagFields(ERROR, TagFail, fields, "设备修复失败: %v", err)
+		outcome.Result = RepairFailed
+		outcome.Err = err
+		if r.stats != nil {
+			r.stats.RecordReset(ctx, false, err.Error())
+		}
+		r.notify(ctx, outcome)
+		hc.Error = err.Error()
+		_ = r.hooks.Run(ctx, HookPostResetFailure, hc)
+		return outcome
+	}
+
+	r.logger.LogTagFields(INFO, TagSuccess, fields, "触屏设备修复成功")
+	outcome.Result = RepairFixed
+	if r.stats != nil {
+		r.stats.RecordReset(ctx, true, "修复成功")
+	}
+	r.notify(ctx, outcome)
+	_ = r.hooks.Run(ctx, HookPostResetSuccess, hc)
+	return outcome
+}
+
+// notify 发送修复结果通知
+func (r *RepairOrchestrator) notify(ctx context.Context, o RepairOutcome) {
+	if r.notifier == nil {
+		return
+	}
+	r.notifier.NotifyRepair(ctx, RepairReport{
+		Device:       r.opts.DeviceName,
+		StatusBefore: o.StatusBefore,
+		StatusAfter:  o.StatusAfter,
+		Attempts:     o.Trigger.Attempt,
+		Fixed:        o.Result == RepairFixed,
+		Skipped:      o.Result == RepairSkipped,
+		Err:          o.Err,
+	})
+}
+
+// isDeviceOK 设备状态是否正常
+func isDeviceOK(status string) bool {
+	return strings.EqualFold(strings.TrimSpace(status), "OK")
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 可控的时钟：Sleep 和 Advance 推进时间，After 返回的通道在时钟推进到期后就绪
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	slept  []time.Duration
+	timers []fakeTimer // After 尚未到期的定时器
+}
+
+// fakeTimer After 创建的定时器
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 12, 24, 8, 30, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slept = append(c.slept, d)
+	c.advance(d)
+}
+
+// Advance 推进时钟（不计入 slept），到期的 After 通道随之就绪
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance(d)
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// After 返回在时钟推进 d 后就绪的通道
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waitForTimer 等待出现 d 后到期的定时器（即被测代码已开始等待），超时则测试失败
+func (c *fakeClock) waitForTimer(t *testing.T, d time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		at := c.now.Add(d)
+		found := false
+		for _, timer := range c.timers {
+			found = found || timer.at.Equal(at)
+		}
+		c.mu.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("等待 %s 后到期的定时器超时", d)
+}
+
+// fakeDevice 按顺序返回预设状态的设备，Reset 推进时钟
+type fakeDevice struct {
+	clock     *fakeClock
+	statuses  []string // 每次 GetStatus 依次返回，用完后重复最后一个
+	statusErr error    // GetStatus 返回的错误（非 nil 时忽略 statuses）
+	resetErr  error
+	resets    int
+	resetWait time.Duration
+}
+
+func (d *fakeDevice) GetStatus(_ context.Context) (string, error) {
+	if d.statusErr != nil {
+		return "", d.statusErr
+	}
+	status := d.statuses[0]
+	if len(d.statuses) > 1 {
+		d.statuses = d.statuses[1:]
+	}
+	return status, nil
+}
+
+func (d *fakeDevice) Reset(_ context.Context, wait time.Duration) error {
+	d.resets++
+	d.resetWait = wait
+	d.clock.Sleep(wait + time.Second) // 禁用、等待、启用
+	return d.resetErr
+}
+
+// fakeRepairStats 记录写入的统计
+type fakeRepairStats struct {
+	events []string
+}
+
+func (s *fakeRepairStats) RecordResume(context.Context) { s.events = append(s.events, "resume") }
+func (s *fakeRepairStats) RecordSkip(context.Context)   { s.events = append(s.events, "skip") }
+func (s *fakeRepairStats) RecordReset(_ context.Context, success bool, _ string) {
+	if success {
+		s.events = append(s.events, "success")
+	} else {
+		s.events = append(s.events, "fail")
+	}
+}
+
+// fakeRepairNotifier 记录发送的通知
+type fakeRepairNotifier struct {
+	reports  []RepairReport
+	episodes []string
+}
+
+func (n *fakeRepairNotifier) NotifyRepair(ctx context.Context, r RepairReport) {
+	n.reports = append(n.reports, r)
+	n.episodes = append(n.episodes, EpisodeFromContext(ctx))
+}
+
+func newTestOrchestrator(device *fakeDevice, opts RepairOptions) (*RepairOrchestrator, *fakeRepairStats, *fakeRepairNotifier) {
+	stats := &fakeRepairStats{}
+	notifier := &fakeRepairNotifier{}
+	r := NewRepairOrchestrator(device, opts)
+	r.SetClock(device.clock)
+	r.SetLogger(ConsoleLogger{MinLevel: ERROR + 1})
+	r.SetStats(stats)
+	r.SetNotifier(notifier)
+	return r, stats, notifier
+}
+
+func TestRepairOrchestrator_Run(t *testing.T) {
+	opts := RepairOptions{
+		DeviceName:       "触摸屏",
+		DeviceID:         "ACPI\\GXTP7386",
+		ResumeDelay:      3 * time.Second,
+		ResetWait:        2 * time.Second,
+		CheckBeforeReset: true,
+	}
+
+	tests := []struct {
+		name        string
+		device      fakeDevice
+		opts        func(*RepairOptions)
+		trigger     RepairTrigger
+		want        RepairResult
+		wantAfter   string
+		wantErr     string
+		wantResets  int
+		wantStats   string
+		wantNotify  int
+		wantSlept   []time.Duration
+		wantElapsed time.Duration
+	}{
+		{
+			name:       "状态正常跳过修复",
+			device:     fakeDevice{statuses: []string{"OK"}},
+			trigger:    RepairTrigger{Source: TriggerPower, Reason: "ResumeSuspend"},
+			want:       RepairSkipped,
+			wantAfter:  "OK",
+			wantStats:  "resume,skip",
+			wantSlept:  []time.Duration{3 * time.Second},
+			wantNotify: 0,
+		},
+		{
+			name:       "未开启修复前检查时 CheckFirst 仍先检查",
+			device:     fakeDevice{statuses: []string{"OK"}},
+			opts:       func(o *RepairOptions) { o.CheckBeforeReset = false },
+			trigger:    RepairTrigger{Source: TriggerOEM, Reason: "OEM 唤醒事件", CheckFirst: true},
+			want:       RepairSkipped,
+			wantAfter:  "OK",
+			wantStats:  "resume",
+			wantSlept:  []time.Duration{3 * time.Second},
+			wantNotify: 0,
+		},
+		{
+			name:       "切换电源时顺带检查不计唤醒和跳过",
+			device:     fakeDevice{statuses: []string{"OK"}},
+			trigger:    RepairTrigger{Source: TriggerPowerSource, Reason: "切换到电池", CheckFirst: true},
+			want:       RepairSkipped,
+			wantAfter:  "OK",
+			wantStats:  "",
+			wantSlept:  []time.Duration{3 * time.Second},
+			wantNotify: 0,
+		},
+		{
+			name:        "切换电源后发现异常时修复但不计唤醒",
+			device:      fakeDevice{statuses: []string{"Error", "OK"}},
+			trigger:     RepairTrigger{Source: TriggerPowerSource, Reason: "切换到电池", CheckFirst: true},
+			want:        RepairFixed,
+			wantAfter:   "OK",
+			wantResets:  1,
+			wantStats:   "success",
+			wantNotify:  1,
+			wantSlept:   []time.Duration{3 * time.Second, 3 * time.Second},
+			wantElapsed: 3 * time.Second,
+		},
+		{
+			name:       "跳过时按 log_all_events 通知",
+			device:     fakeDevice{statuses: []string{"OK"}},
+			opts:       func(o *RepairOptions) { o.NotifySkip = true },
+			trigger:    RepairTrigger{Source: TriggerPoll},
+			want:       RepairSkipped,
+			wantAfter:  "OK",
+			wantStats:  "skip",
+			wantSlept:  []time.Duration{3 * time.Second},
+			wantNotify: 1,
+		},
+		{
+			name:        "修复成功",
+			device:      fakeDevice{statuses: []string{"Error", "OK"}},
+			trigger:     RepairTrigger{Source: TriggerPoll, Reason: "状态变化", Attempt: 2},
+			want:        RepairFixed,
+			wantAfter:   "OK",
+			wantResets:  1,
+			wantStats:   "success",
+			wantNotify:  1,
+			wantSlept:   []time.Duration{3 * time.Second, 3 * time.Second},
+			wantElapsed: 3 * time.Second,
+		},
+		{
+			name:       "重置失败",
+			device:     fakeDevice{statuses: []string{"Error"}, resetErr: errors.New("拒绝访问")},
+			trigger:    RepairTrigger{Source: TriggerOEM},
+			want:       RepairFailed,
+			wantErr:    "拒绝访问",
+			wantResets: 1,
+			wantStats:  "resume,fail",
+			wantNotify: 1,
+			wantSlept:  []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:       "重置后仍异常",
+			device:     fakeDevice{statuses: []string{"Error", "Error"}},
+			trigger:    RepairTrigger{Source: TriggerPending},
+			want:       RepairFailed,
+			wantAfter:  "Error",
+			wantErr:    "设备状态: Error",
+			wantResets: 1,
+			wantStats:  "fail",
+			wantNotify: 1,
+			wantSlept:  []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:       "无法验证修复结果也记录失败",
+			device:     fakeDevice{statusErr: errors.New("PowerShell 超时")},
+			trigger:    RepairTrigger{Source: TriggerPower},
+			want:       RepairFailed,
+			wantErr:    "无法验证修复结果",
+			wantResets: 1,
+			wantStats:  "resume,fail",
+			wantNotify: 1,
+			wantSlept:  []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:        "手动修复不等待、不检查、不计唤醒",
+			device:      fakeDevice{statuses: []string{"OK"}},
+			opts:        func(o *RepairOptions) { o.CheckBeforeReset = false },
+			trigger:     RepairTrigger{Source: TriggerManual},
+			want:        RepairFixed,
+			wantAfter:   "OK",
+			wantResets:  1,
+			wantStats:   "success",
+			wantNotify:  1,
+			wantSlept:   []time.Duration{3 * time.Second},
+			wantElapsed: 3 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			device := tt.device
+			device.clock = clock
+			o := opts
+			if tt.opts != nil {
+				tt.opts(&o)
+			}
+			r, stats, notifier := newTestOrchestrator(&device, o)
+
+			start := clock.Now()
+			outcome := r.Run(context.Background(), tt.trigger)
+
+			if outcome.Result != tt.want {
+				t.Errorf("Result = %s, want %s (err: %v)", outcome.Result, tt.want, outcome.Err)
+			}
+			if outcome.OK() != (tt.want == RepairFixed || tt.want == RepairSkipped) {
+				t.Errorf("OK() = %v", outcome.OK())
+			}
+			if outcome.StatusAfter != tt.wantAfter {
+				t.Errorf("StatusAfter = %q, want %q", outcome.StatusAfter, tt.wantAfter)
+			}
+			if tt.wantErr == "" && outcome.Err != nil || tt.wantErr != "" && (outcome.Err == nil || !strings.Contains(outcome.Err.Error(), tt.wantErr)) {
+				t.Errorf("Err = %v, want %q", outcome.Err, tt.wantErr)
+			}
+			if device.resets != tt.wantResets {
+				t.Errorf("resets = %d, want %d", device.resets, tt.wantResets)
+			}
+			if tt.wantResets > 0 && device.resetWait != 2*time.Second {
+				t.Errorf("Reset wait = %s, want 2s", device.resetWait)
+			}
+			if got := strings.Join(stats.events, ","); got != tt.wantStats {
+				t.Errorf("stats = %s, want %s", got, tt.wantStats)
+			}
+			if len(notifier.reports) != tt.wantNotify {
+				t.Errorf("通知 %d 条, want %d", len(notifier.reports), tt.wantNotify)
+			}
+			if len(clock.slept) != len(tt.wantSlept) {
+				t.Errorf("slept = %v, want %v", clock.slept, tt.wantSlept)
+			}
+			if !outcome.Started.Equal(start) || outcome.Duration != tt.wantElapsed && tt.wantElapsed != 0 {
+				t.Errorf("Started = %s, Duration = %s, want %s", outcome.Started, outcome.Duration, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestRepairOrchestrator_Report(t *testing.T) {
+	clock := newFakeClock()
+	device := &fakeDevice{clock: clock, statuses: []string{"Error", "OK"}}
+	r, _, notifier := newTestOrchestrator(device, RepairOptions{DeviceName: "触摸屏", CheckBeforeReset: true})
+
+	ctx := WithEpisode(context.Background(), "ep-1")
+	outcome := r.Run(ctx, RepairTrigger{Source: TriggerPoll, Attempt: 3})
+
+	if outcome.Trigger.Episode != "ep-1" {
+		t.Errorf("Episode = %q, want ep-1", outcome.Trigger.Episode)
+	}
+	want := RepairReport{Device: "触摸屏", StatusBefore: "Error", StatusAfter: "OK", Attempts: 3, Fixed: true}
+	if len(notifier.reports) != 1 || notifier.reports[0] != want {
+		t.Fatalf("reports = %+v, want %+v", notifier.reports, want)
+	}
+	if notifier.episodes[0] != "ep-1" {
+		t.Errorf("通知的事件 ID = %q, want ep-1", notifier.episodes[0])
+	}
+
+	// 没有事件 ID 时生成新的
+	device.statuses = []string{"OK"}
+	if outcome := r.Run(context.Background(), RepairTrigger{Source: TriggerPower}); outcome.Trigger.Episode == "" || outcome.Trigger.Attempt != 1 {
+		t.Errorf("Trigger = %+v, want 新事件 ID 和 Attempt 1", outcome.Trigger)
+	}
+}
+
+func TestRepairOrchestrator_PreResetVeto(t *testing.T) {
+	t.Setenv("GPD_TEST_HOOK_MODE", "veto")
+
+	clock := newFakeClock()
+	device := &fakeDevice{clock: clock, statuses: []string{"Error"}}
+	r, stats, notifier := newTestOrchestrator(device, RepairOptions{CheckBeforeReset: true})
+	r.SetHooks(NewHookRunner(&HooksConfig{
+		PreReset: &HookConfig{Command: os.Args[0], Args: []string{"-test.run=^TestHookHelper$"}},
+	}, &recordingTagLogger{}))
+
+	outcome := r.Run(context.Background(), RepairTrigger{Source: TriggerPoll})
+	if outcome.Result != RepairVetoed || outcome.OK() {
+		t.Errorf("Result = %s, want vetoed", outcome.Result)
+	}
+	if device.resets != 0 {
+		t.Errorf("被阻止时不应重置设备, resets = %d", device.resets)
+	}
+	if got := strings.Join(stats.events, ","); got != "" {
+		t.Errorf("stats = %s, want 轮询触发不计唤醒", got)
+	}
+	if len(notifier.reports) != 0 {
+		t.Errorf("被阻止时不应发送修复结果通知, got %+v", notifier.reports)
+	}
+}
@@ -0,0 +1,203 @@
+// Package main provides the retry policies that decide how long the poller waits between failed repairs.
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// RetryPolicy 修复失败后的重试间隔策略
+type RetryPolicy interface {
+	// Next 返回连续第 fails 次失败后到下次重试的间隔；prev 为上一次的间隔（首次为基础间隔）
+	Next(fails int, prev time.Duration) time.Duration
+	// Initial 返回首次失败前的基础间隔（重置重试状态时使用）
+	Initial() time.Duration
+	String() string
+}
+
+// JitterMode 指数退避的随机抖动方式
+type JitterMode string
+
+const (
+	JitterNone         JitterMode = "none"         // 不抖动：基础间隔 × 2^失败次数
+	JitterFull         JitterMode = "full"         // 完全抖动：在 [0, 指数间隔] 内随机
+	JitterDecorrelated JitterMode = "decorrelated" // 去相关抖动：在 [基础间隔, 上次间隔 × 3] 内随机
+)
+
+// ExponentialPolicy 指数退避（可选抖动），不超过 Max
+type ExponentialPolicy struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter JitterMode
+	Rand   func() float64 // 返回 [0, 1) 的随机数（为空时使用 math/rand）
+}
+
+func (p ExponentialPolicy) random() float64 {
+	if p.Rand != nil {
+		return p.Rand()
+	}
+	return rand.Float64()
+}
+
+// Initial 基础间隔
+func (p ExponentialPolicy) Initial() time.Duration {
+	return p.Base
+}
+
+// Next 第 fails 次失败后的间隔
+func (p ExponentialPolicy) Next(fails int, prev time.Duration) time.Duration {
+	switch p.Jitter {
+	case JitterDecorrelated:
+		upper := max(prev*3, p.Base)
+		d := p.Base + time.Duration(p.random()*float64(upper-p.Base))
+		return min(d, p.Max)
+	case JitterFull:
+		return time.Duration(p.random() * float64(p.exponential(fails)))
+	}
+	return p.exponential(fails)
+}
+
+// exponential 基础间隔 × 2^fails，不超过 Max
+func (p ExponentialPolicy) exponential(fails int) time.Duration {
+	d := p.Base
+	for i := 0; i < fails && d < p.Max; i++ {
+		d *= 2
+	}
+	return min(d, p.Max)
+}
+
+// String 用于日志和 -status
+func (p ExponentialPolicy) String() string {
+	s := T("retry.exponential", p.Base, p.Max)
+	if p.Jitter != "" && p.Jitter != JitterNone {
+		s += T("retry.jitter", p.Jitter)
+	}
+	return s
+}
+
+// LinearPolicy 线性退避：基础间隔 + 失败次数 × Step，不超过 Max
+type LinearPolicy struct {
+	Base time.Duration
+	Step time.Duration
+	Max  time.Duration
+}
+
+// Initial 基础间隔
+func (p LinearPolicy) Initial() time.Duration {
+	return p.Base
+}
+
+// Next 第 fails 次失败后的间隔
+func (p LinearPolicy) Next(fails int, _ time.Duration) time.Duration {
+	return min(p.Base+time.Duration(fails)*p.Step, p.Max)
+}
+
+// String 用于日志和 -status
+func (p LinearPolicy) String() string {
+	return T("retry.linear", p.Base, p.Step, p.Max)
+}
+
+// FixedSchedulePolicy 固定间隔表：第 n 次失败后等待 Steps[n-1]，超出后重复最后一项
+type FixedSchedulePolicy struct {
+	Steps []time.Duration
+}
+
+// Initial 第一项
+func (p FixedSchedulePolicy) Initial() time.Duration {
+	return p.Steps[0]
+}
+
+// Next 第 fails 次失败后的间隔
+func (p FixedSchedulePolicy) Next(fails int, _ time.Duration) time.Duration {
+	i := min(max(fails-1, 0), len(p.Steps)-1)
+	return p.Steps[i]
+}
+
+// String 用于日志和 -status
+func (p FixedSchedulePolicy) String() string {
+	steps := make([]string, len(p.Steps))
+	for i, d := range p.Steps {
+		steps[i] = d.String()
+	}
+	return T("retry.fixed", strings.Join(steps, ", "))
+}
+
+// RetryPolicyConfig 重试策略配置
+type RetryPolicyConfig struct {
+	Type       string   `json:"type,omitempty"`         // exponential（默认）、linear 或 fixed
+	Jitter     string   `json:"jitter,omitempty"`       // exponential 的抖动方式：none（默认）、full、decorrelated
+	StepSecs   int      `json:"step_secs,omitempty"`    // linear 每次失败增加的秒数（默认等于基础间隔）
+	Schedule   []string `json:"schedule,omitempty"`     // fixed 的间隔表，如 ["10s", "30s", "2m", "10m"]
+	MaxPerWake int      `json:"max_per_wake,omitempty"` // 每次唤醒最多自动修复次数（0=不限制），用完后等下次唤醒
+	MaxPerDay  int      `json:"max_per_day,omitempty"`  // 每天最多自动修复次数（0=不限制），用完后等到第二天
+}
+
+// Validate 验证重试策略配置
+func (c *RetryPolicyConfig) Validate() error {
+	_, err := c.Policy(time.Minute, 10*time.Minute)
+	if err != nil {
+		return err
+	}
+	if c.StepSecs < 0 || c.MaxPerWake < 0 || c.MaxPerDay < 0 {
+		return fmt.Errorf("step_secs、max_per_wake、max_per_day 必须为非负数")
+	}
+	return nil
+}
+
+// Policy 根据配置创建重试策略；base、max 来自 retry_interval_secs 和 max_retry_interval
+func (c *RetryPolicyConfig) Policy(base, maxInterval time.Duration) (RetryPolicy, error) {
+	if c == nil {
+		return ExponentialPolicy{Base: base, Max: maxInterval, Jitter: JitterNone}, nil
+	}
+	switch strings.ToLower(c.Type) {
+	case "", "exponential":
+		jitter := JitterMode(strings.ToLower(c.Jitter))
+		switch jitter {
+		case "":
+			jitter = JitterNone
+		case JitterNone, JitterFull, JitterDecorrelated:
+		default:
+			return nil, fmt.Errorf("不支持的抖动方式: %s（可选 none、full、decorrelated）", c.Jitter)
+		}
+		return ExponentialPolicy{Base: base, Max: maxInterval, Jitter: jitter}, nil
+	case "linear":
+		step := base
+		if c.StepSecs > 0 {
+			step = time.Duration(c.StepSecs) * time.Second
+		}
+		return LinearPolicy{Base: base, Step: step, Max: maxInterval}, nil
+	case "fixed":
+		if len(c.Schedule) == 0 {
+			return nil, fmt.Errorf("fixed 策略需要 schedule 间隔表")
+		}
+		steps := make([]time.Duration, len(c.Schedule))
+		for i, s := range c.Schedule {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("schedule 第 %d 项无效: %w", i+1, err)
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("schedule 第 %d 项必须为正数: %s", i+1, s)
+			}
+			steps[i] = d
+		}
+		return FixedSchedulePolicy{Steps: steps}, nil
+	}
+	return nil, fmt.Errorf("不支持的重试策略: %s（可选 exponential、linear、fixed）", c.Type)
+}
+
+// RetryBudget 每次唤醒和每天的自动修复次数上限（0=不限制）
+type RetryBudget struct {
+	MaxPerWake int
+	MaxPerDay  int
+}
+
+// Budget 返回重试次数上限
+func (c *RetryPolicyConfig) Budget() RetryBudget {
+	if c == nil {
+		return RetryBudget{}
+	}
+	return RetryBudget{MaxPerWake: c.MaxPerWake, MaxPerDay: c.MaxPerDay}
+}
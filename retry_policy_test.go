@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Next(t *testing.T) {
+	half := func() float64 { return 0.5 }
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		fails  int
+		prev   time.Duration
+		want   time.Duration
+	}{
+		{"指数退避首次失败", ExponentialPolicy{Base: time.Minute, Max: 10 * time.Minute}, 1, time.Minute, 2 * time.Minute},
+		{"指数退避达到上限", ExponentialPolicy{Base: time.Minute, Max: 10 * time.Minute}, 8, 10 * time.Minute, 10 * time.Minute},
+		{"完全抖动取一半", ExponentialPolicy{Base: time.Minute, Max: 10 * time.Minute, Jitter: JitterFull, Rand: half}, 2, 0, 2 * time.Minute},
+		{"去相关抖动", ExponentialPolicy{Base: time.Minute, Max: 10 * time.Minute, Jitter: JitterDecorrelated, Rand: half}, 1, 2 * time.Minute, 3*time.Minute + 30*time.Second},
+		{"去相关抖动不超过上限", ExponentialPolicy{Base: time.Minute, Max: 5 * time.Minute, Jitter: JitterDecorrelated, Rand: half}, 3, 10 * time.Minute, 5 * time.Minute},
+		{"线性退避", LinearPolicy{Base: time.Minute, Step: 30 * time.Second, Max: 10 * time.Minute}, 3, 0, 150 * time.Second},
+		{"线性退避达到上限", LinearPolicy{Base: time.Minute, Step: time.Minute, Max: 3 * time.Minute}, 5, 0, 3 * time.Minute},
+		{"固定间隔第一项", FixedSchedulePolicy{Steps: []time.Duration{10 * time.Second, time.Minute}}, 1, 0, 10 * time.Second},
+		{"固定间隔超出后重复最后一项", FixedSchedulePolicy{Steps: []time.Duration{10 * time.Second, time.Minute}}, 5, 0, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Next(tt.fails, tt.prev); got != tt.want {
+				t.Errorf("Next(%d, %s) = %s, want %s", tt.fails, tt.prev, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_FullJitterWithinBounds(t *testing.T) {
+	p := ExponentialPolicy{Base: time.Minute, Max: 10 * time.Minute, Jitter: JitterFull}
+	for fails := 1; fails <= 10; fails++ {
+		if got := p.Next(fails, 0); got < 0 || got > p.Max {
+			t.Fatalf("Next(%d) = %s, want 在 [0, %s] 内", fails, got, p.Max)
+		}
+	}
+}
+
+func TestRetryPolicyConfig_Policy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *RetryPolicyConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "未配置时指数退避", config: nil, want: "指数退避 1m0s～10m0s"},
+		{name: "去相关抖动", config: &RetryPolicyConfig{Jitter: "Decorrelated"}, want: "指数退避 1m0s～10m0s（decorrelated 抖动）"},
+		{name: "线性默认步长", config: &RetryPolicyConfig{Type: "linear"}, want: "线性退避 1m0s 起每次 +1m0s，最多 10m0s"},
+		{name: "固定间隔表", config: &RetryPolicyConfig{Type: "fixed", Schedule: []string{"10s", "2m"}}, want: "固定间隔 [10s, 2m0s]"},
+		{name: "固定间隔表为空", config: &RetryPolicyConfig{Type: "fixed"}, wantErr: true},
+		{name: "间隔格式错误", config: &RetryPolicyConfig{Type: "fixed", Schedule: []string{"10"}}, wantErr: true},
+		{name: "间隔为零", config: &RetryPolicyConfig{Type: "fixed", Schedule: []string{"0s"}}, wantErr: true},
+		{name: "未知抖动方式", config: &RetryPolicyConfig{Jitter: "equal"}, wantErr: true},
+		{name: "未知策略", config: &RetryPolicyConfig{Type: "random"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := tt.config.Policy(time.Minute, 10*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Policy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && policy.String() != tt.want {
+				t.Errorf("Policy() = %q, want %q", policy, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/eventlog"
 )
@@ -21,12 +23,20 @@ type gpdTouchService struct {
 	logger       *Logger
 	stats        *StatsManager
 	notifier     *Notifier
+	repairer     *RepairOrchestrator
+	hooks        *HookRunner
 	poller       *WakeEventPoller
 	powerMonitor *PowerMonitor
+	wakeState    *WakeStateMachine  // 睡眠/唤醒状态（Modern Standby 下由轮询器和电源监控器驱动）
+	powerNotify  []windows.Handle   // 电源设置通知注册句柄
+	wakeEvents   *WakeEventStream   // 服务收到的电源事件
+	trace        *WakeTraceRecorder // 唤醒事件轨迹记录（可选）
+	conditions   PowerConditions    // 盖子、电源和会话锁定状态
+	stopChan     chan struct{}      // 服务停止时关闭，用于结束后台 goroutine
 }
 
 func (s *gpdTouchService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue | svc.AcceptPowerEvent
+	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue | svc.AcceptPowerEvent | svc.AcceptSessionChange
 	changes <- svc.Status{State: svc.StartPending}
 
 	// 设置事件日志
@@ -39,6 +49,11 @@ func (s *gpdTouchService) Execute(args []string, r <-chan svc.ChangeRequest, cha
 	elog.Info(1, "服务已启动")
 	s.logger.InfoTag(TagService, "服务已启动")
 
+	// 监听 -debug-for 写入的临时日志级别
+	s.stopChan = make(chan struct{})
+	go WatchLevelOverride(s.logger, GetLevelOverridePath(), 5*time.Second, s.stopChan)
+	go s.notifier.RunDigest(s.stopChan)
+
 	// 检查是否是 Modern Standby 系统
 	sleepState := GetSystemSleepState()
 	s.logger.InfoTag(TagService, "系统睡眠状态: %s", sleepState)
@@ -47,18 +62,33 @@ func (s *gpdTouchService) Execute(args []string, r <-chan svc.ChangeRequest, cha
 	// 如果是 Modern Standby，启动轮询器作为备用检测方案
 	if IsModernStandbySupported() {
 		s.logger.InfoTag(TagService, "检测到 Modern Standby，启动设备状态轮询")
+		// 轮询器和电源监控器共用同一个睡眠/唤醒状态机
+		s.wakeState = NewWakeStateMachine(nil)
+		s.wakeState.SetLogger(s.logger)
 		s.startPolling(elog)
 
 		// 同时启动电源监控器来监听显示器状态变化
-		s.powerMonitor = NewPowerMonitor(func() {
-			s.logger.InfoTag(TagService, "检测到显示器唤醒事件")
+		s.powerMonitor = NewPowerMonitor(s.wakeState, func(e WakeEvent) {
+			s.logger.InfoTag(TagService, "检测到唤醒事件: %s", PowerEventName(e.Kind))
+			s.rearmOnResume()
 			if s.poller != nil {
 				s.poller.Resume()
+				// 开盖等 wake_triggers 事件以对应来源检查
+				if source, ok := s.cfg.WakeTriggers.TriggerSource(e.Kind); ok {
+					s.poller.CheckNow(RepairTrigger{Source: source, Reason: PowerEventName(e.Kind) + "后检测到设备异常"})
+				}
 			}
 		})
 		s.logger.InfoTag(TagService, "电源监控器已启动，监听显示器状态变化")
 	}
 
+	// 电源事件经事件流依次处理
+	s.wakeEvents = NewWakeEventStream(64)
+	go s.consumeWakeEvents(elog, s.wakeEvents)
+
+	// 显示器、盖子和电源来源的变化通过电源设置通知送达（回调会立即推送到事件流，须在创建事件流之后注册）
+	s.registerPowerSettings()
+
 	changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
 
 	// 主循环
@@ -71,15 +101,22 @@ func (s *gpdTouchService) Execute(args []string, r <-chan svc.ChangeRequest, cha
 			case svc.Stop, svc.Shutdown:
 				elog.Info(1, "服务正在停止")
 				s.logger.InfoTag(TagService, "服务正在停止")
+				close(s.stopChan)
 				// 停止轮询器
 				if s.poller != nil {
 					s.poller.Stop()
 				}
-				// 停止电源监控器
+				// 取消电源设置通知（启动时总会注册，与是否启用电源监控器无关）
+				s.unregisterPowerSettings()
 				if s.powerMonitor != nil {
-					// PowerMonitor 没有显式停止方法，它会在程序退出时自动清理
 					s.logger.InfoTag(TagService, "电源监控器已停止")
 				}
+				s.wakeEvents.Close()
+				if s.trace != nil {
+					_ = s.trace.Close()
+				}
+				// 停止通知渠道的后台补发（未送达的通知保留在离线队列中）
+				_ = s.notifier.Close()
 				changes <- svc.Status{State: svc.StopPending}
 				return
 			case svc.Pause:
@@ -87,8 +124,15 @@ func (s *gpdTouchService) Execute(args []string, r <-chan svc.ChangeRequest, cha
 			case svc.Continue:
 				changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
 			case svc.PowerEvent:
-				// 电源事件处理
-				s.handlePowerEvent(elog, c.EventType)
+				// 解码后交给事件流，由 consumeWakeEvents 处理（电源设置变化已在注册的回调中解码）
+				if e, ok := DecodeServicePowerEvent(time.Now(), c.EventType); ok {
+					s.publishWakeEvent(e)
+				}
+			case svc.SessionChange:
+				// 只关心会话锁定和解锁
+				if e, ok := DecodeSessionChange(time.Now(), c.EventType); ok {
+					s.publishWakeEvent(e)
+				}
 			default:
 				elog.Error(1, fmt.Sprintf("未处理的服务命令: %v", c.Cmd))
 			}
@@ -96,257 +140,253 @@ func (s *gpdTouchService) Execute(args []string, r <-chan svc.ChangeRequest, cha
 	}
 }
 
-// handlePowerEvent 处理电源事件
-func (s *gpdTouchService) handlePowerEvent(elog *eventlog.Log, eventType uint32) {
-	// 记录所有电源事件，方便调试
-	eventName := s.getPowerEventName(eventType)
-	s.logger.InfoTag(TagService, "收到电源事件: %s (类型: %d/0x%X)", eventName, eventType, eventType)
-
-	// 如果有电源监控器，让它也处理这个事件
-	if s.powerMonitor != nil {
-		// 注意：这里假设 eventData 为 0，因为 Windows 服务 API 传递的是简化的事件
-		// PowerMonitor 会处理传统电源事件和显示器状态变化
-		if s.powerMonitor.HandlePowerBroadcast(eventType, 0) {
-			// PowerMonitor 已处理，返回
-			return
+// consumeWakeEvents 依次处理事件流中的电源事件（修复过程不阻塞服务控制请求）
+func (s *gpdTouchService) consumeWakeEvents(elog *eventlog.Log, source WakeEventSource) {
+	for e := range source.Events() {
+		if s.trace != nil {
+			if err := s.trace.Record(e); err != nil {
+				s.logger.WarningTag(TagService, "记录唤醒事件轨迹失败: %v", err)
+			}
 		}
+		s.handleWakeEvent(elog, e)
+	}
+}
+
+// handleWakeEvent 处理一条电源事件
+func (s *gpdTouchService) handleWakeEvent(elog *eventlog.Log, e WakeEvent) {
+	// 记录所有电源事件，方便调试
+	eventName := PowerEventName(e.Kind)
+	s.logger.InfoTag(TagService, "收到电源事件: %s %s", eventName, e)
+
+	// 更新盖子、电源和会话条件
+	if s.conditions.Update(e) {
+		s.logger.InfoTag(TagService, "电源条件变化: 盖子=%s, 电源=%s, 会话锁定=%v",
+			s.conditions.Lid(), s.conditions.PowerSource(), s.conditions.SessionLocked())
+	}
+
+	// require_lid_open：合盖期间的唤醒信号（如 Modern Standby 的后台唤醒）不触发修复，开盖后再处理
+	if s.deferredByLid(e) {
+		s.logger.InfoTag(TagSkip, "盖子合上，忽略%s，开盖后再检查设备", eventName)
+		return
+	}
+
+	// 如果有电源监控器，让它判断是否是 Modern Standby 唤醒（恢复事件、显示器从关闭变为开启或开盖）
+	if s.powerMonitor != nil && s.powerMonitor.HandleWakeEvent(e) {
+		// PowerMonitor 已处理，返回
+		return
 	}
 
 	// 处理唤醒相关事件:
-	// - ResumeAutomatic (18): 自动恢复
-	// - ResumeSuspend (7): 从挂起恢复
-	// - OEM事件 (10): 可能是特定硬件的唤醒信号
-	isResumeEvent := (eventType == 18 || eventType == 7)
-	isOemEvent := (eventType == 10)
+	// - ResumeAutomatic / ResumeSuspend: 从睡眠恢复
+	// - OEM 事件、电源状态改变: Modern Standby 唤醒时常见的信号，只检查状态，异常时才修复
+	// - 开盖、解锁、切换电源: 按 wake_triggers 配置检查状态，异常时才修复
+	if source, ok := s.cfg.WakeTriggers.TriggerSource(e.Kind); ok {
+		s.checkNow(elog, source, eventName)
+		return
+	}
+	isOemEvent := e.Kind == WakeOEM || e.Kind == WakePowerStatus
 
-	if !isResumeEvent && !isOemEvent {
+	if !e.IsResume() && !isOemEvent {
 		return
 	}
+	s.rearmOnResume()
 
 	// 对于OEM事件，直接检查设备状态并在需要时修复
 	// 注意：OEM事件通常是系统从Modern Standby唤醒的信号
 	if isOemEvent {
-		s.logger.InfoTag(TagService, "收到OEM事件，立即检查设备状态")
-
 		// 恢复轮询器（如果被暂停）
 		if s.poller != nil {
 			s.poller.Resume()
 			s.poller.ResetRetryState()
 		}
-
-		// 直接检查设备状态并在需要时修复（异步执行避免阻塞）
-		go func(elog *eventlog.Log) {
-			// 短暂等待系统稳定
-			time.Sleep(2 * time.Second)
-
-			dm := NewDeviceManager(s.cfg.DeviceInstanceID)
-			status, err := dm.GetStatus()
-			if err != nil {
-				s.logger.ErrorTag(TagCheck, "OEM事件后获取设备状态失败: %v", err)
-				return
-			}
-
-			s.logger.InfoTag(TagCheck, "OEM事件后设备状态: %s", status)
-
-			if !strings.EqualFold(status, "OK") {
-				s.logger.InfoTag(TagResume, "OEM事件后检测到设备异常，执行修复")
-				// 使用 handlePolledWake 执行修复（它已包含完整的修复逻辑）
-				s.handlePolledWake(elog)
-			} else {
-				s.logger.InfoTag(TagCheck, "OEM事件后设备状态正常，无需修复")
-			}
-		}(elog)
+		s.checkNow(elog, TriggerOEM, eventName)
 		return
 	}
 
-	// 确认的唤醒事件，执行完整修复流程
-	elog.Info(1, fmt.Sprintf("检测到系统从睡眠恢复 (事件: %s)", eventName))
-	s.logger.InfoTag(TagResume, "系统从睡眠唤醒 (事件类型: %s)", eventName)
-
-	// 记录唤醒事件
-	s.stats.RecordResume()
-
-	// 等待系统稳定
-	delaySeconds := s.cfg.ResumeDelaySeconds
-	if delaySeconds <= 0 {
-		delaySeconds = 3
-	}
-	s.logger.InfoTag(TagResume, "等待系统稳定 (%d 秒)...", delaySeconds)
-	time.Sleep(time.Duration(delaySeconds) * time.Second)
-
-	// 创建设备管理器
-	dm := NewDeviceManager(s.cfg.DeviceInstanceID)
-	deviceName := s.cfg.DeviceName
-	if deviceName == "" {
-		deviceName = s.cfg.DeviceInstanceID
-	}
+	// 确认的唤醒事件，执行完整修复流程（从这里开始的日志、统计和通知共用同一个事件 ID）
+	ctx, episode := NewEpisodeContext(context.Background())
+	elog.Info(1, fmt.Sprintf("检测到系统从睡眠恢复 (事件: %s, ID: %s)", eventName, episode))
+	s.repair(ctx, elog, RepairTrigger{Source: TriggerPower, Reason: eventName})
+}
 
-	// 检查设备状态（如果启用了先检查再修复）
-	if s.cfg.CheckBeforeReset {
-		status, err := dm.GetStatus()
+// registerPowerSettings 注册显示器状态等电源设置通知，变化时在回调中解码并推送到事件流
+func (s *gpdTouchService) registerPowerSettings() {
+	for _, guid := range GetPowerSettingGUIDs() {
+		handle, err := RegisterPowerSettingCallback(&guid, s.publishWakeEvent)
 		if err != nil {
-			s.logger.ErrorTag(TagCheck, "获取设备状态失败: %v", err)
-			elog.Error(1, fmt.Sprintf("获取设备状态失败: %v", err))
-			// 获取状态失败，尝试修复
-		} else {
-			s.logger.InfoTag(TagCheck, "设备状态: %s", status)
-
-			// 如果状态正常，跳过修复
-			if strings.EqualFold(status, "OK") {
-				s.logger.InfoTag(TagSkip, "设备状态正常，无需修复")
-				elog.Info(1, "设备状态正常，跳过修复")
-
-				// 记录跳过
-				s.stats.RecordSkip()
-
-				// 发送通知（如果启用且记录所有事件）
-				if s.cfg.LogAllEvents {
-					s.notifier.NotifyResumeResult(false, true, deviceName, nil)
-				}
-
-				return
-			}
-
-			s.logger.WarningTag(TagCheck, "设备状态异常 (%s)，需要修复", status)
+			s.logger.WarningTag(TagService, "注册电源设置通知失败 (%s): %v", GetPowerSettingName(guid), err)
+			continue
 		}
+		s.powerNotify = append(s.powerNotify, handle)
 	}
+}
 
-	// 执行设备重置
-	s.logger.InfoTag(TagReset, "开始修复设备: %s", deviceName)
-	elog.Info(1, fmt.Sprintf("开始修复设备: %s", deviceName))
-
-	waitDuration := time.Duration(s.cfg.WaitSeconds) * time.Second
-	if err := dm.Reset(waitDuration); err != nil {
-		s.logger.ErrorTag(TagFail, "设备修复失败: %v", err)
-		elog.Error(1, fmt.Sprintf("设备重置失败: %v", err))
+// publishWakeEvent 把电源事件推送到事件流；队列已满时丢弃并记录
+func (s *gpdTouchService) publishWakeEvent(e WakeEvent) {
+	if !s.wakeEvents.Publish(e) {
+		s.logger.WarningTag(TagService, "电源事件队列已满，丢弃事件: %s", e)
+	}
+}
 
-		// 记录失败
-		s.stats.RecordReset(false, fmt.Sprintf("失败: %v", err))
+// unregisterPowerSettings 取消电源设置通知
+func (s *gpdTouchService) unregisterPowerSettings() {
+	for _, handle := range s.powerNotify {
+		_ = UnregisterPowerNotification(handle)
+	}
+	s.powerNotify = nil
+}
 
-		// 发送失败通知
-		s.notifier.NotifyResumeResult(false, false, deviceName, err)
-		return
+// deferredByLid 配置了 require_lid_open 且盖子已合上时，唤醒类事件推迟到开盖后处理
+func (s *gpdTouchService) deferredByLid(e WakeEvent) bool {
+	if s.cfg.WakeTriggers == nil || !s.cfg.WakeTriggers.RequireLidOpen || !s.conditions.LidClosed() {
+		return false
 	}
+	switch e.Kind {
+	case WakeResumeAutomatic, WakeResumeSuspend, WakeDisplayOn, WakeOEM, WakePowerStatus, WakeSessionUnlock:
+		return true
+	}
+	return false
+}
 
-	// 验证修复结果
-	finalStatus, err := dm.GetStatus()
-	if err != nil {
-		s.logger.WarningTag(TagCheck, "无法验证修复结果: %v", err)
-		s.stats.RecordReset(false, "无法验证修复结果")
+// checkNow 立即检查设备状态，异常时才修复
+// 有轮询器时交给轮询器检查（与轮询串行执行，避免同时修复两次），否则单独检查
+func (s *gpdTouchService) checkNow(elog *eventlog.Log, source RepairSource, eventName string) {
+	if s.poller != nil {
+		s.logger.WithFields(LogFields{Trigger: string(source)}).InfoTag(TagService, "收到%s，立即检查设备状态", eventName)
+		s.poller.CheckNow(RepairTrigger{Source: source, Reason: eventName + "后检测到设备异常"})
 		return
 	}
+	go s.checkAndRepair(elog, source, eventName)
+}
 
-	s.logger.InfoTag(TagCheck, "修复后设备状态: %s", finalStatus)
-
-	// 根据实际状态判断是否成功
-	if strings.EqualFold(finalStatus, "OK") {
-		s.logger.InfoTag(TagSuccess, "触屏设备修复成功")
-		elog.Info(1, "触屏设备修复成功")
-		s.stats.RecordReset(true, "修复成功")
-		s.notifier.NotifyResumeResult(true, false, deviceName, nil)
-	} else {
-		s.logger.WarningTag(TagFail, "修复后设备仍处于异常状态: %s", finalStatus)
-		elog.Warning(1, fmt.Sprintf("修复后设备仍处于异常状态: %s", finalStatus))
-		s.stats.RecordReset(false, fmt.Sprintf("修复后状态: %s", finalStatus))
-		s.notifier.NotifyResumeResult(false, false, deviceName, fmt.Errorf("设备状态: %s", finalStatus))
-	}
+// checkAndRepair 检查设备状态，异常时才修复（OEM 事件和 wake_triggers 触发）
+// 等待系统稳定和修复前检查由修复流程负责
+func (s *gpdTouchService) checkAndRepair(elog *eventlog.Log, source RepairSource, eventName string) {
+	ctx, _ := NewEpisodeContext(context.Background())
+	s.logger.WithContext(ctx).InfoTag(TagService, "收到%s，立即检查设备状态", eventName)
+	s.repair(ctx, elog, RepairTrigger{Source: source, Reason: eventName, CheckFirst: true})
 }
 
 // startPolling 启动设备状态轮询（用于 Modern Standby 系统）
 func (s *gpdTouchService) startPolling(elog *eventlog.Log) {
 	// 配置轮询器参数
+	cooldown := s.cfg.GiveUpCooldown()
+	baseRetry, maxRetry := s.cfg.RetryIntervals()
 	pollerCfg := &PollerConfig{
-		BaseRetryInterval: time.Duration(s.cfg.RetryIntervalSecs) * time.Second,
-		MaxRetryInterval:  time.Duration(s.cfg.MaxRetryInterval) * time.Second,
+		BaseRetryInterval: baseRetry,
+		MaxRetryInterval:  maxRetry,
 		MaxRetryCount:     s.cfg.MaxRetryCount,
+		StateMachine:      s.wakeState,
+		GiveUpCooldown:    cooldown,
+		OnGiveUp: func(ctx context.Context, attempts int) {
+			elog.Warning(1, fmt.Sprintf("连续修复失败 %d 次，已停止自动修复，%s 后或下次唤醒时恢复", attempts, cooldown))
+			s.stats.RecordGiveUp(ctx, attempts, time.Now().Add(cooldown))
+			s.notifier.NotifyGiveUp(ctx, s.deviceDisplayName(), attempts)
+			_ = s.hooks.Run(ctx, HookOnGiveUp, HookContext{
+				Device: s.deviceDisplayName(), DeviceID: s.cfg.DeviceInstanceID, Attempt: attempts, Trigger: "poll",
+			})
+		},
+		OnRearm: func(ctx context.Context, reason string) {
+			elog.Info(1, fmt.Sprintf("已恢复自动修复 (原因: %s)", reason))
+			s.stats.ClearGiveUp(ctx, reason)
+		},
+		// -rearm 清除统计文件中的停止状态后，轮询器在下次轮询时恢复
+		RearmRequested: func() bool {
+			return s.stats.GiveUpState() == nil
+		},
+		Schedule:       s.cfg.PollSchedule(),
+		StatePath:      filepath.Join(GetStatsDir(), PollerStateFileName),
+		StateMaxAge:    s.cfg.PollerStateMaxAge(),
+		IdleThresholds: s.cfg.IdleThresholds(),
+		OnCostReport: func(report PollCostReport) {
+			s.stats.RecordPollCost(report)
+		},
+	}
+	// 配置已在加载时验证，这里出错只可能是程序问题，回退到默认的指数退避
+	policy, err := s.cfg.RetryPolicy.Policy(baseRetry, maxRetry)
+	if err != nil {
+		s.logger.WarningTag(TagService, "重试策略无效，使用默认指数退避: %v", err)
+		policy = ExponentialPolicy{Base: baseRetry, Max: maxRetry, Jitter: JitterNone}
+	}
+	pollerCfg.RetryPolicy = policy
+	pollerCfg.RetryBudget = s.cfg.RetryPolicy.Budget()
+	pollerCfg.Flap = s.cfg.FlapDetection.Thresholds()
+	pollerCfg.OnQuarantine = func(ctx context.Context, snap FlapSnapshot) {
+		s.quarantine(ctx, elog, snap)
 	}
-	if pollerCfg.BaseRetryInterval <= 0 {
-		pollerCfg.BaseRetryInterval = 60 * time.Second
+	pollerCfg.OnRelease = func(_ context.Context, since time.Time) {
+		elog.Info(1, fmt.Sprintf("设备状态已稳定，解除隔离 (自 %s 起隔离)", since.Format("2006-01-02 15:04:05")))
 	}
-	if pollerCfg.MaxRetryInterval <= 0 {
-		pollerCfg.MaxRetryInterval = 10 * time.Minute
+
+	// 上次运行遗留的停止状态：新启动的轮询器重新计数
+	if s.stats.ClearGiveUp(context.Background(), "service start") {
+		s.logger.InfoTag(TagService, "清除上次运行遗留的停止自动修复状态")
 	}
 
-	s.poller = NewWakeEventPoller(s.cfg.DeviceInstanceID, func() bool {
-		return s.handlePolledWake(elog)
+	s.poller = NewWakeEventPoller(s.cfg.DeviceInstanceID, func(ctx context.Context, trigger RepairTrigger) RepairResult {
+		return s.repair(ctx, elog, trigger)
 	}, s.logger, pollerCfg)
 	s.poller.Start()
-	s.logger.InfoTag(TagService, "设备状态轮询已启动 (间隔: 10秒)")
+	s.logger.InfoTag(TagService, "设备状态轮询已启动 (间隔: %s; %s; 重试: %s; 抖动: %s)", s.poller.Schedule(), s.cfg.IdleThresholds(), policy, pollerCfg.Flap)
 	elog.Info(1, "Modern Standby 设备状态轮询已启动")
 }
 
-// handlePolledWake 处理轮询检测到的唤醒/设备错误事件
-// 返回 true 表示修复成功，false 表示失败
-func (s *gpdTouchService) handlePolledWake(elog *eventlog.Log) bool {
-	s.logger.InfoTag(TagResume, "轮询检测到设备异常，开始修复")
-	elog.Info(1, "轮询检测到设备异常，开始修复")
-
-	// 记录唤醒事件
-	s.stats.RecordResume()
-
-	// 等待系统稳定
-	delaySeconds := s.cfg.ResumeDelaySeconds
-	if delaySeconds <= 0 {
-		delaySeconds = 3
-	}
-	s.logger.InfoTag(TagResume, "等待系统稳定 (%d 秒)...", delaySeconds)
-	time.Sleep(time.Duration(delaySeconds) * time.Second)
-
-	// 创建设备管理器
-	dm := NewDeviceManager(s.cfg.DeviceInstanceID)
-	deviceName := s.cfg.DeviceName
-	if deviceName == "" {
-		deviceName = s.cfg.DeviceInstanceID
-	}
-
-	// 再次检查状态，可能在等待期间已经恢复
-	status, err := dm.GetStatus()
-	if err == nil && strings.EqualFold(status, "OK") {
-		s.logger.InfoTag(TagSkip, "等待后设备状态已恢复正常，跳过修复")
-		elog.Info(1, "等待后设备状态已恢复正常，跳过修复")
-		s.stats.RecordSkip()
-		return true // 设备已正常，视为成功
-	}
-
-	// 执行设备重置
-	s.logger.InfoTag(TagReset, "开始修复设备: %s", deviceName)
-	elog.Info(1, fmt.Sprintf("开始修复设备: %s", deviceName))
-
-	waitDuration := time.Duration(s.cfg.WaitSeconds) * time.Second
-	if err := dm.Reset(waitDuration); err != nil {
-		s.logger.ErrorTag(TagFail, "设备修复失败: %v", err)
-		elog.Error(1, fmt.Sprintf("设备重置失败: %v", err))
-		s.stats.RecordReset(false, fmt.Sprintf("失败: %v", err))
-		s.notifier.NotifyResumeResult(false, false, deviceName, err)
-		return false
+// quarantine 设备状态频繁变化：补充电源条件后保存诊断快照，写入事件日志并通知用户
+func (s *gpdTouchService) quarantine(ctx context.Context, elog *eventlog.Log, snap FlapSnapshot) {
+	snap.Environment = map[string]string{
+		"lid":            string(s.conditions.Lid()),
+		"power_source":   string(s.conditions.PowerSource()),
+		"session_locked": fmt.Sprint(s.conditions.SessionLocked()),
+		"version":        Version,
 	}
-
-	// 验证修复结果
-	finalStatus, err := dm.GetStatus()
+	path, err := SaveFlapSnapshot(GetStatsDir(), snap)
 	if err != nil {
-		s.logger.WarningTag(TagCheck, "无法验证修复结果: %v", err)
-		// 无法验证，不确定是否成功，视为失败
-		s.stats.RecordReset(false, "无法验证修复结果")
-		return false
+		s.logger.WithContext(ctx).WarningTag(TagFlap, "%v", err)
+	} else {
+		s.logger.WithContext(ctx).InfoTag(TagFlap, "诊断快照已保存: %s", path)
+	}
+	elog.Warning(1, fmt.Sprintf("设备状态频繁变化（%s），已暂停自动修复", snap.Summary()))
+	s.notifier.NotifyQuarantine(ctx, s.deviceDisplayName(), snap.Summary(), snap.ReleaseAt, path)
+}
+
+// rearmOnResume 真正的唤醒事件后恢复已停止的自动修复
+func (s *gpdTouchService) rearmOnResume() {
+	if s.poller != nil {
+		s.poller.Rearm(context.Background(), "resume")
 	}
+}
 
-	s.logger.InfoTag(TagCheck, "修复后设备状态: %s", finalStatus)
+// deviceDisplayName 返回用于日志和通知的设备名称（未配置友好名称时使用实例 ID）
+func (s *gpdTouchService) deviceDisplayName() string {
+	return s.cfg.DeviceDisplayName()
+}
 
-	// 根据实际状态判断是否成功
-	if strings.EqualFold(finalStatus, "OK") {
-		s.logger.InfoTag(TagSuccess, "触屏设备修复成功")
-		elog.Info(1, "触屏设备修复成功")
-		s.stats.RecordReset(true, "修复成功")
-		s.notifier.NotifyResumeResult(true, false, deviceName, nil)
-		return true
+// repairAttempt 返回本次修复是连续第几次尝试（轮询器记录的连续失败次数 + 1）
+func (s *gpdTouchService) repairAttempt() int {
+	if s.poller == nil {
+		return 1
+	}
+	return s.poller.GetConsecutiveFails() + 1
+}
+
+// repair 通过修复流程执行一次修复，并把结果写入 Windows 事件日志，返回修复结果
+func (s *gpdTouchService) repair(ctx context.Context, elog *eventlog.Log, trigger RepairTrigger) RepairResult {
+	if trigger.Source != TriggerPower && trigger.Source != TriggerManual {
+		trigger.Attempt = s.repairAttempt()
 	}
 
-	// 修复后设备仍处于错误状态
-	s.logger.WarningTag(TagFail, "修复后设备仍处于异常状态: %s", finalStatus)
-	elog.Warning(1, fmt.Sprintf("修复后设备仍处于异常状态: %s", finalStatus))
-	s.stats.RecordReset(false, fmt.Sprintf("修复后状态: %s", finalStatus))
-	s.notifier.NotifyResumeResult(false, false, deviceName, fmt.Errorf("设备状态: %s", finalStatus))
-	return false
+	outcome := s.repairer.Run(ctx, trigger)
+	switch outcome.Result {
+	case RepairFixed:
+		elog.Info(1, fmt.Sprintf("触屏设备修复成功 (ID: %s)", outcome.Trigger.Episode))
+	case RepairSkipped:
+		elog.Info(1, fmt.Sprintf("设备状态正常，跳过修复 (ID: %s)", outcome.Trigger.Episode))
+	case RepairVetoed:
+		elog.Warning(1, fmt.Sprintf("pre_reset 钩子阻止了本次修复: %v (ID: %s)", outcome.Err, outcome.Trigger.Episode))
+	default:
+		elog.Error(1, fmt.Sprintf("触屏设备修复失败: %v (ID: %s)", outcome.Err, outcome.Trigger.Episode))
+	}
+	return outcome.Result
 }
 
 func runService() error {
@@ -360,27 +400,68 @@ func runService() error {
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("配置验证失败: %w", err)
 	}
+	SetLocale(DetectLocale(cfg.Language)) // 通知标题等文本在创建通知管理器时确定
 
 	// 初始化日志
 	logDir := cfg.LogDir
 	if logDir == "" {
 		logDir = GetLogDir()
 	}
-	if err := InitLogger(logDir, INFO); err != nil {
+	logLevel, _ := ParseLogLevel(cfg.LogLevel) // 已在 Validate 中校验
+	if err := InitLogger(logDir, logLevel); err != nil {
 		log.Printf("警告: 初始化日志失败: %v", err)
 	}
 	logger := GetLogger()
-
-	// 清理过期日志
-	if cfg.MaxLogDays > 0 {
-		_ = CleanOldLogs(cfg.MaxLogDays)
+	tagLevels, _ := ParseTagLevels(cfg.LogTagLevels)
+	logger.SetTagLevels(tagLevels)
+	logger.CaptureStdLog()
+	logFormat, _ := ParseLogFormat(cfg.LogFormat) // 已在 Validate 中校验
+	logger.SetFormat(logFormat)
+
+	// 可选：同时发送到 syslog 采集端
+	if cfg.Syslog != nil {
+		if sink, err := NewSyslogSink(*cfg.Syslog); err != nil {
+			logger.WarningTag(TagConfig, "初始化 syslog 输出失败: %v", err)
+		} else {
+			logger.AddSink(sink)
+			logger.InfoTag(TagConfig, "日志同时发送到 syslog: %s://%s", cfg.Syslog.Network, cfg.Syslog.Address)
+		}
 	}
 
+	// 日志按天和大小轮转，每次轮转后按保留策略清理
+	logger.SetRotation(cfg.LogRotation())
+	_ = logger.CleanLogs()
+
 	// 初始化统计
 	stats := NewStatsManager(GetStatsDir())
 
 	// 初始化通知
-	notifier := NewNotifier(cfg.EnableNotification)
+	notifier, err := NewNotifierFromConfig(cfg)
+	if err != nil {
+		logger.WarningTag(TagConfig, "初始化通知渠道失败，仅使用 Windows 通知: %v", err)
+		notifier = NewNotifier(cfg.EnableNotification)
+	}
+	notifier.SetLogger(logger)
+
+	// 所有触发来源共用的修复流程
+	dm := NewDeviceManager(cfg.DeviceInstanceID)
+	dm.SetLogger(logger)
+	repairer := NewRepairOrchestrator(dm, cfg.RepairOptions())
+	repairer.SetLogger(logger)
+	repairer.SetStats(stats)
+	repairer.SetNotifier(notifier)
+	hooks := NewHookRunner(cfg.Hooks, logger)
+	repairer.SetHooks(hooks)
+
+	// 可选：记录收到的电源事件，便于在其他机器上回放
+	var trace *WakeTraceRecorder
+	if path := cfg.WakeTracePath(logDir); path != "" {
+		if trace, err = OpenWakeTraceRecorder(path); err != nil {
+			logger.WarningTag(TagConfig, "打开唤醒事件轨迹文件失败: %v", err)
+		} else {
+			logger.InfoTag(TagConfig, "记录唤醒事件轨迹: %s", path)
+		}
+	}
 
 	// 运行服务
 	return svc.Run(serviceName, &gpdTouchService{
@@ -388,6 +469,9 @@ func runService() error {
 		logger:   logger,
 		stats:    stats,
 		notifier: notifier,
+		repairer: repairer,
+		hooks:    hooks,
+		trace:    trace,
 	})
 }
 
@@ -481,29 +565,3 @@ func GetServiceConfigPath() string {
 	dir := filepath.Dir(exe)
 	return filepath.Join(dir, "config.json")
 }
-
-// getPowerEventName 返回电源事件的可读名称
-func (s *gpdTouchService) getPowerEventName(eventType uint32) string {
-	// Windows 电源事件类型
-	// https://docs.microsoft.com/en-us/windows/win32/power/power-management-events
-	switch eventType {
-	case 4: // PBT_APMPOWERSTATUSCHANGE
-		return "电源状态改变"
-	case 5: // PBT_APMRESUMEAUTOMATIC (旧版)
-		return "自动恢复(旧版)"
-	case 6: // PBT_APMRESUMECRITICAL (已废弃)
-		return "关键恢复(已废弃)"
-	case 7: // PBT_APMRESUMESUSPEND
-		return "从挂起恢复(盖子打开/电源按钮)"
-	case 9: // PBT_APMSUSPEND
-		return "系统挂起(盖子关闭/睡眠)"
-	case 10: // PBT_APMOEMEVENT
-		return "OEM事件"
-	case 18: // PBT_APMRESUMEAUTOMATIC
-		return "自动恢复(唤醒)"
-	case 0x8013: // PBT_POWERSETTINGCHANGE
-		return "电源设置变化(显示器/盖子状态)"
-	default:
-		return fmt.Sprintf("未知事件(%d)", eventType)
-	}
-}
@@ -2,10 +2,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,6 +22,8 @@ const (
 	EventSkip    EventType = "SKIP"    // 跳过修复
 	EventSuccess EventType = "SUCCESS" // 修复成功
 	EventFail    EventType = "FAIL"    // 修复失败
+	EventGiveUp  EventType = "GIVE_UP" // 达到最大重试次数，停止自动修复
+	EventRearm   EventType = "REARM"   // 恢复自动修复
 )
 
 // EventRecord 事件记录
@@ -29,6 +33,7 @@ type EventRecord struct {
 	DeviceStatus string    `json:"device_status,omitempty"`
 	Message      string    `json:"message"`
 	Success      bool      `json:"success"`
+	Episode      string    `json:"episode,omitempty"` // 唤醒修复事件 ID
 }
 
 // Stats 统计数据
@@ -55,10 +60,31 @@ type Stats struct {
 	LastEventTime   *time.Time `json:"last_event_time,omitempty"`   // 上次事件时间
 	LastResetResult string     `json:"last_reset_result,omitempty"` // 上次修复结果
 
+	// 停止自动修复
+	TotalGiveUps int          `json:"total_give_ups,omitempty"` // 累计停止自动修复次数
+	GaveUp       *GiveUpState `json:"gave_up,omitempty"`        // 当前处于停止状态时非空（服务和 -rearm 通过它同步）
+
+	// 最近一个周期的轮询开销（Modern Standby 轮询器每小时更新）
+	PollCost *PollCostReport `json:"poll_cost,omitempty"`
+
+	// 事件历史（最近 maxHistoryRecords 条，用于导出和多机汇总）
+	History []EventRecord `json:"history,omitempty"`
+
 	// 内部使用
 	LastStatDate string `json:"last_stat_date"` // 上次统计日期，用于重置计数器
 }
 
+// GiveUpState 停止自动修复状态
+type GiveUpState struct {
+	Since    time.Time `json:"since"`           // 停止时间
+	Attempts int       `json:"attempts"`        // 连续失败次数
+	Until    time.Time `json:"until,omitempty"` // 冷却结束、自动恢复的时间
+	Episode  string    `json:"episode,omitempty"`
+}
+
+// maxHistoryRecords 事件历史最大保留条数
+const maxHistoryRecords = 1000
+
 // StatsManager 统计管理器
 type StatsManager struct {
 	stats    *Stats
@@ -89,11 +115,27 @@ func (sm *StatsManager) getStatsFilePath() string {
 	return filepath.Join(sm.statsDir, "stats.json")
 }
 
+// getLockFilePath 获取统计锁文件路径
+func (sm *StatsManager) getLockFilePath() string {
+	return filepath.Join(sm.statsDir, "stats.json.lock")
+}
+
 // load 加载统计数据
+// 服务和 CLI 会同时读写 stats.json，因此读取也需持有跨进程文件锁
 func (sm *StatsManager) load() error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	lock, err := acquireFileLock(sm.getLockFilePath())
+	if err == nil {
+		defer lock.Release()
+	}
+
+	return sm.loadLocked()
+}
+
+// loadLocked 从磁盘读取统计数据（调用方需持有锁）
+func (sm *StatsManager) loadLocked() error {
 	data, err := os.ReadFile(sm.getStatsFilePath())
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -114,7 +156,32 @@ func (sm *StatsManager) load() error {
 	return nil
 }
 
-// save 保存统计数据
+// update 在跨进程文件锁保护下执行 读取-修改-保存
+// 每次修改前都从磁盘重新加载，避免覆盖其他进程写入的计数
+func (sm *StatsManager) update(modify func()) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if err := os.MkdirAll(sm.statsDir, 0o755); err != nil {
+		return err
+	}
+
+	lock, err := acquireFileLock(sm.getLockFilePath())
+	if err != nil {
+		return fmt.Errorf("获取统计文件锁失败: %w", err)
+	}
+	defer lock.Release()
+
+	// 文件损坏时沿用内存中的数据，保存时会覆盖损坏的文件
+	_ = sm.loadLocked()
+	sm.checkDateRollover()
+
+	modify()
+
+	return sm.save()
+}
+
+// save 保存统计数据（先写临时文件再重命名，避免读取到写了一半的文件）
 func (sm *StatsManager) save() error {
 	data, err := json.MarshalIndent(sm.stats, "", "  ")
 	if err != nil {
@@ -126,7 +193,12 @@ func (sm *StatsManager) save() error {
 		return err
 	}
 
-	return os.WriteFile(sm.getStatsFilePath(), data, 0o644)
+	tmpPath := sm.getStatsFilePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, sm.getStatsFilePath())
 }
 
 // checkDateRollover 检查日期变化，重置计数器
@@ -173,142 +245,276 @@ func (sm *StatsManager) checkDateRollover() {
 }
 
 // RecordResume 记录唤醒事件
-func (sm *StatsManager) RecordResume() {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	sm.checkDateRollover()
+func (sm *StatsManager) RecordResume(ctx context.Context) {
+	_ = sm.update(func() {
+		now := time.Now()
+		sm.stats.TotalResumeEvents++
+		sm.stats.LastResumeTime = &now
+		sm.appendHistory(EventRecord{
+			Timestamp: now,
+			Type:      EventResume,
+			Message:   "系统唤醒",
+			Success:   true,
+			Episode:   EpisodeFromContext(ctx),
+		})
+	})
+}
 
-	now := time.Now()
-	sm.stats.TotalResumeEvents++
-	sm.stats.LastResumeTime = &now
+// RecordReset 记录修复事件
+func (sm *StatsManager) RecordReset(ctx context.Context, success bool, result string) {
+	_ = sm.update(func() {
+		now := time.Now()
+		sm.stats.LastResetTime = &now
+		sm.stats.LastEventTime = &now
+		sm.stats.LastResetResult = result
+
+		if success {
+			sm.stats.TotalResets++
+			sm.stats.TodayResets++
+			sm.stats.WeekResets++
+			sm.stats.MonthResets++
+		} else {
+			sm.stats.TotalFailures++
+			sm.stats.TodayFailures++
+			sm.stats.WeekFailures++
+			sm.stats.MonthFailures++
+		}
 
-	_ = sm.save()
+		eventType := EventFail
+		if success {
+			eventType = EventSuccess
+		}
+		sm.appendHistory(EventRecord{
+			Timestamp: now,
+			Type:      eventType,
+			Message:   result,
+			Success:   success,
+			Episode:   EpisodeFromContext(ctx),
+		})
+	})
 }
 
-// RecordReset 记录修复事件
-func (sm *StatsManager) RecordReset(success bool, result string) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+// RecordSkip 记录跳过事件
+func (sm *StatsManager) RecordSkip(ctx context.Context) {
+	_ = sm.update(func() {
+		now := time.Now()
+		sm.stats.LastEventTime = &now
+		sm.stats.LastResetResult = "状态正常，已跳过"
+
+		sm.stats.TotalSkips++
+		sm.stats.TodaySkips++
+		sm.stats.WeekSkips++
+		sm.stats.MonthSkips++
+
+		sm.appendHistory(EventRecord{
+			Timestamp:    now,
+			Type:         EventSkip,
+			DeviceStatus: "OK",
+			Message:      "状态正常，已跳过",
+			Success:      true,
+			Episode:      EpisodeFromContext(ctx),
+		})
+	})
+}
 
-	sm.checkDateRollover()
+// RecordGiveUp 记录停止自动修复（until 为冷却结束、自动恢复的时间）
+func (sm *StatsManager) RecordGiveUp(ctx context.Context, attempts int, until time.Time) {
+	_ = sm.update(func() {
+		now := time.Now()
+		sm.stats.LastEventTime = &now
+		sm.stats.TotalGiveUps++
+		sm.stats.GaveUp = &GiveUpState{Since: now, Attempts: attempts, Until: until, Episode: EpisodeFromContext(ctx)}
+
+		sm.appendHistory(EventRecord{
+			Timestamp: now,
+			Type:      EventGiveUp,
+			Message:   fmt.Sprintf("连续失败 %d 次，停止自动修复", attempts),
+			Episode:   EpisodeFromContext(ctx),
+		})
+	})
+}
 
-	now := time.Now()
-	sm.stats.LastResetTime = &now
-	sm.stats.LastEventTime = &now
-	sm.stats.LastResetResult = result
-
-	if success {
-		sm.stats.TotalResets++
-		sm.stats.TodayResets++
-		sm.stats.WeekResets++
-		sm.stats.MonthResets++
-	} else {
-		sm.stats.TotalFailures++
-		sm.stats.TodayFailures++
-		sm.stats.WeekFailures++
-		sm.stats.MonthFailures++
-	}
+// ClearGiveUp 清除停止状态，恢复自动修复；reason 说明恢复原因（如 cooldown、resume、manual）
+// 当前不处于停止状态时返回 false
+func (sm *StatsManager) ClearGiveUp(ctx context.Context, reason string) bool {
+	cleared := false
+	_ = sm.update(func() {
+		if sm.stats.GaveUp == nil {
+			return
+		}
+		cleared = true
+		sm.stats.GaveUp = nil
+		sm.appendHistory(EventRecord{
+			Timestamp: time.Now(),
+			Type:      EventRearm,
+			Message:   "恢复自动修复: " + reason,
+			Success:   true,
+			Episode:   EpisodeFromContext(ctx),
+		})
+	})
+	return cleared
+}
 
-	_ = sm.save()
+// RecordPollCost 记录最近一个周期的轮询开销（供 -status 显示）
+func (sm *StatsManager) RecordPollCost(report PollCostReport) {
+	_ = sm.update(func() {
+		sm.stats.PollCost = &report
+	})
 }
 
-// RecordSkip 记录跳过事件
-func (sm *StatsManager) RecordSkip() {
+// PollCost 返回最近一个周期的轮询开销（没有记录时返回 nil）
+func (sm *StatsManager) PollCost() *PollCostReport {
+	_ = sm.load()
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	sm.checkDateRollover()
+	if sm.stats.PollCost == nil {
+		return nil
+	}
+	report := *sm.stats.PollCost
+	return &report
+}
 
-	now := time.Now()
-	sm.stats.LastEventTime = &now
-	sm.stats.LastResetResult = "状态正常，已跳过"
+// GiveUpState 返回当前的停止自动修复状态（未停止时返回 nil）
+func (sm *StatsManager) GiveUpState() *GiveUpState {
+	_ = sm.load()
 
-	sm.stats.TotalSkips++
-	sm.stats.TodaySkips++
-	sm.stats.WeekSkips++
-	sm.stats.MonthSkips++
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 
-	_ = sm.save()
+	if sm.stats.GaveUp == nil {
+		return nil
+	}
+	state := *sm.stats.GaveUp
+	return &state
 }
 
-// GetStats 获取统计数据副本
+// appendHistory 追加事件历史，超出上限时丢弃最旧的记录（调用方需持有锁）
+func (sm *StatsManager) appendHistory(record EventRecord) {
+	sm.stats.History = append(sm.stats.History, record)
+	if len(sm.stats.History) > maxHistoryRecords {
+		sm.stats.History = sm.stats.History[len(sm.stats.History)-maxHistoryRecords:]
+	}
+}
+
+// GetStats 获取统计数据副本（从磁盘重新加载，反映其他进程的最新写入）
 func (sm *StatsManager) GetStats() Stats {
+	_ = sm.load()
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	sm.checkDateRollover()
 
-	return *sm.stats
+	stats := *sm.stats
+	stats.History = append([]EventRecord(nil), sm.stats.History...)
+	return stats
+}
+
+// GetHistory 获取事件历史副本（按时间顺序）
+func (sm *StatsManager) GetHistory() []EventRecord {
+	_ = sm.load()
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	return append([]EventRecord(nil), sm.stats.History...)
 }
 
 // FormatStats 格式化统计数据为人类可读格式
 func (sm *StatsManager) FormatStats() string {
 	stats := sm.GetStats()
 
-	var result string
-	result += "╔══════════════════════════════════════════╗\n"
-	result += "║           📊 统计信息                     ║\n"
-	result += "╠══════════════════════════════════════════╣\n"
-
-	// 今日统计
-	result += "║ 📅 今日                                  ║\n"
-	result += fmt.Sprintf("║    修复: %-3d  跳过: %-3d  失败: %-3d       ║\n",
-		stats.TodayResets, stats.TodaySkips, stats.TodayFailures)
+	var sb strings.Builder
+	border := strings.Repeat("═", statsBoxWidth)
+	line := func(text string) {
+		sb.WriteString("║ " + padDisplay(text, statsBoxWidth-1) + "║\n")
+	}
 
-	// 本周统计
-	result += "║ 📆 本周                                  ║\n"
-	result += fmt.Sprintf("║    修复: %-3d  跳过: %-3d  失败: %-3d       ║\n",
-		stats.WeekResets, stats.WeekSkips, stats.WeekFailures)
+	sb.WriteString("╔" + border + "╗\n")
+	line("          " + T("stats.title"))
+	sb.WriteString("╠" + border + "╣\n")
 
-	// 本月统计
-	result += "║ 🗓️  本月                                  ║\n"
-	result += fmt.Sprintf("║    修复: %-3d  跳过: %-3d  失败: %-3d       ║\n",
-		stats.MonthResets, stats.MonthSkips, stats.MonthFailures)
+	// 今日、本周、本月统计
+	line(T("stats.today"))
+	line(T("stats.counts", stats.TodayResets, stats.TodaySkips, stats.TodayFailures))
+	line(T("stats.week"))
+	line(T("stats.counts", stats.WeekResets, stats.WeekSkips, stats.WeekFailures))
+	line(T("stats.month"))
+	line(T("stats.counts", stats.MonthResets, stats.MonthSkips, stats.MonthFailures))
 
 	// 累计统计
-	result += "╠══════════════════════════════════════════╣\n"
-	result += fmt.Sprintf("║ 📈 累计                                  ║\n")
-	result += fmt.Sprintf("║    唤醒: %-5d                            ║\n", stats.TotalResumeEvents)
-	result += fmt.Sprintf("║    修复: %-5d                            ║\n", stats.TotalResets)
-	result += fmt.Sprintf("║    跳过: %-5d                            ║\n", stats.TotalSkips)
-	result += fmt.Sprintf("║    失败: %-5d                            ║\n", stats.TotalFailures)
+	sb.WriteString("╠" + border + "╣\n")
+	line(T("stats.total"))
+	line(T("stats.total.resumes", stats.TotalResumeEvents))
+	line(T("stats.total.resets", stats.TotalResets))
+	line(T("stats.total.skips", stats.TotalSkips))
+	line(T("stats.total.failures", stats.TotalFailures))
+	if stats.TotalGiveUps > 0 {
+		line(T("stats.total.give_ups", stats.TotalGiveUps))
+	}
 
 	// 最近事件
-	result += "╠══════════════════════════════════════════╣\n"
-	result += "║ 🕐 最近事件                              ║\n"
+	sb.WriteString("╠" + border + "╣\n")
+	line(T("stats.recent"))
 
-	if stats.LastResumeTime != nil {
-		result += fmt.Sprintf("║    上次唤醒: %s   ║\n", stats.LastResumeTime.Format("2006-01-02 15:04:05"))
-	} else {
-		result += "║    上次唤醒: 无记录                      ║\n"
-	}
-
-	if stats.LastResetTime != nil {
-		result += fmt.Sprintf("║    上次修复: %s   ║\n", stats.LastResetTime.Format("2006-01-02 15:04:05"))
-	} else {
-		result += "║    上次修复: 无记录                      ║\n"
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return T("stats.no_record")
+		}
+		return t.Format("2006-01-02 15:04:05")
 	}
+	line(T("stats.last_resume", formatTime(stats.LastResumeTime)))
+	line(T("stats.last_reset", formatTime(stats.LastResetTime)))
 
 	if stats.LastResetResult != "" {
 		// 截断结果字符串以适应宽度
-		displayResult := stats.LastResetResult
+		displayResult := []rune(stats.LastResetResult)
 		if len(displayResult) > 28 {
-			displayResult = displayResult[:25] + "..."
+			displayResult = append(displayResult[:25], []rune("...")...)
 		}
-		result += fmt.Sprintf("║    结果: %-32s ║\n", displayResult)
+		line(T("stats.result", string(displayResult)))
 	}
 
-	result += "╚══════════════════════════════════════════╝\n"
+	sb.WriteString("╚" + border + "╝\n")
+
+	return sb.String()
+}
+
+// statsBoxWidth 统计信息边框内的显示宽度
+const statsBoxWidth = 42
 
-	return result
+// padDisplay 按终端显示宽度（中文和 emoji 占两列）在右侧补空格
+func padDisplay(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// displayWidth 估算字符串在终端中的显示宽度
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch {
+		case r == 0xFE0F || r == 0x200D: // emoji 变体选择符和连接符不占宽度
+		case r >= 0x1100 && (r <= 0x115F || (r >= 0x2E80 && r <= 0xA4CF) || (r >= 0xAC00 && r <= 0xD7A3) ||
+			(r >= 0xF900 && r <= 0xFAFF) || (r >= 0xFE30 && r <= 0xFE4F) || (r >= 0xFF00 && r <= 0xFF60) ||
+			(r >= 0xFFE0 && r <= 0xFFE6) || (r >= 0x1F300 && r <= 0x1FAFF)):
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
 }
 
 // FormatStatsSimple 格式化简洁统计信息
 func (sm *StatsManager) FormatStatsSimple() string {
 	stats := sm.GetStats()
 
-	return fmt.Sprintf("今日: 修复%d/跳过%d/失败%d | 累计: 修复%d/跳过%d",
+	return T("stats.simple",
 		stats.TodayResets, stats.TodaySkips, stats.TodayFailures,
 		stats.TotalResets, stats.TotalSkips)
 }
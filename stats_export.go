@@ -0,0 +1,376 @@
+// Package main provides statistics export and multi-machine aggregation.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// ExportFormat 统计导出格式
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportCSV  ExportFormat = "csv"
+)
+
+// csvHeader CSV 导出的列（每行都带机器元数据，便于直接拼接多台机器的文件）
+var csvHeader = []string{
+	"hostname", "manufacturer", "model", "bios_version", "tool_version",
+	"timestamp", "type", "device_status", "message", "success", "episode",
+}
+
+// MachineInfo 机器元数据
+type MachineInfo struct {
+	Hostname     string `json:"hostname"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model"`
+	BIOSVersion  string `json:"bios_version"`
+	ToolVersion  string `json:"tool_version"`
+}
+
+// StatsExport 统计导出文件（JSON 格式）
+type StatsExport struct {
+	Machine    MachineInfo   `json:"machine"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Summary    Stats         `json:"summary"`
+	Events     []EventRecord `json:"events"`
+}
+
+// GetMachineInfo 获取本机元数据（主机名、DMI 型号、BIOS 版本、工具版本）
+func GetMachineInfo() MachineInfo {
+	info := MachineInfo{
+		ToolVersion: GetVersionInfo().Version,
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		info.Hostname = hostname
+	}
+
+	// DMI 信息由系统固件写入注册表
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`HARDWARE\DESCRIPTION\System\BIOS`,
+		registry.QUERY_VALUE)
+	if err == nil {
+		defer key.Close()
+		info.Manufacturer, _, _ = key.GetStringValue("SystemManufacturer")
+		info.Model, _, _ = key.GetStringValue("SystemProductName")
+		info.BIOSVersion, _, _ = key.GetStringValue("BIOSVersion")
+	}
+
+	return info
+}
+
+// NewStatsExport 根据统计数据创建导出内容
+func NewStatsExport(machine MachineInfo, stats Stats) *StatsExport {
+	events := stats.History
+	if events == nil {
+		events = []EventRecord{}
+	}
+	stats.History = nil
+
+	return &StatsExport{
+		Machine:    machine,
+		ExportedAt: time.Now(),
+		Summary:    stats,
+		Events:     events,
+	}
+}
+
+// ParseExportFormat 解析导出格式
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch ExportFormat(strings.ToLower(strings.TrimSpace(s))) {
+	case ExportJSON:
+		return ExportJSON, nil
+	case ExportCSV:
+		return ExportCSV, nil
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %q（可选 csv、json）", s)
+	}
+}
+
+// Write 按指定格式写出导出内容
+func (e *StatsExport) Write(w io.Writer, format ExportFormat) error {
+	switch format {
+	case ExportJSON:
+		data, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化统计失败: %w", err)
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, ev := range e.Events {
+			if err := cw.Write(csvRow(e.Machine, ev)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("不支持的导出格式: %q", format)
+	}
+}
+
+// csvRow 将一条事件转换为 CSV 行
+func csvRow(m MachineInfo, ev EventRecord) []string {
+	return []string{
+		m.Hostname, m.Manufacturer, m.Model, m.BIOSVersion, m.ToolVersion,
+		ev.Timestamp.Format(time.RFC3339Nano), string(ev.Type), ev.DeviceStatus,
+		ev.Message, strconv.FormatBool(ev.Success), ev.Episode,
+	}
+}
+
+// FleetEvent 带机器元数据的事件（合并多台机器导出时使用）
+type FleetEvent struct {
+	Machine MachineInfo
+	EventRecord
+}
+
+// LoadStatsExportFile 读取导出文件（按扩展名识别 CSV，其余按 JSON 解析）
+func LoadStatsExportFile(path string) ([]FleetEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开导出文件失败: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		events, err := readCSVExport(f)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+		}
+		return events, nil
+	}
+
+	var export StatsExport
+	if err := json.NewDecoder(f).Decode(&export); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+
+	events := make([]FleetEvent, 0, len(export.Events))
+	for _, ev := range export.Events {
+		events = append(events, FleetEvent{Machine: export.Machine, EventRecord: ev})
+	}
+	return events, nil
+}
+
+// readCSVExport 解析 CSV 导出内容
+func readCSVExport(r io.Reader) ([]FleetEvent, error) {
+	cr := csv.NewReader(r)
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("文件为空")
+	}
+
+	// 按表头定位列，允许列顺序不同
+	col := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		col[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"hostname", "model", "timestamp", "type"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("缺少列: %s", name)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	events := make([]FleetEvent, 0, len(records)-1)
+	for lineNo, row := range records[1:] {
+		// 保留纳秒，与 JSON 导出的时间一致，合并时才能按时间去重
+		ts, err := time.Parse(time.RFC3339Nano, field(row, "timestamp"))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行时间格式错误: %w", lineNo+2, err)
+		}
+		success, _ := strconv.ParseBool(field(row, "success"))
+		events = append(events, FleetEvent{
+			Machine: MachineInfo{
+				Hostname:     field(row, "hostname"),
+				Manufacturer: field(row, "manufacturer"),
+				Model:        field(row, "model"),
+				BIOSVersion:  field(row, "bios_version"),
+				ToolVersion:  field(row, "tool_version"),
+			},
+			EventRecord: EventRecord{
+				Timestamp:    ts,
+				Type:         EventType(field(row, "type")),
+				DeviceStatus: field(row, "device_status"),
+				Message:      field(row, "message"),
+				Success:      success,
+				Episode:      field(row, "episode"),
+			},
+		})
+	}
+	return events, nil
+}
+
+// FleetGroupStats 一组机器（同型号或同 BIOS 版本）的汇总
+type FleetGroupStats struct {
+	Model       string
+	BIOSVersion string
+	Machines    int
+	Resumes     int
+	Resets      int
+	Failures    int
+	Skips       int
+}
+
+// IssueRate 唤醒后触屏异常的比例（需要修复的次数 / 唤醒次数）
+func (g *FleetGroupStats) IssueRate() float64 {
+	if g.Resumes == 0 {
+		return 0
+	}
+	return float64(g.Resets+g.Failures) / float64(g.Resumes)
+}
+
+// FailureRate 修复失败率（失败次数 / 修复尝试次数）
+func (g *FleetGroupStats) FailureRate() float64 {
+	attempts := g.Resets + g.Failures
+	if attempts == 0 {
+		return 0
+	}
+	return float64(g.Failures) / float64(attempts)
+}
+
+// FleetReport 多台机器的汇总报告
+type FleetReport struct {
+	Machines int
+	Events   int
+	ByModel  []*FleetGroupStats
+	ByBIOS   []*FleetGroupStats
+}
+
+// MergeFleetEvents 合并多台机器的事件并按型号、BIOS 版本汇总
+// 同一台机器重复导出的相同事件只计一次
+func MergeFleetEvents(events []FleetEvent) *FleetReport {
+	type eventKey struct {
+		hostname  string
+		timestamp int64
+		eventType EventType
+	}
+	seen := make(map[eventKey]bool, len(events))
+
+	byModel := make(map[string]*FleetGroupStats)
+	byBIOS := make(map[string]*FleetGroupStats)
+	modelHosts := make(map[string]map[string]bool)
+	biosHosts := make(map[string]map[string]bool)
+	hosts := make(map[string]bool)
+	report := &FleetReport{}
+
+	for _, ev := range events {
+		key := eventKey{ev.Machine.Hostname, ev.Timestamp.UnixNano(), ev.Type}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		report.Events++
+
+		model := ev.Machine.Model
+		if model == "" {
+			model = "未知型号"
+		}
+		bios := ev.Machine.BIOSVersion
+		if bios == "" {
+			bios = "未知版本"
+		}
+		biosKey := model + "\x00" + bios
+
+		if byModel[model] == nil {
+			byModel[model] = &FleetGroupStats{Model: model}
+			modelHosts[model] = make(map[string]bool)
+		}
+		if byBIOS[biosKey] == nil {
+			byBIOS[biosKey] = &FleetGroupStats{Model: model, BIOSVersion: bios}
+			biosHosts[biosKey] = make(map[string]bool)
+		}
+		hosts[ev.Machine.Hostname] = true
+		modelHosts[model][ev.Machine.Hostname] = true
+		biosHosts[biosKey][ev.Machine.Hostname] = true
+
+		for _, g := range []*FleetGroupStats{byModel[model], byBIOS[biosKey]} {
+			switch ev.Type {
+			case EventResume:
+				g.Resumes++
+			case EventSuccess:
+				g.Resets++
+			case EventFail:
+				g.Failures++
+			case EventSkip:
+				g.Skips++
+			}
+		}
+	}
+
+	report.Machines = len(hosts)
+	for model, g := range byModel {
+		g.Machines = len(modelHosts[model])
+		report.ByModel = append(report.ByModel, g)
+	}
+	for key, g := range byBIOS {
+		g.Machines = len(biosHosts[key])
+		report.ByBIOS = append(report.ByBIOS, g)
+	}
+
+	sort.Slice(report.ByModel, func(i, j int) bool {
+		return report.ByModel[i].Model < report.ByModel[j].Model
+	})
+	sort.Slice(report.ByBIOS, func(i, j int) bool {
+		a, b := report.ByBIOS[i], report.ByBIOS[j]
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.BIOSVersion < b.BIOSVersion
+	})
+
+	return report
+}
+
+// Format 格式化汇总报告为人类可读格式
+func (r *FleetReport) Format() string {
+	var sb strings.Builder
+
+	sb.WriteString(T("fleet.title") + "\n\n")
+	sb.WriteString(T("fleet.totals", r.Machines, r.Events) + "\n")
+
+	writeGroup := func(title string, groups []*FleetGroupStats, withBIOS bool) {
+		sb.WriteString(fmt.Sprintf("\n%s\n", title))
+		for _, g := range groups {
+			name := g.Model
+			if withBIOS {
+				name = fmt.Sprintf("%s / BIOS %s", g.Model, g.BIOSVersion)
+			}
+			sb.WriteString(fmt.Sprintf("  %s\n", name))
+			sb.WriteString(T("fleet.counts", g.Machines, g.Resumes, g.Resets, g.Failures, g.Skips) + "\n")
+			sb.WriteString(T("fleet.rates", g.IssueRate()*100, g.FailureRate()*100) + "\n")
+		}
+	}
+
+	writeGroup(T("fleet.by_model"), r.ByModel, false)
+	writeGroup(T("fleet.by_bios"), r.ByBIOS, true)
+
+	sb.WriteString("\n" + T("fleet.note") + "\n")
+	sb.WriteString(T("fleet.footer") + "\n")
+	return sb.String()
+}
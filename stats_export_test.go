@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMachine(hostname, model, bios string) MachineInfo {
+	return MachineInfo{
+		Hostname:     hostname,
+		Manufacturer: "GPD",
+		Model:        model,
+		BIOSVersion:  bios,
+		ToolVersion:  "1.0.1",
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ExportFormat
+		wantErr bool
+	}{
+		{"json", ExportJSON, false},
+		{"CSV", ExportCSV, false},
+		{" csv ", ExportCSV, false},
+		{"xml", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseExportFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExportFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseExportFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsExport_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStatsManager(tmpDir)
+	ctx := WithEpisode(context.Background(), "20251224-083015-a1b2")
+	sm.RecordResume(ctx)
+	sm.RecordReset(ctx, true, "修复成功")
+	sm.RecordResume(context.Background())
+	sm.RecordSkip(context.Background())
+
+	export := NewStatsExport(testMachine("gpd-01", "G1619-04", "2.10"), sm.GetStats())
+
+	if len(export.Events) != 4 {
+		t.Fatalf("Events = %d, want 4", len(export.Events))
+	}
+	if export.Summary.History != nil {
+		t.Error("Summary should not duplicate the event history")
+	}
+
+	for _, format := range []ExportFormat{ExportJSON, ExportCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(tmpDir, "export."+string(format))
+			var buf bytes.Buffer
+			if err := export.Write(&buf, format); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := LoadStatsExportFile(path)
+			if err != nil {
+				t.Fatalf("LoadStatsExportFile() error = %v", err)
+			}
+			if len(events) != len(export.Events) {
+				t.Fatalf("loaded %d events, want %d", len(events), len(export.Events))
+			}
+
+			got := events[1]
+			if got.Machine.Hostname != "gpd-01" || got.Machine.Model != "G1619-04" || got.Machine.BIOSVersion != "2.10" {
+				t.Errorf("Machine = %+v, metadata not preserved", got.Machine)
+			}
+			if got.Type != EventSuccess || !got.Success || got.Message != "修复成功" {
+				t.Errorf("Event = %+v, want successful reset", got.EventRecord)
+			}
+			if got.Episode != "20251224-083015-a1b2" || events[3].Episode != "" {
+				t.Errorf("Episode = %q / %q, episode IDs not preserved", got.Episode, events[3].Episode)
+			}
+		})
+	}
+}
+
+func TestLoadStatsExportFile_CSVMissingColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(path, []byte("hostname,timestamp\nx,2025-01-01T00:00:00Z\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadStatsExportFile(path); err == nil {
+		t.Error("LoadStatsExportFile() should fail when required columns are missing")
+	}
+}
+
+func TestMergeFleetEvents(t *testing.T) {
+	base := time.Date(2025, 12, 1, 8, 0, 0, 0, time.UTC)
+	event := func(m MachineInfo, offset int, typ EventType) FleetEvent {
+		return FleetEvent{
+			Machine:     m,
+			EventRecord: EventRecord{Timestamp: base.Add(time.Duration(offset) * time.Minute), Type: typ},
+		}
+	}
+
+	a := testMachine("gpd-a", "G1619-04", "2.10")
+	b := testMachine("gpd-b", "G1619-04", "2.12")
+	c := testMachine("gpd-c", "G1618-04", "1.05")
+
+	events := []FleetEvent{
+		event(a, 0, EventResume), event(a, 1, EventSuccess),
+		event(a, 10, EventResume), event(a, 11, EventFail),
+		event(b, 0, EventResume), event(b, 1, EventSkip),
+		event(c, 0, EventResume), event(c, 1, EventSuccess),
+	}
+	// 同一台机器的重复导出不应重复计数
+	events = append(events, event(a, 0, EventResume), event(a, 1, EventSuccess))
+
+	report := MergeFleetEvents(events)
+
+	if report.Machines != 3 {
+		t.Errorf("Machines = %d, want 3", report.Machines)
+	}
+	if report.Events != 8 {
+		t.Errorf("Events = %d, want 8 (duplicates removed)", report.Events)
+	}
+	if len(report.ByModel) != 2 {
+		t.Fatalf("ByModel = %d groups, want 2", len(report.ByModel))
+	}
+	if len(report.ByBIOS) != 3 {
+		t.Fatalf("ByBIOS = %d groups, want 3", len(report.ByBIOS))
+	}
+
+	win := report.ByModel[1]
+	if win.Model != "G1619-04" {
+		t.Fatalf("ByModel[1].Model = %q, want G1619-04", win.Model)
+	}
+	if win.Machines != 2 || win.Resumes != 3 || win.Resets != 1 || win.Failures != 1 || win.Skips != 1 {
+		t.Errorf("G1619-04 stats = %+v", win)
+	}
+	if got := win.FailureRate(); got != 0.5 {
+		t.Errorf("FailureRate() = %v, want 0.5", got)
+	}
+	if got, want := win.IssueRate(), 2.0/3.0; got != want {
+		t.Errorf("IssueRate() = %v, want %v", got, want)
+	}
+
+	formatted := report.Format()
+	for _, want := range []string{"按型号", "按 BIOS 版本", "G1619-04 / BIOS 2.12"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Format() should contain %q", want)
+		}
+	}
+}
+
+func TestFleetReport_FormatEnglish(t *testing.T) {
+	withLocale(t, LocaleEnUS)
+	m := testMachine("gpd-a", "G1619-04", "2.10")
+	base := time.Date(2025, 12, 1, 8, 0, 0, 0, time.UTC)
+	report := MergeFleetEvents([]FleetEvent{
+		{Machine: m, EventRecord: EventRecord{Timestamp: base, Type: EventResume}},
+		{Machine: m, EventRecord: EventRecord{Timestamp: base.Add(time.Minute), Type: EventSuccess}},
+	})
+
+	formatted := report.Format()
+	for _, want := range []string{
+		"FLEET REPORT", "Machines: 1  Events: 2", "By model:", "By BIOS version:", "G1619-04 / BIOS 2.10",
+		"Wakes: 1", "Issue rate: 100.0%", "Repair failure rate:   0.0%",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Format() should contain %q:\n%s", want, formatted)
+		}
+	}
+	if hasHan(formatted) {
+		t.Errorf("en-US 报告中不应有中文:\n%s", formatted)
+	}
+}
+
+func TestMergeFleetEvents_CSVAndJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStatsManager(tmpDir)
+	sm.RecordResume(context.Background())
+	sm.RecordReset(context.Background(), false, "设备状态: Error")
+	export := NewStatsExport(testMachine("gpd-01", "G1619-04", "2.10"), sm.GetStats())
+
+	// 同一台机器分别导出 CSV 和 JSON 后一起合并，不应重复计数
+	var events []FleetEvent
+	for _, format := range []ExportFormat{ExportCSV, ExportJSON} {
+		var buf bytes.Buffer
+		if err := export.Write(&buf, format); err != nil {
+			t.Fatalf("Write(%s) error = %v", format, err)
+		}
+		path := filepath.Join(tmpDir, "export."+string(format))
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadStatsExportFile(path)
+		if err != nil {
+			t.Fatalf("LoadStatsExportFile(%s) error = %v", format, err)
+		}
+		events = append(events, loaded...)
+	}
+
+	report := MergeFleetEvents(events)
+	if report.Machines != 1 || report.Events != len(export.Events) {
+		t.Errorf("Machines = %d, Events = %d, want 1 台 %d 条", report.Machines, report.Events, len(export.Events))
+	}
+	if !events[0].Timestamp.Equal(export.Events[0].Timestamp) {
+		t.Errorf("CSV 时间 = %s, want %s（应保留纳秒）", events[0].Timestamp, export.Events[0].Timestamp)
+	}
+}
+
+func TestFleetGroupStats_ZeroDivision(t *testing.T) {
+	g := &FleetGroupStats{}
+	if g.IssueRate() != 0 || g.FailureRate() != 0 {
+		t.Error("rates should be 0 for empty groups")
+	}
+}
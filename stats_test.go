@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewStatsManager(t *testing.T) {
@@ -30,7 +36,7 @@ func TestStatsManager_RecordResume(t *testing.T) {
 
 	initialCount := sm.stats.TotalResumeEvents
 
-	sm.RecordResume()
+	sm.RecordResume(context.Background())
 
 	if sm.stats.TotalResumeEvents != initialCount+1 {
 		t.Errorf("TotalResumeEvents = %d, want %d", sm.stats.TotalResumeEvents, initialCount+1)
@@ -59,7 +65,7 @@ func TestStatsManager_RecordReset(t *testing.T) {
 			initialResets := sm.stats.TotalResets
 			initialFailures := sm.stats.TotalFailures
 
-			sm.RecordReset(tt.success, tt.result)
+			sm.RecordReset(context.Background(), tt.success, tt.result)
 
 			if tt.success {
 				if sm.stats.TotalResets != initialResets+1 {
@@ -84,7 +90,7 @@ func TestStatsManager_RecordSkip(t *testing.T) {
 
 	initialSkips := sm.stats.TotalSkips
 
-	sm.RecordSkip()
+	sm.RecordSkip(context.Background())
 
 	if sm.stats.TotalSkips != initialSkips+1 {
 		t.Errorf("TotalSkips = %d, want %d", sm.stats.TotalSkips, initialSkips+1)
@@ -99,9 +105,9 @@ func TestStatsManager_GetStats(t *testing.T) {
 	tmpDir := t.TempDir()
 	sm := NewStatsManager(tmpDir)
 
-	sm.RecordResume()
-	sm.RecordReset(true, "成功")
-	sm.RecordSkip()
+	sm.RecordResume(context.Background())
+	sm.RecordReset(context.Background(), true, "成功")
+	sm.RecordSkip(context.Background())
 
 	stats := sm.GetStats()
 
@@ -122,8 +128,8 @@ func TestStatsManager_FormatStats(t *testing.T) {
 	tmpDir := t.TempDir()
 	sm := NewStatsManager(tmpDir)
 
-	sm.RecordResume()
-	sm.RecordReset(true, "成功")
+	sm.RecordResume(context.Background())
+	sm.RecordReset(context.Background(), true, "成功")
 
 	formatted := sm.FormatStats()
 
@@ -166,9 +172,9 @@ func TestStatsManager_SaveAndLoad(t *testing.T) {
 	sm := NewStatsManager(tmpDir)
 
 	// 记录一些事件
-	sm.RecordResume()
-	sm.RecordReset(true, "测试")
-	sm.RecordSkip()
+	sm.RecordResume(context.Background())
+	sm.RecordReset(context.Background(), true, "测试")
+	sm.RecordSkip(context.Background())
 
 	originalResumeCount := sm.stats.TotalResumeEvents
 	originalResetCount := sm.stats.TotalResets
@@ -194,10 +200,190 @@ func TestStatsManager_StatsFileCreated(t *testing.T) {
 	tmpDir := t.TempDir()
 	sm := NewStatsManager(tmpDir)
 
-	sm.RecordResume()
+	sm.RecordResume(context.Background())
 
 	statsFile := filepath.Join(tmpDir, "stats.json")
 	if _, err := os.Stat(statsFile); os.IsNotExist(err) {
 		t.Error("stats.json file should be created after RecordResume()")
 	}
 }
+
+func TestStatsManager_History(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStatsManager(tmpDir)
+
+	sm.RecordResume(context.Background())
+	sm.RecordReset(context.Background(), false, "失败: 超时")
+	sm.RecordSkip(context.Background())
+
+	history := sm.GetHistory()
+	if len(history) != 3 {
+		t.Fatalf("len(history) = %d, want 3", len(history))
+	}
+
+	wantTypes := []EventType{EventResume, EventFail, EventSkip}
+	for i, want := range wantTypes {
+		if history[i].Type != want {
+			t.Errorf("history[%d].Type = %s, want %s", i, history[i].Type, want)
+		}
+	}
+
+	// 历史应随统计一起持久化
+	sm2 := NewStatsManager(tmpDir)
+	if got := len(sm2.GetHistory()); got != 3 {
+		t.Errorf("loaded history = %d records, want 3", got)
+	}
+}
+
+func TestStatsManager_HistoryCapped(t *testing.T) {
+	sm := NewStatsManager(t.TempDir())
+
+	err := sm.update(func() {
+		for i := 0; i < maxHistoryRecords+10; i++ {
+			sm.appendHistory(EventRecord{Type: EventResume, Message: "test"})
+		}
+	})
+	if err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+
+	if got := len(sm.GetHistory()); got != maxHistoryRecords {
+		t.Errorf("len(history) = %d, want %d", got, maxHistoryRecords)
+	}
+}
+
+// statsHelperEnv 子进程模式标记，见 TestStatsManager_HelperProcess
+const statsHelperEnv = "GPD_TOUCH_STATS_HELPER_DIR"
+
+// TestStatsManager_HelperProcess 不是真正的测试，由并发进程测试以子进程方式调用
+func TestStatsManager_HelperProcess(t *testing.T) {
+	dir := os.Getenv(statsHelperEnv)
+	if dir == "" {
+		return
+	}
+
+	count, _ := strconv.Atoi(os.Getenv("GPD_TOUCH_STATS_HELPER_COUNT"))
+	sm := NewStatsManager(dir)
+	for i := 0; i < count; i++ {
+		sm.RecordResume(context.Background())
+		sm.RecordReset(context.Background(), i%2 == 0, "helper")
+	}
+}
+
+func TestStatsManager_ConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过多进程测试")
+	}
+
+	tmpDir := t.TempDir()
+	const processes = 4
+	const perProcess = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, processes)
+	for i := 0; i < processes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestStatsManager_HelperProcess$")
+			cmd.Env = append(os.Environ(),
+				statsHelperEnv+"="+tmpDir,
+				"GPD_TOUCH_STATS_HELPER_COUNT="+strconv.Itoa(perProcess))
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("helper failed: %v: %s", err, out)
+			}
+		}()
+	}
+
+	// 同时在当前进程中写入，模拟服务与 CLI 同时记录
+	sm := NewStatsManager(tmpDir)
+	for i := 0; i < perProcess; i++ {
+		sm.RecordSkip(context.Background())
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	stats := NewStatsManager(tmpDir).GetStats()
+	if stats.TotalResumeEvents != processes*perProcess {
+		t.Errorf("TotalResumeEvents = %d, want %d", stats.TotalResumeEvents, processes*perProcess)
+	}
+	if got := stats.TotalResets + stats.TotalFailures; got != processes*perProcess {
+		t.Errorf("TotalResets+TotalFailures = %d, want %d", got, processes*perProcess)
+	}
+	if stats.TotalSkips != perProcess {
+		t.Errorf("TotalSkips = %d, want %d", stats.TotalSkips, perProcess)
+	}
+	if got, want := len(stats.History), processes*perProcess*2+perProcess; got != want {
+		t.Errorf("len(History) = %d, want %d", got, want)
+	}
+
+	// 长期存在的实例也应看到其他进程的写入
+	if got := sm.GetStats().TotalResumeEvents; got != processes*perProcess {
+		t.Errorf("stale StatsManager sees %d resume events, want %d", got, processes*perProcess)
+	}
+}
+
+func TestStatsManager_GiveUp(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStatsManager(tmpDir)
+	ctx := WithEpisode(context.Background(), "ep-1")
+
+	if sm.GiveUpState() != nil {
+		t.Fatal("新建的统计不应处于停止状态")
+	}
+	if sm.ClearGiveUp(ctx, "manual") {
+		t.Error("未停止时 ClearGiveUp() 应返回 false")
+	}
+
+	until := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	sm.RecordGiveUp(ctx, 10, until)
+
+	// 另一个进程（如 -status、-rearm）读取到相同的状态
+	other := NewStatsManager(tmpDir)
+	state := other.GiveUpState()
+	if state == nil {
+		t.Fatal("GiveUpState() = nil, want 停止状态")
+	}
+	if state.Attempts != 10 || !state.Until.Equal(until) || state.Episode != "ep-1" {
+		t.Errorf("GiveUpState() = %+v", state)
+	}
+	if got := other.GetStats().TotalGiveUps; got != 1 {
+		t.Errorf("TotalGiveUps = %d, want 1", got)
+	}
+
+	if !other.ClearGiveUp(context.Background(), "manual") {
+		t.Fatal("ClearGiveUp() = false, want true")
+	}
+	if sm.GiveUpState() != nil {
+		t.Error("-rearm 清除后服务进程应读取到未停止状态")
+	}
+
+	history := sm.GetHistory()
+	if len(history) != 2 || history[0].Type != EventGiveUp || history[1].Type != EventRearm {
+		t.Errorf("history = %+v, want GIVE_UP, REARM", history)
+	}
+}
+
+func TestStatsManager_PollCost(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStatsManager(tmpDir)
+	if sm.PollCost() != nil {
+		t.Fatal("新建的统计不应有轮询开销")
+	}
+
+	start := time.Date(2025, 12, 25, 7, 0, 0, 0, time.UTC)
+	sm.RecordPollCost(PollCostReport{
+		Start: start, End: start.Add(time.Hour), Checks: 360, Spawns: 362, SpawnsPerHour: 362,
+		ModeTime: map[PollMode]time.Duration{PollNormal: time.Hour},
+	})
+
+	// -status 在另一个进程中读取
+	cost := NewStatsManager(tmpDir).PollCost()
+	if cost == nil || cost.Checks != 360 || cost.Spawns != 362 || cost.Period() != time.Hour || cost.ModeTime[PollNormal] != time.Hour {
+		t.Errorf("PollCost() = %+v", cost)
+	}
+}
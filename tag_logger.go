@@ -0,0 +1,75 @@
+// Package main provides the tagged logging interface injected into device operations.
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// TagLogger 带级别和事件标签的日志接口
+// 服务中由 *Logger 实现（写入日志文件），CLI 中由 ConsoleLogger 实现（输出到控制台）
+type TagLogger interface {
+	LogTag(ctx context.Context, level LogLevel, tag EventTag, format string, args ...interface{})
+}
+
+// LogTag 实现 TagLogger，context 中的事件 ID 写入 episode 字段
+func (l *Logger) LogTag(ctx context.Context, level LogLevel, tag EventTag, format string, args ...interface{}) {
+	l.write(level, tag, LogFields{Episode: EpisodeFromContext(ctx)}, format, args...)
+}
+
+// ConsoleLogger 通过标准库 log 输出到控制台，保持 CLI 原有的输出格式
+type ConsoleLogger struct {
+	MinLevel LogLevel // 低于此级别的日志不输出（零值为 DEBUG，全部输出）
+}
+
+// LogTag 实现 TagLogger
+func (c ConsoleLogger) LogTag(ctx context.Context, level LogLevel, tag EventTag, format string, args ...interface{}) {
+	if level < c.MinLevel {
+		return
+	}
+
+	switch level {
+	case WARNING:
+		format = "警告: " + format
+	case ERROR:
+		format = "错误: " + format
+	}
+	if id := EpisodeFromContext(ctx); id != "" {
+		format = "[" + id + "] " + format
+	}
+	log.Printf(format, args...)
+}
+
+// stdLogWriter 将标准库 log 的输出转发到日志记录器
+type stdLogWriter struct {
+	logger *Logger
+}
+
+// Write 每次调用对应一条 log.Printf 输出
+func (w stdLogWriter) Write(p []byte) (int, error) {
+	w.logger.write(INFO, "", LogFields{}, "%s", strings.TrimRight(string(p), "\r\n"))
+	return len(p), nil
+}
+
+// CaptureStdLog 让标准库 log 的输出也写入日志文件（服务模式使用，避免遗漏未注入日志接口的输出）
+func (l *Logger) CaptureStdLog() {
+	log.SetFlags(0)
+	log.SetOutput(stdLogWriter{logger: l})
+}
+
+// FieldTagLogger 支持结构化字段（设备、状态、耗时、触发来源等）的 TagLogger
+type FieldTagLogger interface {
+	TagLogger
+	LogTagFields(level LogLevel, tag EventTag, fields LogFields, format string, args ...interface{})
+}
+
+// LogTagFields 实现 FieldTagLogger
+func (l *Logger) LogTagFields(level LogLevel, tag EventTag, fields LogFields, format string, args ...interface{}) {
+	l.write(level, tag, fields, format, args...)
+}
+
+// LogTagFields 实现 FieldTagLogger（控制台只显示事件 ID，其他字段省略）
+func (c ConsoleLogger) LogTagFields(level LogLevel, tag EventTag, fields LogFields, format string, args ...interface{}) {
+	c.LogTag(WithEpisode(context.Background(), fields.Episode), level, tag, format, args...)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger_LogTag(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+	l.SetLevel(INFO)
+
+	var tl TagLogger = l
+	ctx := WithEpisode(context.Background(), "ep-1")
+	tl.LogTag(ctx, INFO, TagReset, "正在禁用设备: %s", "ACPI\\GXTP7386")
+	tl.LogTag(ctx, DEBUG, TagReset, "等待 %v...", 2*time.Second)
+	tl.LogTag(context.Background(), WARNING, TagCheck, "无法获取最终状态: %v", "timeout")
+
+	lines, err := readLogLinesFrom(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("lines = %v, want 2 (DEBUG filtered)", lines)
+	}
+
+	first, _ := ParseLogLine(lines[0])
+	if first.Level != "INFO" || first.Tag != TagReset || first.Fields.Episode != "ep-1" {
+		t.Errorf("first entry = %+v", first)
+	}
+	second, _ := ParseLogLine(lines[1])
+	if second.Level != "WARN" || second.Tag != TagCheck || second.Fields.Episode != "" {
+		t.Errorf("second entry = %+v", second)
+	}
+}
+
+func TestConsoleLogger(t *testing.T) {
+	var buf bytes.Buffer
+	out, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+
+	c := ConsoleLogger{MinLevel: INFO}
+	ctx := WithEpisode(context.Background(), "ep-1")
+	c.LogTag(ctx, INFO, TagReset, "设备已禁用")
+	c.LogTag(context.Background(), WARNING, TagCheck, "无法获取初始状态: %v", "x")
+	c.LogTag(context.Background(), DEBUG, TagReset, "hidden")
+
+	want := "[ep-1] 设备已禁用\n警告: 无法获取初始状态: x\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestLogger_CaptureStdLog(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 12, 24, 8, 30, 0, 0, time.Local)
+	l := newTestLogger(t, dir, &now, LogRotation{})
+
+	out, flags := log.Writer(), log.Flags()
+	l.CaptureStdLog()
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+
+	log.Printf("遗留输出 %d", 1)
+
+	lines, err := readLogLinesFrom(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("readLogLinesFrom() error = %v", err)
+	}
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "[INFO ] 遗留输出 1") {
+		t.Errorf("lines = %q", lines)
+	}
+}
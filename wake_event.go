@@ -0,0 +1,354 @@
+// Package main provides typed wake events decoded from Windows power broadcasts and the power monitor that consumes them.
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WakeEventKind 唤醒相关事件类型
+type WakeEventKind string
+
+const (
+	WakeSuspend         WakeEventKind = "suspend"          // 系统挂起（PBT_APMSUSPEND）
+	WakeResumeAutomatic WakeEventKind = "resume_automatic" // 自动恢复（PBT_APMRESUMEAUTOMATIC）
+	WakeResumeSuspend   WakeEventKind = "resume_suspend"   // 用户操作恢复（PBT_APMRESUMESUSPEND）
+	WakeDisplayOn       WakeEventKind = "display_on"       // 显示器开启
+	WakeDisplayOff      WakeEventKind = "display_off"      // 显示器关闭
+	WakeDisplayDimmed   WakeEventKind = "display_dimmed"   // 显示器调暗
+	WakeOEM             WakeEventKind = "oem"              // OEM 事件（PBT_APMOEMEVENT）
+	WakePowerStatus     WakeEventKind = "power_status"     // 电源状态改变（PBT_APMPOWERSTATUSCHANGE）
+	WakeLidOpen         WakeEventKind = "lid_open"         // 盖子打开
+	WakeLidClose        WakeEventKind = "lid_close"        // 盖子合上
+	WakePowerAC         WakeEventKind = "power_ac"         // 切换到交流电源
+	WakePowerDC         WakeEventKind = "power_dc"         // 切换到电池
+	WakeSessionLock     WakeEventKind = "session_lock"     // 会话锁定
+	WakeSessionUnlock   WakeEventKind = "session_unlock"   // 会话解锁
+	WakeUnknown         WakeEventKind = "unknown"          // 未识别的事件
+)
+
+// Windows 电源广播事件类型（WM_POWERBROADCAST 的 wParam / SERVICE_CONTROL_POWEREVENT 的 dwEventType）
+// https://learn.microsoft.com/windows/win32/power/power-management-events
+const (
+	PBTAPMSuspend           uint32 = 0x0004
+	PBTAPMResumeSuspend     uint32 = 0x0007
+	PBTAPMPowerStatusChange uint32 = 0x000A
+	PBTAPMOEMEvent          uint32 = 0x000B
+	PBTAPMResumeAutomatic   uint32 = 0x0012
+	PBTPowerSettingChange   uint32 = 0x8013
+)
+
+// 电源设置 GUID（PBT_POWERSETTINGCHANGE 时 POWERBROADCAST_SETTING.PowerSetting 的字符串形式）
+const (
+	powerSettingDisplayState   = "6fe69556-704a-47a0-8f24-c28d936fda47" // GUID_CONSOLE_DISPLAY_STATE
+	powerSettingMonitorPowerOn = "02731015-4510-4526-99e6-e5a17ebd1aea" // GUID_MONITOR_POWER_ON
+	powerSettingLidSwitch      = "ba3e0f4d-b817-4094-a2d1-d56379e6a0f3" // GUID_LIDSWITCH_STATE_CHANGE
+	powerSettingACDCSource     = "5d3e9a59-e9d5-4b00-a6bd-ff34ff516548" // GUID_ACDC_POWER_SOURCE
+)
+
+// 会话变化事件类型（SERVICE_CONTROL_SESSIONCHANGE 的 dwEventType）
+const (
+	WTSSessionLock   uint32 = 0x7
+	WTSSessionUnlock uint32 = 0x8
+)
+
+// powerBroadcastSettingHeaderSize POWERBROADCAST_SETTING 中 Data 之前的字节数（GUID 16 字节 + DataLength 4 字节）
+const powerBroadcastSettingHeaderSize = 20
+
+const (
+	// 显示器状态值
+	DisplayStateOff    = 0 // 显示器关闭
+	DisplayStateDimmed = 1 // 显示器调暗
+	DisplayStateOn     = 2 // 显示器开启
+)
+
+// WakeEvent 一条解码后的电源事件，也是唤醒事件轨迹文件中的一行
+type WakeEvent struct {
+	Time      time.Time     `json:"time"`
+	Kind      WakeEventKind `json:"kind"`
+	EventType uint32        `json:"event_type"`        // 原始 PBT_* 事件类型
+	Setting   string        `json:"setting,omitempty"` // PBT_POWERSETTINGCHANGE 的电源设置 GUID（小写，无括号）
+	Value     uint32        `json:"value,omitempty"`   // 电源设置的值（如显示器状态）
+}
+
+// IsResume 是否是系统恢复事件
+func (e WakeEvent) IsResume() bool {
+	return e.Kind == WakeResumeAutomatic || e.Kind == WakeResumeSuspend
+}
+
+// String 用于日志的事件描述
+func (e WakeEvent) String() string {
+	if e.Setting != "" {
+		return fmt.Sprintf("%s (类型: 0x%X, 设置: %s, 值: %d)", e.Kind, e.EventType, e.Setting, e.Value)
+	}
+	return fmt.Sprintf("%s (类型: %d/0x%X)", e.Kind, e.EventType, e.EventType)
+}
+
+// DecodePowerEvent 将原始电源事件解码为 WakeEvent
+// setting 和 value 只在 PBT_POWERSETTINGCHANGE 时有意义
+func DecodePowerEvent(t time.Time, eventType uint32, setting string, value uint32) WakeEvent {
+	e := WakeEvent{Time: t, Kind: WakeUnknown, EventType: eventType}
+	switch eventType {
+	case PBTAPMSuspend:
+		e.Kind = WakeSuspend
+	case PBTAPMResumeSuspend:
+		e.Kind = WakeResumeSuspend
+	case PBTAPMResumeAutomatic:
+		e.Kind = WakeResumeAutomatic
+	case PBTAPMOEMEvent:
+		e.Kind = WakeOEM
+	case PBTAPMPowerStatusChange:
+		e.Kind = WakePowerStatus
+	case PBTPowerSettingChange:
+		e.Setting = strings.ToLower(strings.Trim(setting, "{}"))
+		e.Value = value
+		e.Kind = decodePowerSetting(e.Setting, value)
+	}
+	return e
+}
+
+// decodePowerSetting 根据电源设置 GUID 和值确定事件类型
+func decodePowerSetting(setting string, value uint32) WakeEventKind {
+	switch setting {
+	case powerSettingMonitorPowerOn:
+		// GUID_MONITOR_POWER_ON: 0 关闭，1 开启
+		if value == 0 {
+			return WakeDisplayOff
+		}
+		return WakeDisplayOn
+	case powerSettingDisplayState:
+		switch value {
+		case DisplayStateOff:
+			return WakeDisplayOff
+		case DisplayStateDimmed:
+			return WakeDisplayDimmed
+		case DisplayStateOn:
+			return WakeDisplayOn
+		}
+	case powerSettingLidSwitch:
+		// 0 合上，1 打开
+		switch value {
+		case 0:
+			return WakeLidClose
+		case 1:
+			return WakeLidOpen
+		}
+	case powerSettingACDCSource:
+		// 0 交流电源，1 电池，2 短时电源（如 UPS）按电池处理
+		switch value {
+		case 0:
+			return WakePowerAC
+		case 1, 2:
+			return WakePowerDC
+		}
+	}
+	return WakeUnknown
+}
+
+// ParsePowerBroadcastSetting 解析 POWERBROADCAST_SETTING 缓冲区，返回电源设置 GUID（小写，无括号）和数据
+func ParsePowerBroadcastSetting(buf []byte) (string, []byte, error) {
+	if len(buf) < powerBroadcastSettingHeaderSize {
+		return "", nil, fmt.Errorf("POWERBROADCAST_SETTING 长度不足: %d 字节", len(buf))
+	}
+	le := binary.LittleEndian
+	setting := fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		le.Uint32(buf[0:4]), le.Uint16(buf[4:6]), le.Uint16(buf[6:8]), buf[8:10], buf[10:16])
+
+	n := le.Uint32(buf[16:20])
+	if uint64(n) > uint64(len(buf)-powerBroadcastSettingHeaderSize) {
+		return "", nil, fmt.Errorf("POWERBROADCAST_SETTING 数据长度 %d 超出缓冲区 (%d 字节)", n, len(buf))
+	}
+	return setting, buf[powerBroadcastSettingHeaderSize : powerBroadcastSettingHeaderSize+int(n)], nil
+}
+
+// DecodePowerSettingBuffer 解码 PBT_POWERSETTINGCHANGE 事件携带的 POWERBROADCAST_SETTING
+// 缓冲区无效时返回 unknown 事件
+func DecodePowerSettingBuffer(t time.Time, buf []byte) WakeEvent {
+	setting, data, err := ParsePowerBroadcastSetting(buf)
+	if err != nil {
+		return WakeEvent{Time: t, Kind: WakeUnknown, EventType: PBTPowerSettingChange}
+	}
+	var value uint32
+	if len(data) >= 4 {
+		value = binary.LittleEndian.Uint32(data)
+	} else if len(data) > 0 {
+		value = uint32(data[0])
+	}
+	return DecodePowerEvent(t, PBTPowerSettingChange, setting, value)
+}
+
+// DecodeSessionChange 解码会话变化事件，只关心锁定和解锁（其他会话事件返回 false）
+func DecodeSessionChange(t time.Time, eventType uint32) (WakeEvent, bool) {
+	switch eventType {
+	case WTSSessionLock:
+		return WakeEvent{Time: t, Kind: WakeSessionLock, EventType: eventType}, true
+	case WTSSessionUnlock:
+		return WakeEvent{Time: t, Kind: WakeSessionUnlock, EventType: eventType}, true
+	}
+	return WakeEvent{}, false
+}
+
+// PowerEventName 返回电源事件的可读名称
+func PowerEventName(kind WakeEventKind) string {
+	switch kind {
+	case WakeSuspend:
+		return "系统挂起(盖子关闭/睡眠)"
+	case WakeResumeAutomatic:
+		return "自动恢复(唤醒)"
+	case WakeResumeSuspend:
+		return "从挂起恢复(盖子打开/电源按钮)"
+	case WakeDisplayOn:
+		return "显示器开启"
+	case WakeDisplayOff:
+		return "显示器关闭"
+	case WakeDisplayDimmed:
+		return "显示器调暗"
+	case WakeOEM:
+		return "OEM事件"
+	case WakePowerStatus:
+		return "电源状态改变"
+	case WakeLidOpen:
+		return "盖子打开"
+	case WakeLidClose:
+		return "盖子合上"
+	case WakePowerAC:
+		return "切换到交流电源"
+	case WakePowerDC:
+		return "切换到电池"
+	case WakeSessionLock:
+		return "会话锁定"
+	case WakeSessionUnlock:
+		return "会话解锁"
+	default:
+		return "未知事件"
+	}
+}
+
+// WakeEventSource 唤醒事件流（Windows 服务的电源事件处理、轨迹回放都是事件来源）
+type WakeEventSource interface {
+	// Events 返回事件通道，来源结束时关闭
+	Events() <-chan WakeEvent
+}
+
+// WakeEventStream 由调用方推送事件的事件流
+type WakeEventStream struct {
+	ch      chan WakeEvent
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+// NewWakeEventStream 创建事件流，buffer 为缓冲的事件数
+func NewWakeEventStream(buffer int) *WakeEventStream {
+	return &WakeEventStream{ch: make(chan WakeEvent, buffer)}
+}
+
+// Events 返回事件通道
+func (s *WakeEventStream) Events() <-chan WakeEvent {
+	return s.ch
+}
+
+// Publish 推送事件；缓冲区已满或已关闭时丢弃并返回 false（不阻塞服务控制处理）
+func (s *WakeEventStream) Publish(e WakeEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.ch <- e:
+		return true
+	default:
+		s.dropped++
+		return false
+	}
+}
+
+// Dropped 因缓冲区已满丢弃的事件数
+func (s *WakeEventStream) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close 关闭事件流
+func (s *WakeEventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// PowerMonitor 根据电源事件驱动睡眠/唤醒状态机，并判断系统是否从 Modern Standby 唤醒
+type PowerMonitor struct {
+	callback       func(WakeEvent)   // 唤醒时的回调函数（参数为触发唤醒的事件）
+	state          *WakeStateMachine // 睡眠/唤醒状态（与轮询器共用）
+	lastResumeTime time.Time         // 上次唤醒时间
+	mu             sync.Mutex        // 保护并发访问
+	minInterval    time.Duration     // 最小触发间隔，防止重复触发
+	clock          Clock             // 事件没有时间戳时使用
+}
+
+// NewPowerMonitor 创建电源监控器
+func NewPowerMonitor(state *WakeStateMachine, callback func(WakeEvent)) *PowerMonitor {
+	return &PowerMonitor{
+		callback:    callback,
+		state:       state,
+		minInterval: 5 * time.Second,
+		clock:       systemClock{},
+	}
+}
+
+// HandleWakeEvent 处理一条电源事件
+// 返回 true 表示这是一个唤醒事件（已触发回调）
+func (pm *PowerMonitor) HandleWakeEvent(e WakeEvent) bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	name := PowerEventName(e.Kind)
+	switch e.Kind {
+	case WakeSuspend, WakeDisplayOff, WakeLidClose:
+		pm.state.Fire(InputSuspend, name)
+	case WakeDisplayDimmed, WakeSessionLock:
+		pm.state.Fire(InputIdle, name)
+	case WakeSessionUnlock:
+		pm.state.Fire(InputActive, name)
+	case WakeResumeAutomatic, WakeResumeSuspend, WakeLidOpen:
+		pm.state.Fire(InputResume, name)
+		return pm.triggerResume(e)
+	case WakeDisplayOn:
+		// 显示器从关闭变为开启 = 系统唤醒；从调暗恢复只说明用户回来了
+		if pm.state.State() == StateAsleep {
+			pm.state.Fire(InputResume, name)
+			return pm.triggerResume(e)
+		}
+		pm.state.Fire(InputActive, name)
+	}
+	return false
+}
+
+// triggerResume 触发唤醒事件（按事件时间去重，回放轨迹时结果与实际一致）
+func (pm *PowerMonitor) triggerResume(e WakeEvent) bool {
+	at := e.Time
+	if at.IsZero() {
+		at = pm.clock.Now()
+	}
+
+	// 防止短时间内重复触发
+	if !pm.lastResumeTime.IsZero() && at.Sub(pm.lastResumeTime) < pm.minInterval {
+		return false
+	}
+
+	pm.lastResumeTime = at
+
+	if pm.callback != nil {
+		go pm.callback(e)
+	}
+
+	return true
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodePowerEvent(t *testing.T) {
+	now := time.Date(2025, 12, 25, 7, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		eventType uint32
+		setting   string
+		value     uint32
+		want      WakeEventKind
+	}{
+		{"挂起", PBTAPMSuspend, "", 0, WakeSuspend},
+		{"自动恢复", PBTAPMResumeAutomatic, "", 0, WakeResumeAutomatic},
+		{"从挂起恢复", PBTAPMResumeSuspend, "", 0, WakeResumeSuspend},
+		{"OEM 事件", PBTAPMOEMEvent, "", 0, WakeOEM},
+		{"电源状态改变", PBTAPMPowerStatusChange, "", 0, WakePowerStatus},
+		{"显示器关闭", PBTPowerSettingChange, "{6FE69556-704A-47A0-8F24-C28D936FDA47}", 0, WakeDisplayOff},
+		{"显示器调暗", PBTPowerSettingChange, "{6FE69556-704A-47A0-8F24-C28D936FDA47}", 1, WakeDisplayDimmed},
+		{"显示器开启", PBTPowerSettingChange, "{6FE69556-704A-47A0-8F24-C28D936FDA47}", 2, WakeDisplayOn},
+		{"MONITOR_POWER_ON 开启", PBTPowerSettingChange, "02731015-4510-4526-99e6-e5a17ebd1aea", 1, WakeDisplayOn},
+		{"MONITOR_POWER_ON 关闭", PBTPowerSettingChange, "02731015-4510-4526-99e6-e5a17ebd1aea", 0, WakeDisplayOff},
+		{"未知电源设置", PBTPowerSettingChange, "98a7f580-01f7-48aa-9c0f-44352c29e5c0", 1, WakeUnknown},
+		{"未知事件类型", 0x9, "", 0, WakeUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := DecodePowerEvent(now, tt.eventType, tt.setting, tt.value)
+			if e.Kind != tt.want {
+				t.Errorf("Kind = %s, want %s", e.Kind, tt.want)
+			}
+			if e.EventType != tt.eventType || !e.Time.Equal(now) {
+				t.Errorf("事件 = %+v, 缺少原始类型或时间", e)
+			}
+			if want := strings.ToLower(strings.Trim(tt.setting, "{}")); e.Setting != want {
+				t.Errorf("Setting = %q, 应为小写无括号的 GUID", e.Setting)
+			}
+		})
+	}
+}
+
+func TestPowerMonitor_HandleWakeEvent(t *testing.T) {
+	base := time.Date(2025, 12, 25, 7, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	tests := []struct {
+		name   string
+		events []WakeEvent
+		want   []bool
+	}{
+		{
+			name:   "显示器从关闭到开启视为唤醒",
+			events: []WakeEvent{{Time: at(0), Kind: WakeDisplayOff}, {Time: at(60), Kind: WakeDisplayOn}},
+			want:   []bool{false, true},
+		},
+		{
+			name:   "从调暗恢复不算唤醒",
+			events: []WakeEvent{{Time: at(0), Kind: WakeDisplayDimmed}, {Time: at(10), Kind: WakeDisplayOn}},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "5 秒内的重复恢复事件去重",
+			events: []WakeEvent{{Time: at(0), Kind: WakeResumeAutomatic}, {Time: at(1), Kind: WakeResumeSuspend}, {Time: at(6), Kind: WakeResumeSuspend}},
+			want:   []bool{true, false, true},
+		},
+		{
+			name:   "其他事件不触发",
+			events: []WakeEvent{{Time: at(0), Kind: WakeSuspend}, {Time: at(1), Kind: WakeOEM}, {Time: at(2), Kind: WakePowerStatus}},
+			want:   []bool{false, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := NewPowerMonitor(NewWakeStateMachine(newFakeClock()), nil)
+			for i, e := range tt.events {
+				if got := pm.HandleWakeEvent(e); got != tt.want[i] {
+					t.Errorf("事件 %d (%s) = %v, want %v", i, e.Kind, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// powerBroadcastSetting 按 POWERBROADCAST_SETTING 内存布局构造缓冲区（GUID + DataLength + Data）
+func powerBroadcastSetting(guid [16]byte, dataLength uint32, data ...byte) []byte {
+	buf := append([]byte{}, guid[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, dataLength)
+	return append(buf, data...)
+}
+
+func TestDecodePowerSettingBuffer(t *testing.T) {
+	now := time.Date(2025, 12, 25, 7, 45, 0, 0, time.UTC)
+	// GUID 前三段按小端存储
+	lid := [16]byte{0x4d, 0x0f, 0x3e, 0xba, 0x17, 0xb8, 0x94, 0x40, 0xa2, 0xd1, 0xd5, 0x63, 0x79, 0xe6, 0xa0, 0xf3}
+	acdc := [16]byte{0x59, 0x9a, 0x3e, 0x5d, 0xd5, 0xe9, 0x00, 0x4b, 0xa6, 0xbd, 0xff, 0x34, 0xff, 0x51, 0x65, 0x48}
+	display := [16]byte{0x56, 0x95, 0xe6, 0x6f, 0x4a, 0x70, 0xa0, 0x47, 0x8f, 0x24, 0xc2, 0x8d, 0x93, 0x6f, 0xda, 0x47}
+
+	tests := []struct {
+		name        string
+		buf         []byte
+		want        WakeEventKind
+		wantSetting string
+		wantValue   uint32
+	}{
+		{"合盖", powerBroadcastSetting(lid, 4, 0, 0, 0, 0), WakeLidClose, powerSettingLidSwitch, 0},
+		{"开盖", powerBroadcastSetting(lid, 4, 1, 0, 0, 0), WakeLidOpen, powerSettingLidSwitch, 1},
+		{"交流电源", powerBroadcastSetting(acdc, 4, 0, 0, 0, 0), WakePowerAC, powerSettingACDCSource, 0},
+		{"电池", powerBroadcastSetting(acdc, 4, 1, 0, 0, 0), WakePowerDC, powerSettingACDCSource, 1},
+		{"UPS 短时供电", powerBroadcastSetting(acdc, 4, 2, 0, 0, 0), WakePowerDC, powerSettingACDCSource, 2},
+		{"显示器开启", powerBroadcastSetting(display, 4, 2, 0, 0, 0), WakeDisplayOn, "6fe69556-704a-47a0-8f24-c28d936fda47", 2},
+		{"1 字节数据", powerBroadcastSetting(lid, 1, 1), WakeLidOpen, powerSettingLidSwitch, 1},
+		{"缓冲区后有多余字节", powerBroadcastSetting(display, 4, 0, 0, 0, 0, 0xff, 0xff), WakeDisplayOff, "6fe69556-704a-47a0-8f24-c28d936fda47", 0},
+		{"缓冲区长度不足", lid[:], WakeUnknown, "", 0},
+		{"数据长度超出缓冲区", powerBroadcastSetting(lid, 8, 1, 0, 0, 0), WakeUnknown, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := DecodePowerSettingBuffer(now, tt.buf)
+			if e.Kind != tt.want || e.Setting != tt.wantSetting || e.Value != tt.wantValue {
+				t.Errorf("事件 = %+v, want kind=%s setting=%s value=%d", e, tt.want, tt.wantSetting, tt.wantValue)
+			}
+			if e.EventType != PBTPowerSettingChange || !e.Time.Equal(now) {
+				t.Errorf("事件 = %+v, 缺少原始类型或时间", e)
+			}
+		})
+	}
+}
+
+func TestParsePowerBroadcastSetting_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		wantErr string
+	}{
+		{"空缓冲区", nil, "长度不足"},
+		{"只有 GUID", make([]byte, 16), "长度不足"},
+		{"数据长度超出缓冲区", powerBroadcastSetting([16]byte{}, 0xffffffff), "超出缓冲区"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParsePowerBroadcastSetting(tt.buf)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeSessionChange(t *testing.T) {
+	now := time.Date(2025, 12, 25, 7, 45, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		eventType uint32
+		want      WakeEventKind
+		wantOK    bool
+	}{
+		{"锁定", WTSSessionLock, WakeSessionLock, true},
+		{"解锁", WTSSessionUnlock, WakeSessionUnlock, true},
+		{"登录（不关心）", 0x5, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := DecodeSessionChange(now, tt.eventType)
+			if ok != tt.wantOK || e.Kind != tt.want {
+				t.Errorf("DecodeSessionChange = (%+v, %v), want (%s, %v)", e, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWakeEventStream(t *testing.T) {
+	s := NewWakeEventStream(1)
+	if !s.Publish(WakeEvent{Kind: WakeSuspend}) {
+		t.Fatal("第一条事件应写入缓冲区")
+	}
+	if s.Publish(WakeEvent{Kind: WakeResumeAutomatic}) || s.Dropped() != 1 {
+		t.Errorf("缓冲区已满时应丢弃事件, dropped = %d", s.Dropped())
+	}
+	s.Close()
+	s.Close()
+	if s.Publish(WakeEvent{Kind: WakeOEM}) {
+		t.Error("关闭后不应再接受事件")
+	}
+
+	var kinds []WakeEventKind
+	for e := range s.Events() {
+		kinds = append(kinds, e.Kind)
+	}
+	if len(kinds) != 1 || kinds[0] != WakeSuspend {
+		t.Errorf("事件 = %v, want [suspend]", kinds)
+	}
+}
@@ -0,0 +1,254 @@
+// Package main provides the explicit sleep/wake state machine driven by the power monitor and the poller.
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WakeState 睡眠/唤醒状态
+type WakeState string
+
+const (
+	StateAwake     WakeState = "awake"     // 系统活跃，设备正常或尚未发现异常
+	StateDozing    WakeState = "dozing"    // 长时间无输入（可能已合盖）；发现异常时等用户回来再修复
+	StateAsleep    WakeState = "asleep"    // 系统挂起或显示器关闭，不检查、不修复
+	StateResuming  WakeState = "resuming"  // 刚唤醒或用户回来，等待检查设备状态
+	StateRepairing WakeState = "repairing" // 正在修复
+	StateCooldown  WakeState = "cooldown"  // 修复失败，等待退避间隔后重试
+	StateGaveUp    WakeState = "gave_up"   // 连续失败达到上限，停止自动修复
+)
+
+// allWakeStates 所有状态（按转换表的打印顺序）
+var allWakeStates = []WakeState{
+	StateAwake, StateDozing, StateAsleep, StateResuming, StateRepairing, StateCooldown, StateGaveUp,
+}
+
+// WakeInput 驱动状态机的输入
+type WakeInput string
+
+const (
+	InputIdle         WakeInput = "idle"          // 长时间无用户输入
+	InputActive       WakeInput = "active"        // 用户活跃
+	InputSuspend      WakeInput = "suspend"       // 系统挂起或显示器关闭
+	InputResume       WakeInput = "resume"        // 系统恢复或显示器开启
+	InputDeviceOK     WakeInput = "device_ok"     // 检查到设备正常
+	InputDeviceError  WakeInput = "device_error"  // 检查到设备异常
+	InputRepairOK     WakeInput = "repair_ok"     // 修复成功
+	InputRepairFailed WakeInput = "repair_failed" // 修复失败（未达到上限）
+	InputRetryDue     WakeInput = "retry_due"     // 退避间隔已到
+	InputGiveUp       WakeInput = "give_up"       // 连续失败达到上限
+	InputRearm        WakeInput = "rearm"         // 冷却结束或 -rearm 要求恢复
+)
+
+// allWakeInputs 所有输入（按转换表的打印顺序）
+var allWakeInputs = []WakeInput{
+	InputIdle, InputActive, InputSuspend, InputResume, InputDeviceOK, InputDeviceError,
+	InputRepairOK, InputRepairFailed, InputRetryDue, InputGiveUp, InputRearm,
+}
+
+// wakeTransitions 状态转换表；表中没有的 (状态, 输入) 组合保持原状态
+var wakeTransitions = map[WakeState]map[WakeInput]WakeState{
+	StateAwake: {
+		InputIdle:        StateDozing,
+		InputSuspend:     StateAsleep,
+		InputResume:      StateResuming,
+		InputDeviceError: StateRepairing,
+		InputGiveUp:      StateGaveUp,
+	},
+	StateDozing: {
+		InputActive:  StateResuming,
+		InputSuspend: StateAsleep,
+		InputResume:  StateResuming,
+		InputGiveUp:  StateGaveUp,
+	},
+	StateAsleep: {
+		InputActive: StateResuming,
+		InputResume: StateResuming,
+		InputGiveUp: StateGaveUp,
+	},
+	StateResuming: {
+		InputSuspend:     StateAsleep,
+		InputDeviceOK:    StateAwake,
+		InputDeviceError: StateRepairing,
+		InputGiveUp:      StateGaveUp,
+	},
+	StateRepairing: {
+		InputSuspend:      StateAsleep,
+		InputRepairOK:     StateAwake,
+		InputRepairFailed: StateCooldown,
+		InputGiveUp:       StateGaveUp,
+	},
+	StateCooldown: {
+		InputIdle:     StateDozing,
+		InputSuspend:  StateAsleep,
+		InputResume:   StateResuming,
+		InputDeviceOK: StateAwake,
+		InputRetryDue: StateRepairing,
+		InputGiveUp:   StateGaveUp,
+	},
+	StateGaveUp: {
+		InputResume:   StateResuming,
+		InputDeviceOK: StateAwake,
+		InputRearm:    StateAwake,
+	},
+}
+
+// NextWakeState 根据转换表返回下一个状态
+func NextWakeState(from WakeState, input WakeInput) WakeState {
+	if to, ok := wakeTransitions[from][input]; ok {
+		return to
+	}
+	return from
+}
+
+// FormatWakeStateTable 以文本表格输出状态转换表（行为状态，列为输入，"·" 表示保持原状态）
+func FormatWakeStateTable() string {
+	width := len("state")
+	for _, s := range allWakeStates {
+		width = max(width, len(s))
+	}
+	colWidths := make([]int, len(allWakeInputs))
+	for i, in := range allWakeInputs {
+		colWidths[i] = len(in)
+		for _, s := range allWakeStates {
+			if to := NextWakeState(s, in); to != s {
+				colWidths[i] = max(colWidths[i], len(to))
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells ...string) {
+		row := fmt.Sprintf("%-*s", width, cells[0])
+		for i, cell := range cells[1:] {
+			// "·" 占 1 列但不止 1 个字节，按显示宽度补齐
+			row += "  " + cell + strings.Repeat(" ", colWidths[i]-len([]rune(cell)))
+		}
+		b.WriteString(strings.TrimRight(row, " ") + "\n")
+	}
+
+	header := []string{"state"}
+	for _, in := range allWakeInputs {
+		header = append(header, string(in))
+	}
+	writeRow(header...)
+	for _, s := range allWakeStates {
+		row := []string{string(s)}
+		for _, in := range allWakeInputs {
+			cell := "·"
+			if to := NextWakeState(s, in); to != s {
+				cell = string(to)
+			}
+			row = append(row, cell)
+		}
+		writeRow(row...)
+	}
+	return b.String()
+}
+
+// WakeTransition 一次状态转换
+type WakeTransition struct {
+	From   WakeState
+	To     WakeState
+	Input  WakeInput
+	Reason string
+	At     time.Time
+}
+
+// Changed 状态是否发生变化
+func (t WakeTransition) Changed() bool {
+	return t.From != t.To
+}
+
+// WakeStateMachine 睡眠/唤醒状态机
+// 电源监控器（电源事件）和轮询器（空闲时间、设备状态、修复结果）向它输入事件，
+// 由当前状态决定是否检查和修复设备
+type WakeStateMachine struct {
+	mu        sync.Mutex
+	state     WakeState
+	since     time.Time
+	clock     Clock
+	logger    TagLogger
+	listeners []func(WakeTransition)
+}
+
+// NewWakeStateMachine 创建状态机，初始状态为 awake
+func NewWakeStateMachine(clock Clock) *WakeStateMachine {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &WakeStateMachine{state: StateAwake, since: clock.Now(), clock: clock}
+}
+
+// SetLogger 设置状态转换日志输出
+func (m *WakeStateMachine) SetLogger(logger TagLogger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = logger
+}
+
+// OnTransition 注册状态变化时的回调（在 Fire 返回前同步调用，回调中可以再次调用 Fire）
+func (m *WakeStateMachine) OnTransition(fn func(WakeTransition)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// State 当前状态
+func (m *WakeStateMachine) State() WakeState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Since 进入当前状态的时间
+func (m *WakeStateMachine) Since() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.since
+}
+
+// Restore 恢复服务重启前保存的状态（不经过转换表，也不通知回调）
+func (m *WakeStateMachine) Restore(state WakeState, since time.Time) {
+	m.mu.Lock()
+	from := m.state
+	m.state = state
+	m.since = since
+	logger := m.logger
+	m.mu.Unlock()
+
+	if logger != nil && from != state {
+		logger.LogTag(context.Background(), INFO, TagState, "恢复状态: %s → %s (自 %s)", from, state, since.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// Fire 输入一个事件，返回发生的转换（状态未变化时 From == To）
+func (m *WakeStateMachine) Fire(input WakeInput, reason string) WakeTransition {
+	m.mu.Lock()
+	t := WakeTransition{From: m.state, To: NextWakeState(m.state, input), Input: input, Reason: reason, At: m.clock.Now()}
+	if !t.Changed() {
+		m.mu.Unlock()
+		return t
+	}
+	m.state = t.To
+	m.since = t.At
+	logger := m.logger
+	listeners := append([]func(WakeTransition){}, m.listeners...)
+	m.mu.Unlock()
+
+	if logger != nil {
+		if reason != "" {
+			logger.LogTag(context.Background(), INFO, TagState, "状态转换: %s → %s (输入: %s, 原因: %s)", t.From, t.To, input, reason)
+		} else {
+			logger.LogTag(context.Background(), INFO, TagState, "状态转换: %s → %s (输入: %s)", t.From, t.To, input)
+		}
+	}
+	for _, fn := range listeners {
+		fn(t)
+	}
+	return t
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// wakeStateTableGolden 状态转换表（修改转换规则时同步更新，并确认 README/日志说明仍然成立）
+const wakeStateTableGolden = `state      idle    active    suspend  resume    device_ok  device_error  repair_ok  repair_failed  retry_due  give_up  rearm
+awake      dozing  ·         asleep   resuming  ·          repairing     ·          ·              ·          gave_up  ·
+dozing     ·       resuming  asleep   resuming  ·          ·             ·          ·              ·          gave_up  ·
+asleep     ·       resuming  ·        resuming  ·          ·             ·          ·              ·          gave_up  ·
+resuming   ·       ·         asleep   ·         awake      repairing     ·          ·              ·          gave_up  ·
+repairing  ·       ·         asleep   ·         ·          ·             awake      cooldown       ·          gave_up  ·
+cooldown   dozing  ·         asleep   resuming  awake      ·             ·          ·              repairing  gave_up  ·
+gave_up    ·       ·         ·        resuming  awake      ·             ·          ·              ·          ·        awake
+`
+
+func TestFormatWakeStateTable(t *testing.T) {
+	if got := FormatWakeStateTable(); got != wakeStateTableGolden {
+		t.Errorf("状态转换表变化:\n%s\nwant:\n%s", got, wakeStateTableGolden)
+	}
+}
+
+func TestNextWakeState_Invariants(t *testing.T) {
+	for _, from := range allWakeStates {
+		for _, in := range allWakeInputs {
+			to := NextWakeState(from, in)
+			if to == StateRepairing && from != StateRepairing && in != InputDeviceError && in != InputRetryDue {
+				t.Errorf("%s --%s--> repairing: 只有发现异常或重试到期才能开始修复", from, in)
+			}
+			if from == StateAsleep && (to == StateRepairing || to == StateAwake) {
+				t.Errorf("asleep --%s--> %s: 睡眠中必须先经过 resuming", in, to)
+			}
+			if in == InputGiveUp && to != StateGaveUp {
+				t.Errorf("%s --give_up--> %s, want gave_up", from, to)
+			}
+		}
+	}
+}
+
+func TestWakeStateMachine_Sequences(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []WakeInput
+		want   []WakeState // 每个输入之后的状态
+	}{
+		{
+			name:   "活跃时设备异常立即修复",
+			inputs: []WakeInput{InputActive, InputDeviceError, InputRepairOK, InputDeviceOK},
+			want:   []WakeState{StateAwake, StateRepairing, StateAwake, StateAwake},
+		},
+		{
+			name:   "空闲时变异常，用户回来后补修复",
+			inputs: []WakeInput{InputIdle, InputDeviceError, InputDeviceError, InputActive, InputDeviceError, InputRepairOK},
+			want:   []WakeState{StateDozing, StateDozing, StateDozing, StateResuming, StateRepairing, StateAwake},
+		},
+		{
+			name:   "空闲后回来设备正常",
+			inputs: []WakeInput{InputIdle, InputActive, InputDeviceOK},
+			want:   []WakeState{StateDozing, StateResuming, StateAwake},
+		},
+		{
+			name:   "Modern Standby 睡眠：显示器关闭到开启",
+			inputs: []WakeInput{InputIdle, InputSuspend, InputDeviceError, InputIdle, InputResume, InputDeviceError, InputRepairOK},
+			want:   []WakeState{StateDozing, StateAsleep, StateAsleep, StateAsleep, StateResuming, StateRepairing, StateAwake},
+		},
+		{
+			name:   "修复失败退避后重试",
+			inputs: []WakeInput{InputDeviceError, InputRepairFailed, InputDeviceError, InputRetryDue, InputRepairFailed, InputRetryDue, InputRepairOK},
+			want:   []WakeState{StateRepairing, StateCooldown, StateCooldown, StateRepairing, StateCooldown, StateRepairing, StateAwake},
+		},
+		{
+			name:   "退避期间设备自行恢复",
+			inputs: []WakeInput{InputDeviceError, InputRepairFailed, InputDeviceOK},
+			want:   []WakeState{StateRepairing, StateCooldown, StateAwake},
+		},
+		{
+			name:   "退避期间用户离开，回来后再修复",
+			inputs: []WakeInput{InputDeviceError, InputRepairFailed, InputIdle, InputRetryDue, InputActive, InputDeviceError},
+			want:   []WakeState{StateRepairing, StateCooldown, StateDozing, StateDozing, StateResuming, StateRepairing},
+		},
+		{
+			name:   "达到上限后停止，只接受恢复条件",
+			inputs: []WakeInput{InputDeviceError, InputGiveUp, InputDeviceError, InputRetryDue, InputIdle, InputSuspend, InputRearm},
+			want:   []WakeState{StateRepairing, StateGaveUp, StateGaveUp, StateGaveUp, StateGaveUp, StateGaveUp, StateAwake},
+		},
+		{
+			name:   "停止后唤醒重新检查",
+			inputs: []WakeInput{InputDeviceError, InputGiveUp, InputResume, InputDeviceError},
+			want:   []WakeState{StateRepairing, StateGaveUp, StateResuming, StateRepairing},
+		},
+		{
+			name:   "修复过程中系统挂起",
+			inputs: []WakeInput{InputDeviceError, InputSuspend, InputRepairFailed, InputRepairOK, InputResume, InputDeviceOK},
+			want:   []WakeState{StateRepairing, StateAsleep, StateAsleep, StateAsleep, StateResuming, StateAwake},
+		},
+		{
+			name:   "重复的恢复事件",
+			inputs: []WakeInput{InputSuspend, InputResume, InputResume, InputActive, InputDeviceOK},
+			want:   []WakeState{StateAsleep, StateResuming, StateResuming, StateResuming, StateAwake},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewWakeStateMachine(newFakeClock())
+			for i, in := range tt.inputs {
+				m.Fire(in, "")
+				if got := m.State(); got != tt.want[i] {
+					t.Fatalf("第 %d 个输入 %s 后状态 = %s, want %s", i+1, in, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWakeStateMachine_TransitionLogAndListener(t *testing.T) {
+	clock := newFakeClock()
+	m := NewWakeStateMachine(clock)
+	logger := &recordingTagLogger{}
+	m.SetLogger(logger)
+
+	var seen []WakeTransition
+	m.OnTransition(func(tr WakeTransition) {
+		seen = append(seen, tr)
+		// 回调中可以继续输入事件
+		if tr.To == StateResuming {
+			m.Fire(InputDeviceOK, "设备状态正常")
+		}
+	})
+
+	clock.Sleep(time.Minute)
+	m.Fire(InputSuspend, "显示器关闭")
+	m.Fire(InputSuspend, "显示器关闭") // 状态不变，不记录
+	clock.Sleep(8 * time.Hour)
+	m.Fire(InputResume, "显示器开启")
+
+	if m.State() != StateAwake {
+		t.Errorf("State = %s, want awake", m.State())
+	}
+	if !m.Since().Equal(clock.Now()) {
+		t.Errorf("Since = %s, want %s", m.Since(), clock.Now())
+	}
+	if len(seen) != 3 {
+		t.Fatalf("回调 %d 次, want 3: %+v", len(seen), seen)
+	}
+	if seen[1].From != StateAsleep || seen[1].To != StateResuming || seen[1].Input != InputResume || seen[1].Reason != "显示器开启" {
+		t.Errorf("第 2 次转换 = %+v", seen[1])
+	}
+
+	log := logger.String()
+	if n := strings.Count(log, "[STATE]"); n != 3 {
+		t.Errorf("应记录 3 条状态转换日志, got %d:\n%s", n, log)
+	}
+	if !strings.Contains(log, "asleep → resuming (输入: resume, 原因: 显示器开启)") {
+		t.Errorf("日志缺少转换详情:\n%s", log)
+	}
+}
+
+func TestWakeStateMachine_Restore(t *testing.T) {
+	clock := newFakeClock()
+	m := NewWakeStateMachine(clock)
+	logger := &recordingTagLogger{}
+	m.SetLogger(logger)
+	called := false
+	m.OnTransition(func(WakeTransition) { called = true })
+
+	since := clock.Now().Add(-3 * time.Minute)
+	m.Restore(StateCooldown, since)
+	if m.State() != StateCooldown || !m.Since().Equal(since) {
+		t.Errorf("恢复后 = %s (自 %s)", m.State(), m.Since())
+	}
+	if called {
+		t.Error("恢复状态不应通知回调")
+	}
+	if !strings.Contains(logger.String(), "恢复状态: awake → cooldown") {
+		t.Errorf("日志缺少恢复记录:\n%s", logger.String())
+	}
+
+	// 恢复后按转换表继续
+	m.Fire(InputRetryDue, "持续异常")
+	if m.State() != StateRepairing {
+		t.Errorf("State = %s, want repairing", m.State())
+	}
+}
@@ -0,0 +1,136 @@
+// Package main provides recording and replay of wake-event traces in JSONL format.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WakeTraceRecorder 将电源事件逐行写入 JSONL 轨迹文件，用于在其他机器上回放
+type WakeTraceRecorder struct {
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File // 由 OpenWakeTraceRecorder 打开时负责关闭
+}
+
+// NewWakeTraceRecorder 创建写入 w 的轨迹记录器
+func NewWakeTraceRecorder(w io.Writer) *WakeTraceRecorder {
+	return &WakeTraceRecorder{w: w}
+}
+
+// OpenWakeTraceRecorder 以追加方式打开轨迹文件
+func OpenWakeTraceRecorder(path string) (*WakeTraceRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建轨迹目录失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开轨迹文件失败: %w", err)
+	}
+	return &WakeTraceRecorder{w: f, file: f}, nil
+}
+
+// Record 写入一条事件
+func (r *WakeTraceRecorder) Record(e WakeEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入轨迹失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭轨迹文件（写入外部 Writer 时不做任何事）
+func (r *WakeTraceRecorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// ReadWakeTrace 读取 JSONL 轨迹（跳过空行，任何一行无法解析时返回带行号的错误）
+func ReadWakeTrace(rd io.Reader) ([]WakeEvent, error) {
+	var events []WakeEvent
+	scanner := bufio.NewScanner(rd)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Bytes()
+		if len(text) == 0 {
+			continue
+		}
+		var e WakeEvent
+		if err := json.Unmarshal(text, &e); err != nil {
+			return nil, fmt.Errorf("解析轨迹第 %d 行失败: %w", line, err)
+		}
+		if e.Kind == "" {
+			return nil, fmt.Errorf("轨迹第 %d 行缺少 kind", line)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取轨迹失败: %w", err)
+	}
+	return events, nil
+}
+
+// LoadWakeTrace 读取轨迹文件
+func LoadWakeTrace(path string) ([]WakeEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开轨迹文件失败: %w", err)
+	}
+	defer f.Close()
+	return ReadWakeTrace(f)
+}
+
+// WakeTraceReplayer 按记录时的间隔重新发出轨迹中的事件
+// 使用假时钟时间隔只推进时钟，测试中回放数小时的轨迹也是瞬间完成
+type WakeTraceReplayer struct {
+	events []WakeEvent
+	clock  Clock
+	ctx    context.Context
+	once   sync.Once
+	ch     chan WakeEvent
+}
+
+// NewWakeTraceReplayer 创建回放器；ctx 取消时停止回放并关闭事件通道
+func NewWakeTraceReplayer(ctx context.Context, events []WakeEvent, clock Clock) *WakeTraceReplayer {
+	return &WakeTraceReplayer{events: events, clock: clock, ctx: ctx, ch: make(chan WakeEvent)}
+}
+
+// Events 返回事件通道（第一次调用时开始回放，回放结束后关闭）
+func (r *WakeTraceReplayer) Events() <-chan WakeEvent {
+	r.once.Do(func() { go r.run() })
+	return r.ch
+}
+
+// run 依次发出事件
+func (r *WakeTraceReplayer) run() {
+	defer close(r.ch)
+	var prev time.Time
+	for _, e := range r.events {
+		if !prev.IsZero() && e.Time.After(prev) {
+			r.clock.Sleep(e.Time.Sub(prev))
+		}
+		if !e.Time.IsZero() {
+			prev = e.Time
+		}
+		select {
+		case r.ch <- e:
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
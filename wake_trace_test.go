@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWakeTraceRecorder_RoundTrip(t *testing.T) {
+	now := time.Date(2025, 12, 25, 7, 45, 12, 0, time.FixedZone("CST", 8*3600))
+	events := []WakeEvent{
+		DecodePowerEvent(now, PBTAPMPowerStatusChange, "", 0),
+		DecodePowerEvent(now.Add(time.Second), PBTPowerSettingChange, "{6FE69556-704A-47A0-8F24-C28D936FDA47}", DisplayStateOn),
+	}
+
+	var buf bytes.Buffer
+	rec := NewWakeTraceRecorder(&buf)
+	for _, e := range events {
+		if err := rec.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("应写入 2 行 JSONL, got %d:\n%s", n, buf.String())
+	}
+
+	got, err := ReadWakeTrace(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(events) {
+		t.Fatalf("读取 %d 条, want %d", len(got), len(events))
+	}
+	for i := range events {
+		if !got[i].Time.Equal(events[i].Time) || got[i].Kind != events[i].Kind || got[i].Setting != events[i].Setting || got[i].Value != events[i].Value {
+			t.Errorf("事件 %d = %+v, want %+v", i, got[i], events[i])
+		}
+	}
+}
+
+func TestOpenWakeTraceRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "wake.jsonl")
+	for i := 0; i < 2; i++ {
+		rec, err := OpenWakeTraceRecorder(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.Record(WakeEvent{Time: time.Now(), Kind: WakeOEM, EventType: PBTAPMOEMEvent}); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := LoadWakeTrace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Errorf("重新打开后应追加写入, got %d 条", len(events))
+	}
+}
+
+func TestReadWakeTrace_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"无效 JSON", "{\"kind\":\"oem\"}\n{oops\n", "第 2 行"},
+		{"缺少 kind", "{\"time\":\"2025-12-25T07:45:12Z\"}\n", "缺少 kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadWakeTrace(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestWakeTraceReplayer_ModernStandby 回放一晚 Modern Standby 睡眠的轨迹：
+// 显示器调暗 → 关闭 → 早上电源状态改变和显示器开启（两个 GUID 各一次）→ 午间挂起和两次恢复
+func TestWakeTraceReplayer_ModernStandby(t *testing.T) {
+	events, err := LoadWakeTrace(filepath.Join("testdata", "wake_traces", "modern_standby.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 9 {
+		t.Fatalf("轨迹应有 9 条事件, got %d", len(events))
+	}
+
+	clock := newFakeClock()
+	start := clock.Now()
+	replayer := NewWakeTraceReplayer(context.Background(), events, clock)
+	pm := NewPowerMonitor(NewWakeStateMachine(clock), nil)
+
+	var wakes []time.Time
+	var source WakeEventSource = replayer
+	for e := range source.Events() {
+		if pm.HandleWakeEvent(e) {
+			wakes = append(wakes, e.Time)
+		}
+	}
+
+	want := []string{"2025-12-25T07:45:13+08:00", "2025-12-25T13:30:00+08:00"}
+	if len(wakes) != len(want) {
+		t.Fatalf("识别到 %d 次唤醒 %v, want %v", len(wakes), wakes, want)
+	}
+	for i := range want {
+		if got := wakes[i].Format(time.RFC3339); got != want[i] {
+			t.Errorf("第 %d 次唤醒 = %s, want %s", i+1, got, want[i])
+		}
+	}
+
+	// 按记录的间隔推进时钟
+	if elapsed := clock.Now().Sub(start); elapsed != 14*time.Hour+20*time.Minute+time.Second {
+		t.Errorf("回放耗时 = %s, want 14h20m1s", elapsed)
+	}
+}
+
+func TestWakeTraceReplayer_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := []WakeEvent{{Kind: WakeSuspend}, {Kind: WakeResumeAutomatic}}
+	replayer := NewWakeTraceReplayer(ctx, events, newFakeClock())
+
+	ch := replayer.Events()
+	<-ch
+	cancel()
+	for range ch {
+	}
+}